@@ -0,0 +1,75 @@
+package inbound_test
+
+import (
+	"testing"
+	"time"
+
+	"fsos-server/internal/adapters/inbound"
+)
+
+func TestParseCron_Next(t *testing.T) {
+	// Monday 19 October 2026, 10:07 UTC.
+	from := time.Date(2026, 10, 19, 10, 7, 30, 0, time.UTC)
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 3 * * *", time.Date(2026, 10, 20, 3, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 10, 19, 11, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 19, 10, 15, 0, 0, time.UTC)},
+		{"0 9 * * MON", time.Date(2026, 10, 26, 9, 0, 0, 0, time.UTC)},
+		{"30 8 * * sat,sun", time.Date(2026, 10, 24, 8, 30, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 JAN *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"5/20 10 * * *", time.Date(2026, 10, 19, 10, 25, 0, 0, time.UTC)},
+		{"0 12 1,15 * *", time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matches (Friday 23rd comes first).
+		{"0 0 13 * 5", time.Date(2026, 10, 23, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		cron, err := inbound.ParseCron(c.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", c.expr, err)
+			continue
+		}
+		if got := cron.Next(from); !got.Equal(c.want) {
+			t.Errorf("%q: Next = %v, want %v", c.expr, got, c.want)
+		}
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "@often"} {
+		if _, err := inbound.ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q): expected an error", expr)
+		}
+	}
+}
+
+func TestParseCron_NeverFires(t *testing.T) {
+	cron, err := inbound.ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := cron.Next(time.Now()); !next.IsZero() {
+		t.Errorf("Next = %v, want zero for 30 February", next)
+	}
+}
+
+// Next works in the location of the time it is given.
+func TestParseCron_TimeZone(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skip("no tz database:", err)
+	}
+	cron, _ := inbound.ParseCron("0 3 * * *")
+	from := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	got := cron.Next(from.In(madrid))
+	if want := time.Date(2026, 10, 20, 1, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next = %v, want 03:00 Madrid (%v)", got, want)
+	}
+}
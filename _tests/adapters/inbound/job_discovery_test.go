@@ -58,8 +58,119 @@ func TestDiscoverJobs_ReadsHeaders(t *testing.T) {
 	}
 }
 
+func TestDiscoverJobs_CronAndRunOptions(t *testing.T) {
+	scriptsDir := t.TempDir()
+	jobsDir := filepath.Join(scriptsDir, "jobs")
+	if err := os.MkdirAll(jobsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, body string) {
+		if err := os.WriteFile(filepath.Join(jobsDir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("nightly.lua", `-- @job cron="0 3 * * *" tz=UTC jitter=60 overlap=queue`+"\n")
+	write("hourly.lua", "-- @job cron=@hourly delay=30\n")
+	write("warmup.lua", "-- @job interval=60 delay=5 overlap=skip\n")
+	write("badcron.lua", `-- @job cron="0 25 * * *"`+"\n")
+	write("badtz.lua", `-- @job cron=@daily tz=Mars/Olympus`+"\n")
+	write("badoverlap.lua", "-- @job interval=60 overlap=parallel\n")
+	write("tzonly.lua", "-- @job interval=60 tz=UTC\n")
+
+	got := map[string]inbound.ScheduledJob{}
+	for _, j := range inbound.DiscoverJobs(scriptsDir, &testutil.MockLogger{}) {
+		got[j.Name] = j
+	}
+
+	want := map[string]inbound.ScheduledJob{
+		"nightly": {Name: "nightly", Cron: "0 3 * * *", TimeZone: "UTC", Jitter: time.Minute, Overlap: inbound.OverlapQueue},
+		"hourly":  {Name: "hourly", Cron: "@hourly", Delay: 30 * time.Second},
+		"warmup":  {Name: "warmup", Interval: time.Minute, Delay: 5 * time.Second, Overlap: inbound.OverlapSkip},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d jobs %v, want %d", len(got), got, len(want))
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("%s = %+v, want %+v", name, got[name], w)
+		}
+	}
+}
+
 func TestDiscoverJobs_MissingDir(t *testing.T) {
 	if jobs := inbound.DiscoverJobs(t.TempDir(), &testutil.MockLogger{}); jobs != nil {
 		t.Fatalf("expected nil for missing jobs dir, got %v", jobs)
 	}
 }
+
+// ValidateJobHeaders flags the headers DiscoverJobs would skip, with their
+// line, including those of disabled jobs.
+func TestValidateJobHeaders_FlagsInvalidHeaders(t *testing.T) {
+	scriptsDir := t.TempDir()
+	jobsDir := filepath.Join(scriptsDir, "jobs")
+	if err := os.MkdirAll(jobsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{
+		"ok.lua":      "-- @job interval=60\n",
+		"helper.lua":  "return {}\n",
+		"badcron.lua": "-- nightly cleanup\n-- @job cron=\"61 * * * *\"\n",
+		"off.lua":     "-- @job interval=abc enabled=false\n",
+	} {
+		if err := os.WriteFile(filepath.Join(jobsDir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := map[string]int{}
+	for _, p := range inbound.ValidateJobHeaders(scriptsDir) {
+		got[filepath.Base(p.Path)] = p.Line
+	}
+	want := map[string]int{"badcron.lua": 2, "off.lua": 1}
+	if len(got) != len(want) || got["badcron.lua"] != 2 || got["off.lua"] != 1 {
+		t.Errorf("problems = %v, want %v", got, want)
+	}
+	if problems := inbound.ValidateJobHeaders(t.TempDir()); problems != nil {
+		t.Errorf("missing jobs dir: %v", problems)
+	}
+}
+
+// DiscoverInterceptors lists <scriptsDir>/interceptors/*.lua in name order;
+// the optional "-- @interceptor phase=" header picks the phases.
+func TestDiscoverInterceptors_OrderAndPhases(t *testing.T) {
+	scriptsDir := t.TempDir()
+	dir := filepath.Join(scriptsDir, "interceptors")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{
+		"20_log.lua":    "-- @interceptor phase=after\n",
+		"10_filter.lua": "-- chat filter\n",
+		"30_audit.lua":  "-- @interceptor phase=both\n",
+		"notes.txt":     "not a script",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := inbound.DiscoverInterceptors(scriptsDir, &testutil.MockLogger{})
+	want := []inbound.InterceptorScript{
+		{Name: "10_filter", Before: true},
+		{Name: "20_log", After: true},
+		{Name: "30_audit", Before: true, After: true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("DiscoverInterceptors = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("interceptor %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if none := inbound.DiscoverInterceptors(t.TempDir(), nil); len(none) != 0 {
+		t.Errorf("missing directory = %+v, want none", none)
+	}
+}
@@ -92,3 +92,73 @@ func TestMetricsServer_Counters(t *testing.T) {
 		t.Errorf("active_connections = %v, want 0", metrics["active_connections"])
 	}
 }
+
+func TestMetricsServer_JobEndpoints(t *testing.T) {
+	engine := &countingEngine{}
+	scheduler := inbound.NewScheduler(engine, []inbound.ScheduledJob{
+		{Name: "cleanup", Cron: "@daily", TimeZone: "UTC"},
+	}, &testutil.MockLogger{})
+	defer scheduler.Stop()
+
+	ms := inbound.NewMetricsServer("18933", "127.0.0.1", testutil.NewMockSessionStore(), &testutil.MockLogger{})
+	go ms.Start()
+	defer ms.Shutdown()
+	time.Sleep(50 * time.Millisecond)
+
+	resp, err := http.Get("http://localhost:18933/jobs")
+	if err != nil {
+		t.Fatalf("jobs request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("GET /jobs without scheduler = %d, want 503", resp.StatusCode)
+	}
+
+	ms.UseScheduler(scheduler)
+
+	// The metrics listener is read-only: runs are triggered from the console.
+	resp, err = http.Post("http://localhost:18933/jobs/run?name=cleanup", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("POST /jobs/run = %d, want 404", resp.StatusCode)
+	}
+	if len(scheduler.History("cleanup")) != 0 {
+		t.Fatal("POST /jobs/run must not run the job")
+	}
+
+	if err := scheduler.Trigger("cleanup"); err != nil {
+		t.Fatalf("trigger cleanup: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(scheduler.History("cleanup")) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	resp, err = http.Get("http://localhost:18933/jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Jobs []struct {
+			Name     string `json:"name"`
+			Schedule string `json:"schedule"`
+			Runs     []struct {
+				Manual bool   `json:"manual"`
+				Error  string `json:"error"`
+			} `json:"runs"`
+		} `json:"jobs"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Jobs) != 1 || body.Jobs[0].Name != "cleanup" || body.Jobs[0].Schedule != "cron @daily UTC" {
+		t.Fatalf("jobs = %+v", body.Jobs)
+	}
+	if runs := body.Jobs[0].Runs; len(runs) != 1 || !runs[0].Manual || runs[0].Error != "" {
+		t.Errorf("runs = %+v, want one successful manual run", runs)
+	}
+}
@@ -85,6 +85,39 @@ func TestDBPlayer_OwnData_AllowedForNonAdmin(t *testing.T) {
 	}
 }
 
+// DBUser data follows the same owner-or-admin rule as DBPlayer.
+func TestDBUser_CrossUser_DeniedForNonAdmin(t *testing.T) {
+	setCalled := false
+	db := &testutil.MockDBAdapter{
+		SetUserAttributeFunc: func(user, attr string, value lingo.LValue) error {
+			setCalled = true
+			return nil
+		},
+	}
+	svc := newNonAdminService(t, db, "lowuser")
+
+	for _, target := range []string{"victim", "lowuser"} {
+		plist := lingo.NewLPropList()
+		plist.AddElement(lingo.NewLSymbol("userID"), lingo.NewLString(target))
+		plist.AddElement(lingo.NewLSymbol("attribute"), lingo.NewLString("title"))
+		plist.AddElement(lingo.NewLSymbol("value"), lingo.NewLString("king"))
+		resp, err := svc.Handle("lowuser", buildDBMsg("DBUser.setAttribute", plist))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if target == "victim" {
+			if resp.ErrCode != smus.ErrInvalidServerCommand {
+				t.Errorf("cross-user ErrCode = %d, want %d (denied)", resp.ErrCode, smus.ErrInvalidServerCommand)
+			}
+			if setCalled {
+				t.Error("DB must not be written when cross-user access is denied")
+			}
+		} else if resp.ErrCode != smus.ErrNoError || !setCalled {
+			t.Errorf("own-data ErrCode = %d (called=%v), want %d", resp.ErrCode, setCalled, smus.ErrNoError)
+		}
+	}
+}
+
 // H3: a Logon for a userID that already has a live session must be refused.
 func TestLogon_DuplicateUserID_Rejected(t *testing.T) {
 	db := &testutil.MockDBAdapter{}
@@ -10,6 +10,8 @@ import (
 	"fsos-server/internal/domain/services"
 	"fsos-server/internal/domain/types/lingo"
 	"fsos-server/internal/domain/types/smus"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 // dbCommandLevels maps all DB commands to level 20 so the admin (level 80) can execute them.
@@ -18,6 +20,10 @@ var dbCommandLevels = map[string]int{
 	"DBPlayer.setAttribute":           20,
 	"DBPlayer.deleteAttribute":        20,
 	"DBPlayer.getAttributeNames":      20,
+	"DBUser.getAttribute":             20,
+	"DBUser.setAttribute":             20,
+	"DBUser.deleteAttribute":          20,
+	"DBUser.getAttributeNames":        20,
 	"DBApplication.getAttribute":      20,
 	"DBApplication.setAttribute":      20,
 	"DBApplication.deleteAttribute":   20,
@@ -27,6 +33,11 @@ var dbCommandLevels = map[string]int{
 	"DBAdmin.createUser":              80,
 	"DBAdmin.deleteUser":              80,
 	"DBAdmin.getUserCount":            80,
+	"DBAdmin.getUsers":                80,
+	"DBAdmin.getApplications":         80,
+	"DBAdmin.getBans":                 80,
+	"DBAdmin.setUserLevel":            80,
+	"DBAdmin.setPassword":             80,
 	"DBAdmin.ban":                     80,
 	"DBAdmin.revokeBan":               80,
 }
@@ -182,6 +193,97 @@ func TestDBPlayer_GetAttributeNames(t *testing.T) {
 	}
 }
 
+// --- DBUser ---
+
+func TestDBUser_SetGetAttribute(t *testing.T) {
+	var stored lingo.LValue
+	var gotUser string
+	db := &testutil.MockDBAdapter{
+		SetUserAttributeFunc: func(user, attr string, value lingo.LValue) error {
+			gotUser = user
+			stored = value
+			return nil
+		},
+		GetUserAttributeFunc: func(user, attr string) (lingo.LValue, error) {
+			return stored, nil
+		},
+	}
+
+	svc, _ := setupDBCommandsService(t, db)
+
+	setPlist := lingo.NewLPropList()
+	setPlist.AddElement(lingo.NewLSymbol("userID"), lingo.NewLString("player1"))
+	setPlist.AddElement(lingo.NewLSymbol("attribute"), lingo.NewLString("gems"))
+	setPlist.AddElement(lingo.NewLSymbol("value"), lingo.NewLInteger(7))
+	resp, err := svc.Handle("admin", buildDBMsg("DBUser.setAttribute", setPlist))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ErrCode != smus.ErrNoError {
+		t.Fatalf("ErrCode = %d, want %d", resp.ErrCode, smus.ErrNoError)
+	}
+	if gotUser != "player1" {
+		t.Errorf("SetUserAttribute userID = %q, want %q", gotUser, "player1")
+	}
+
+	getPlist := lingo.NewLPropList()
+	getPlist.AddElement(lingo.NewLSymbol("userID"), lingo.NewLString("player1"))
+	getPlist.AddElement(lingo.NewLSymbol("attribute"), lingo.NewLString("gems"))
+	resp, err = svc.Handle("admin", buildDBMsg("DBUser.getAttribute", getPlist))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ErrCode != smus.ErrNoError {
+		t.Fatalf("ErrCode = %d, want %d", resp.ErrCode, smus.ErrNoError)
+	}
+	if resp.MsgContent.ToInteger() != 7 {
+		t.Errorf("value = %d, want 7", resp.MsgContent.ToInteger())
+	}
+}
+
+func TestDBUser_DeleteAttributeAndNames(t *testing.T) {
+	deleted := false
+	db := &testutil.MockDBAdapter{
+		DeleteUserAttributeFunc: func(user, attr string) error {
+			deleted = true
+			return nil
+		},
+		GetUserAttributeNamesFunc: func(user string) ([]string, error) {
+			return []string{"gems", "title"}, nil
+		},
+	}
+
+	svc, _ := setupDBCommandsService(t, db)
+
+	delPlist := lingo.NewLPropList()
+	delPlist.AddElement(lingo.NewLSymbol("userID"), lingo.NewLString("player1"))
+	delPlist.AddElement(lingo.NewLSymbol("attribute"), lingo.NewLString("gems"))
+	resp, err := svc.Handle("admin", buildDBMsg("DBUser.deleteAttribute", delPlist))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ErrCode != smus.ErrNoError {
+		t.Fatalf("ErrCode = %d, want %d", resp.ErrCode, smus.ErrNoError)
+	}
+	if !deleted {
+		t.Error("expected DeleteUserAttribute to be called")
+	}
+
+	namesPlist := lingo.NewLPropList()
+	namesPlist.AddElement(lingo.NewLSymbol("userID"), lingo.NewLString("player1"))
+	resp, err = svc.Handle("admin", buildDBMsg("DBUser.getAttributeNames", namesPlist))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	list, ok := resp.MsgContent.(*lingo.LList)
+	if !ok {
+		t.Fatalf("content type = %T, want *lingo.LList", resp.MsgContent)
+	}
+	if len(list.Values) != 2 {
+		t.Fatalf("names count = %d, want 2", len(list.Values))
+	}
+}
+
 // --- DBApplication ---
 
 func TestDBApplication_SetGetAttribute(t *testing.T) {
@@ -395,7 +497,9 @@ func TestDBAdmin_DeleteUser(t *testing.T) {
 }
 
 func TestDBAdmin_GetUserCount(t *testing.T) {
-	db := &testutil.MockDBAdapter{}
+	db := &testutil.MockDBAdapter{
+		CountUsersFunc: func() (int, error) { return 1234, nil },
+	}
 	svc, _ := setupDBCommandsService(t, db)
 
 	resp, err := svc.Handle("admin", buildDBMsg("DBAdmin.getUserCount", lingo.NewLVoid()))
@@ -405,9 +509,121 @@ func TestDBAdmin_GetUserCount(t *testing.T) {
 	if resp.ErrCode != smus.ErrNoError {
 		t.Fatalf("ErrCode = %d, want %d", resp.ErrCode, smus.ErrNoError)
 	}
-	count := resp.MsgContent.ToInteger()
-	if count < 1 {
-		t.Errorf("user count = %d, want >= 1", count)
+	if count := resp.MsgContent.ToInteger(); count != 1234 {
+		t.Errorf("user count = %d, want 1234 (registered accounts, not sessions)", count)
+	}
+}
+
+func TestDBAdmin_GetUsers_Paginated(t *testing.T) {
+	var gotOffset, gotLimit int
+	created := time.Unix(1700000000, 0)
+	db := &testutil.MockDBAdapter{
+		ListUsersFunc: func(offset, limit int) ([]ports.User, error) {
+			gotOffset, gotLimit = offset, limit
+			return []ports.User{
+				{Username: "alice", UserLevel: 20, CreatedAt: created},
+				{Username: "bob", UserLevel: 80, CreatedAt: created},
+			}, nil
+		},
+	}
+	svc, _ := setupDBCommandsService(t, db)
+
+	plist := lingo.NewLPropList()
+	plist.AddElement(lingo.NewLSymbol("offset"), lingo.NewLInteger(10))
+	plist.AddElement(lingo.NewLSymbol("limit"), lingo.NewLInteger(2))
+	resp, err := svc.Handle("admin", buildDBMsg("DBAdmin.getUsers", plist))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ErrCode != smus.ErrNoError {
+		t.Fatalf("ErrCode = %d, want %d", resp.ErrCode, smus.ErrNoError)
+	}
+	if gotOffset != 10 || gotLimit != 2 {
+		t.Errorf("ListUsers(%d, %d), want (10, 2)", gotOffset, gotLimit)
+	}
+	list, ok := resp.MsgContent.(*lingo.LList)
+	if !ok || len(list.Values) != 2 {
+		t.Fatalf("content = %v, want 2-entry list", resp.MsgContent)
+	}
+	second := list.Values[1].(*lingo.LPropList)
+	name, _ := second.GetElement("userID")
+	level, _ := second.GetElement("userLevel")
+	createdAt, _ := second.GetElement("createdAt")
+	if lingo.StringValue(name) != "bob" || level.ToInteger() != 80 || createdAt.ToInteger() != 1700000000 {
+		t.Errorf("entry = %v, want bob/80/1700000000", second)
+	}
+
+	// Without paging parameters a default page size applies.
+	if _, err := svc.Handle("admin", buildDBMsg("DBAdmin.getUsers", lingo.NewLVoid())); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotOffset != 0 || gotLimit <= 0 {
+		t.Errorf("default page = (%d, %d), want offset 0 and a positive limit", gotOffset, gotLimit)
+	}
+}
+
+func TestDBAdmin_GetApplications(t *testing.T) {
+	db := &testutil.MockDBAdapter{
+		ListApplicationsFunc: func(offset, limit int) ([]string, error) {
+			return []string{"chess", "poker"}, nil
+		},
+	}
+	svc, _ := setupDBCommandsService(t, db)
+
+	resp, err := svc.Handle("admin", buildDBMsg("DBAdmin.getApplications", lingo.NewLVoid()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	list, ok := resp.MsgContent.(*lingo.LList)
+	if resp.ErrCode != smus.ErrNoError || !ok || len(list.Values) != 2 || lingo.StringValue(list.Values[1]) != "poker" {
+		t.Errorf("resp = %d %v, want [chess, poker]", resp.ErrCode, resp.MsgContent)
+	}
+}
+
+func TestDBAdmin_GetBans(t *testing.T) {
+	var gotActiveOnly []bool
+	username := "baduser"
+	ip := "10.0.0.9"
+	expires := time.Unix(1800000000, 0)
+	db := &testutil.MockDBAdapter{
+		ListBansFunc: func(offset, limit int, activeOnly bool) ([]ports.Ban, error) {
+			gotActiveOnly = append(gotActiveOnly, activeOnly)
+			return []ports.Ban{
+				{UUID: "b-1", Username: &username, Reason: "cheating", ExpiresAt: &expires, CreatedAt: time.Unix(1700000000, 0)},
+				{UUID: "b-2", IPAddress: &ip, Reason: "spam", CreatedAt: time.Unix(1700000000, 0)},
+			}, nil
+		},
+	}
+	svc, _ := setupDBCommandsService(t, db)
+
+	resp, err := svc.Handle("admin", buildDBMsg("DBAdmin.getBans", lingo.NewLVoid()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	list, ok := resp.MsgContent.(*lingo.LList)
+	if resp.ErrCode != smus.ErrNoError || !ok || len(list.Values) != 2 {
+		t.Fatalf("resp = %d %v, want 2 bans", resp.ErrCode, resp.MsgContent)
+	}
+	first := list.Values[0].(*lingo.LPropList)
+	user, _ := first.GetElement("userID")
+	exp, _ := first.GetElement("expiresAt")
+	if lingo.StringValue(user) != "baduser" || exp.ToInteger() != 1800000000 {
+		t.Errorf("first ban = %v, want baduser expiring at 1800000000", first)
+	}
+	second := list.Values[1].(*lingo.LPropList)
+	addr, _ := second.GetElement("ipAddress")
+	noUser, _ := second.GetElement("userID")
+	if lingo.StringValue(addr) != ip || noUser.GetType() != lingo.VtVoid {
+		t.Errorf("second ban = %v, want IP-only ban", second)
+	}
+
+	plist := lingo.NewLPropList()
+	plist.AddElement(lingo.NewLSymbol("activeOnly"), lingo.NewLInteger(0))
+	if _, err := svc.Handle("admin", buildDBMsg("DBAdmin.getBans", plist)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(gotActiveOnly) != 2 || !gotActiveOnly[0] || gotActiveOnly[1] {
+		t.Errorf("activeOnly calls = %v, want [true false]", gotActiveOnly)
 	}
 }
 
@@ -440,6 +656,191 @@ func TestDBAdmin_Ban(t *testing.T) {
 	}
 }
 
+func TestDBAdmin_Ban_ByIPWithDuration(t *testing.T) {
+	var gotUser *int64
+	var gotIP *string
+	var gotExpires *time.Time
+	db := &testutil.MockDBAdapter{
+		CreateBanFunc: func(userID *int64, ipAddress *string, reason string, expiresAt *time.Time) error {
+			gotUser, gotIP, gotExpires = userID, ipAddress, expiresAt
+			return nil
+		},
+	}
+	svc, _ := setupDBCommandsService(t, db)
+
+	plist := lingo.NewLPropList()
+	plist.AddElement(lingo.NewLSymbol("ipAddress"), lingo.NewLString("10.0.0.9"))
+	plist.AddElement(lingo.NewLSymbol("reason"), lingo.NewLString("flooding"))
+	plist.AddElement(lingo.NewLSymbol("duration"), lingo.NewLInteger(3600))
+	before := time.Now()
+	resp, err := svc.Handle("admin", buildDBMsg("DBAdmin.ban", plist))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ErrCode != smus.ErrNoError {
+		t.Fatalf("ErrCode = %d, want %d", resp.ErrCode, smus.ErrNoError)
+	}
+	if gotUser != nil || gotIP == nil || *gotIP != "10.0.0.9" {
+		t.Errorf("ban target = %v/%v, want IP-only 10.0.0.9", gotUser, gotIP)
+	}
+	if gotExpires == nil || gotExpires.Before(before.Add(time.Hour)) || gotExpires.After(time.Now().Add(time.Hour)) {
+		t.Errorf("expiresAt = %v, want about one hour from now", gotExpires)
+	}
+}
+
+func TestDBAdmin_Ban_RequiresTarget(t *testing.T) {
+	called := false
+	db := &testutil.MockDBAdapter{
+		CreateBanFunc: func(userID *int64, ipAddress *string, reason string, expiresAt *time.Time) error {
+			called = true
+			return nil
+		},
+	}
+	svc, _ := setupDBCommandsService(t, db)
+
+	plist := lingo.NewLPropList()
+	plist.AddElement(lingo.NewLSymbol("reason"), lingo.NewLString("nobody"))
+	resp, err := svc.Handle("admin", buildDBMsg("DBAdmin.ban", plist))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ErrCode != smus.ErrInvalidMessageFormat || called {
+		t.Errorf("ErrCode = %d (called=%v), want %d without a ban", resp.ErrCode, called, smus.ErrInvalidMessageFormat)
+	}
+}
+
+// userAtLevel stubs GetUser with accounts at the given levels.
+func userAtLevel(levels map[string]int) func(string) (*ports.User, error) {
+	return func(username string) (*ports.User, error) {
+		level, ok := levels[username]
+		if !ok {
+			return nil, ports.ErrUserNotFound
+		}
+		return &ports.User{Username: username, UserLevel: level}, nil
+	}
+}
+
+func TestDBAdmin_SetUserLevel(t *testing.T) {
+	levels := map[string]int{"mod": 20}
+	db := &testutil.MockDBAdapter{
+		GetUserFunc: userAtLevel(levels),
+		UpdateUserLevelFunc: func(username string, level int) error {
+			levels[username] = level
+			return nil
+		},
+	}
+	svc, _ := setupDBCommandsService(t, db)
+
+	set := func(user string, level int32) int32 {
+		plist := lingo.NewLPropList()
+		plist.AddElement(lingo.NewLSymbol("userID"), lingo.NewLString(user))
+		plist.AddElement(lingo.NewLSymbol("userLevel"), lingo.NewLInteger(level))
+		resp, err := svc.Handle("admin", buildDBMsg("DBAdmin.setUserLevel", plist))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp.ErrCode
+	}
+
+	if code := set("mod", 60); code != smus.ErrNoError || levels["mod"] != 60 {
+		t.Errorf("setUserLevel ErrCode = %d level = %d, want 0 and 60", code, levels["mod"])
+	}
+	// The admin is level 80 and cannot hand out more than that.
+	if code := set("mod", 100); code != smus.ErrInvalidServerCommand || levels["mod"] != 60 {
+		t.Errorf("escalation ErrCode = %d level = %d, want %d and unchanged", code, levels["mod"], smus.ErrInvalidServerCommand)
+	}
+}
+
+func TestDBAdmin_SetUserLevel_OutrankedTarget(t *testing.T) {
+	updated := false
+	db := &testutil.MockDBAdapter{
+		GetUserFunc: userAtLevel(map[string]int{"root": 100}),
+		UpdateUserLevelFunc: func(username string, level int) error {
+			updated = true
+			return nil
+		},
+	}
+	svc, _ := setupDBCommandsService(t, db)
+
+	// The admin is level 80 and may not demote a level-100 account.
+	plist := lingo.NewLPropList()
+	plist.AddElement(lingo.NewLSymbol("userID"), lingo.NewLString("root"))
+	plist.AddElement(lingo.NewLSymbol("userLevel"), lingo.NewLInteger(20))
+	resp, err := svc.Handle("admin", buildDBMsg("DBAdmin.setUserLevel", plist))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ErrCode != smus.ErrInvalidServerCommand || updated {
+		t.Errorf("ErrCode = %d updated = %v, want %d and no update", resp.ErrCode, updated, smus.ErrInvalidServerCommand)
+	}
+}
+
+func TestDBAdmin_SetUserLevel_UpdatesLiveSession(t *testing.T) {
+	svc, sessionStore := setupDBCommandsService(t, &testutil.MockDBAdapter{GetUserFunc: userAtLevel(map[string]int{"player": 20})})
+	sessionStore.RegisterConnection("player", "10.0.0.2")
+
+	plist := lingo.NewLPropList()
+	plist.AddElement(lingo.NewLSymbol("userID"), lingo.NewLString("player"))
+	plist.AddElement(lingo.NewLSymbol("userLevel"), lingo.NewLInteger(40))
+	if _, err := svc.Handle("admin", buildDBMsg("DBAdmin.setUserLevel", plist)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	level, err := sessionStore.GetUserAttribute("player", services.UserLevelAttribute)
+	if err != nil || level.ToInteger() != 40 {
+		t.Errorf("session level = %v (err=%v), want 40", level, err)
+	}
+}
+
+func TestDBAdmin_SetPassword(t *testing.T) {
+	var gotUser, gotHash string
+	db := &testutil.MockDBAdapter{
+		GetUserFunc: userAtLevel(map[string]int{"alice": 20}),
+		UpdateUserPasswordFunc: func(username, passwordHash string) error {
+			gotUser, gotHash = username, passwordHash
+			return nil
+		},
+	}
+	svc, _ := setupDBCommandsService(t, db)
+
+	plist := lingo.NewLPropList()
+	plist.AddElement(lingo.NewLSymbol("userID"), lingo.NewLString("alice"))
+	plist.AddElement(lingo.NewLSymbol("password"), lingo.NewLString("n3w-secret"))
+	resp, err := svc.Handle("admin", buildDBMsg("DBAdmin.setPassword", plist))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ErrCode != smus.ErrNoError || gotUser != "alice" {
+		t.Fatalf("ErrCode = %d user = %q, want 0 and alice", resp.ErrCode, gotUser)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(gotHash), []byte("n3w-secret")) != nil {
+		t.Error("stored hash does not match the new password")
+	}
+}
+
+func TestDBAdmin_SetPassword_OutrankedTarget(t *testing.T) {
+	updated := false
+	db := &testutil.MockDBAdapter{
+		GetUserFunc: userAtLevel(map[string]int{"root": 100}),
+		UpdateUserPasswordFunc: func(username, passwordHash string) error {
+			updated = true
+			return nil
+		},
+	}
+	svc, _ := setupDBCommandsService(t, db)
+
+	// A level-80 admin resetting a superuser's password would own the account.
+	plist := lingo.NewLPropList()
+	plist.AddElement(lingo.NewLSymbol("userID"), lingo.NewLString("root"))
+	plist.AddElement(lingo.NewLSymbol("password"), lingo.NewLString("takeover"))
+	resp, err := svc.Handle("admin", buildDBMsg("DBAdmin.setPassword", plist))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ErrCode != smus.ErrInvalidServerCommand || updated {
+		t.Errorf("ErrCode = %d updated = %v, want %d and no update", resp.ErrCode, updated, smus.ErrInvalidServerCommand)
+	}
+}
+
 func TestDBAdmin_RevokeBan(t *testing.T) {
 	var revokedBanID int64
 	db := &testutil.MockDBAdapter{
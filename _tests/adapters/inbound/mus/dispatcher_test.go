@@ -165,3 +165,102 @@ func TestDispatcher_NoRecipients_ReturnsError(t *testing.T) {
 		t.Error("expected error for message with no recipients")
 	}
 }
+
+func TestDispatcher_ScriptResult_ErrorCodeSubjectAndReplies(t *testing.T) {
+	scriptEngine := &testutil.MockScriptEngine{
+		HasScriptFunc: func(subject string) bool { return subject == "shop/buy" },
+		ExecuteFunc: func(msg *ports.ScriptMessage) (*ports.ScriptResult, error) {
+			return &ports.ScriptResult{
+				Content: lingo.NewLString("not enough gold"),
+				ErrCode: smus.ErrOperationNotAllowed,
+				Subject: "buyFailed",
+				Replies: []ports.ScriptReply{
+					{Subject: "goldBalance", Content: lingo.NewLInteger(3)},
+					{Recipient: "user2", Subject: "tradeCancelled", ErrCode: smus.ErrRequestedDataNotFound, Content: lingo.NewLVoid()},
+				},
+			}, nil
+		},
+	}
+	dispatcher, connWriter, _ := newTestDispatcher(scriptEngine)
+
+	resp, err := dispatcher.Dispatch("user1", chatMsg("system.script", "shop/buy", lingo.NewLVoid()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp == nil || resp.Subject.Value != "buyFailed" || resp.ErrCode != smus.ErrOperationNotAllowed || resp.SenderID.Value != "system.script" {
+		t.Fatalf("main reply = %+v, want buyFailed from system.script with ErrOperationNotAllowed", resp)
+	}
+
+	if len(connWriter.Writes) != 2 {
+		t.Fatalf("queued replies written = %d, want 2", len(connWriter.Writes))
+	}
+	first, _ := smus.ParseMUSMessage(connWriter.Writes[0].Data)
+	if connWriter.Writes[0].ClientID != "user1" || first.Subject.Value != "goldBalance" || first.MsgContent.ToInteger() != 3 {
+		t.Errorf("first reply = %s %+v, want goldBalance=3 to the sender", connWriter.Writes[0].ClientID, first)
+	}
+	second, _ := smus.ParseMUSMessage(connWriter.Writes[1].Data)
+	if connWriter.Writes[1].ClientID != "user2" || second.ErrCode != smus.ErrRequestedDataNotFound {
+		t.Errorf("second reply = %s %+v, want user2 with ErrRequestedDataNotFound", connWriter.Writes[1].ClientID, second)
+	}
+}
+
+func TestDispatcher_ScriptResult_NoReply(t *testing.T) {
+	scriptEngine := &testutil.MockScriptEngine{
+		HasScriptFunc: func(subject string) bool { return true },
+		ExecuteFunc: func(msg *ports.ScriptMessage) (*ports.ScriptResult, error) {
+			return &ports.ScriptResult{Content: lingo.NewLVoid(), NoReply: true}, nil
+		},
+	}
+	dispatcher, _, _ := newTestDispatcher(scriptEngine)
+
+	resp, err := dispatcher.Dispatch("user1", chatMsg("system.script", "quiet", lingo.NewLVoid()))
+	if err != nil || resp != nil {
+		t.Errorf("resp=%v err=%v, want no reply", resp, err)
+	}
+}
+
+func TestDispatcher_ScriptQueueFull_AnswersServerBusy(t *testing.T) {
+	scriptEngine := &testutil.MockScriptEngine{
+		HasScriptFunc: func(subject string) bool { return true },
+		ExecuteFunc: func(msg *ports.ScriptMessage) (*ports.ScriptResult, error) {
+			return nil, ports.ErrScriptQueueFull
+		},
+	}
+	dispatcher, _, _ := newTestDispatcher(scriptEngine)
+
+	resp, err := dispatcher.Dispatch("user1", chatMsg("system.script", "busyScript", lingo.NewLVoid()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp == nil || resp.ErrCode != smus.ErrNoConnectionsAvailable || resp.Subject.Value != "busyScript" {
+		t.Errorf("resp = %+v, want busyScript answered with ErrNoConnectionsAvailable", resp)
+	}
+}
+
+func TestDispatcher_RunSystemCommand_ReturnsAnswer(t *testing.T) {
+	dispatcher, connWriter, _ := newTestDispatcher(nil)
+
+	code, content, err := dispatcher.RunSystemCommand("user1", "system.server.getTime", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != smus.ErrNoError || content == nil {
+		t.Errorf("code=%d content=%v, want the server time", code, content)
+	}
+	if len(connWriter.Writes) != 0 {
+		t.Error("the answer should be returned, not sent")
+	}
+
+	code, _, err = dispatcher.RunSystemCommand("user1", "system.no.such", lingo.NewLVoid())
+	if err != nil || code != smus.ErrInvalidServerCommand {
+		t.Errorf("code=%d err=%v, want ErrInvalidServerCommand", code, err)
+	}
+}
+
+func TestDispatcher_RunSystemCommand_RefusesLogon(t *testing.T) {
+	dispatcher, _, _ := newTestDispatcher(nil)
+
+	if _, _, err := dispatcher.RunSystemCommand("user1", "Logon", lingo.NewLVoid()); err == nil {
+		t.Error("Logon should be refused from a script")
+	}
+}
@@ -0,0 +1,127 @@
+package mus_test
+
+import (
+	"testing"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/inbound/mus"
+	"fsos-server/internal/domain/services"
+	"fsos-server/internal/domain/types/lingo"
+	"fsos-server/internal/domain/types/smus"
+)
+
+// newExtensionDispatcher wires a dispatcher with an extension registry, with
+// "player" at level 20 and "admin" at level 80.
+func newExtensionDispatcher(t *testing.T, levels map[string]int, exts ...mus.Extension) (*mus.Dispatcher, error) {
+	t.Helper()
+	logger := &testutil.MockLogger{}
+	sessionStore := testutil.NewMockSessionStore()
+	for user, level := range map[string]int32{"player": 20, "admin": 80} {
+		sessionStore.RegisterConnection(user, "10.0.0.1")
+		sessionStore.SetUserAttribute(user, services.UserLevelAttribute, lingo.NewLInteger(level))
+	}
+	connWriter := &testutil.MockConnectionWriter{}
+	sender := mus.NewSender(connWriter, sessionStore, logger, nil, false, "faria")
+	authz := services.NewAuthorizer(sessionStore, levels)
+	systemService := mus.NewSystemService(nil, sessionStore, logger, nil, nil, connWriter, services.NewLogonService(nil, sessionStore, connWriter, logger, "none", 40),
+		authz, nil, nil)
+	dispatcher := mus.NewDispatcher(logger, nil, systemService, sender, nil)
+
+	registry := mus.NewExtensionRegistry(&mus.ExtensionContext{Sessions: sessionStore, Sender: sender, Authz: authz, Logger: logger})
+	for _, ext := range exts {
+		if err := registry.Register(ext, systemService.HasHandler); err != nil {
+			return nil, err
+		}
+	}
+	dispatcher.UseExtensions(registry)
+	return dispatcher, nil
+}
+
+func echoExtension(subject string, level int, tag string) mus.Extension {
+	return mus.Extension{
+		Subject: subject,
+		Level:   level,
+		Handler: func(ctx *mus.ExtensionContext, senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
+			return mus.NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrNoError, lingo.NewLString(tag)), nil
+		},
+	}
+}
+
+func TestExtensions_ExactAndPrefixRouting(t *testing.T) {
+	dispatcher, err := newExtensionDispatcher(t, nil,
+		echoExtension("game.*", 20, "game"),
+		echoExtension("game.shop.*", 20, "shop"),
+		echoExtension("game.shop.buy", 20, "buy"),
+	)
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	for subject, want := range map[string]string{
+		"game.shop.buy":  "buy",
+		"game.shop.list": "shop",
+		"game.ping":      "game",
+	} {
+		resp, err := dispatcher.Dispatch("player", buildDBMsg(subject, lingo.NewLVoid()))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", subject, err)
+		}
+		if resp.ErrCode != smus.ErrNoError || lingo.StringValue(resp.MsgContent) != want {
+			t.Errorf("%s -> %d %v, want handler %q", subject, resp.ErrCode, resp.MsgContent, want)
+		}
+	}
+
+	// Unmatched subjects still fall through to the System service.
+	resp, _ := dispatcher.Dispatch("player", buildDBMsg("other.thing", lingo.NewLVoid()))
+	if resp.ErrCode != smus.ErrInvalidServerCommand {
+		t.Errorf("unmatched subject ErrCode = %d, want %d", resp.ErrCode, smus.ErrInvalidServerCommand)
+	}
+}
+
+func TestExtensions_DeclaredLevelEnforced(t *testing.T) {
+	called := false
+	ext := echoExtension("game.admin.reset", 80, "reset")
+	inner := ext.Handler
+	ext.Handler = func(ctx *mus.ExtensionContext, senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
+		called = true
+		return inner(ctx, senderID, msg)
+	}
+	dispatcher, err := newExtensionDispatcher(t, map[string]int{}, ext)
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	resp, _ := dispatcher.Dispatch("player", buildDBMsg("game.admin.reset", lingo.NewLVoid()))
+	if resp.ErrCode != smus.ErrInvalidServerCommand || called {
+		t.Errorf("level-20 caller ErrCode = %d (called=%v), want denied", resp.ErrCode, called)
+	}
+	resp, _ = dispatcher.Dispatch("admin", buildDBMsg("game.admin.reset", lingo.NewLVoid()))
+	if resp.ErrCode != smus.ErrNoError || !called {
+		t.Errorf("level-80 caller ErrCode = %d (called=%v), want allowed", resp.ErrCode, called)
+	}
+}
+
+func TestExtensions_ConfiguredLevelOverridesDeclared(t *testing.T) {
+	dispatcher, err := newExtensionDispatcher(t, map[string]int{"game.*": 80}, echoExtension("game.*", 20, "game"))
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	resp, _ := dispatcher.Dispatch("player", buildDBMsg("game.ping", lingo.NewLVoid()))
+	if resp.ErrCode != smus.ErrInvalidServerCommand {
+		t.Errorf("ErrCode = %d, want configured level 80 to deny the player", resp.ErrCode)
+	}
+}
+
+func TestExtensions_RegistrationErrors(t *testing.T) {
+	cases := map[string][]mus.Extension{
+		"shadows built-in": {echoExtension("Logon", 20, "x")},
+		"duplicate":        {echoExtension("game.a", 20, "x"), echoExtension("game.a", 20, "y")},
+		"no handler":       {{Subject: "game.b", Level: 20}},
+		"empty subject":    {echoExtension("", 20, "x")},
+	}
+	for name, exts := range cases {
+		if _, err := newExtensionDispatcher(t, nil, exts...); err == nil {
+			t.Errorf("%s: expected a registration error", name)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fsos-server/_tests/testutil"
 	"fsos-server/internal/adapters/inbound/mus"
 	"fsos-server/internal/domain/types/lingo"
+	"fsos-server/internal/domain/types/smus"
 )
 
 func setupGroupManager() (*mus.GroupManager, *mus.MovieManager, *testutil.MockSessionStore) {
@@ -116,11 +117,30 @@ func TestGroupManager_LeaveAllGroups(t *testing.T) {
 	}
 }
 
+func TestGroupManager_LeaveAllGroups_DropsSubscriptions(t *testing.T) {
+	gm, mm, _ := setupGroupManager()
+	gm.UseMovies(mm)
+
+	mm.JoinMovie("lobby", "user1")
+	movie, _ := mm.GetMovie("lobby")
+	movie.AddGroup("testers", mus.NewGroup("testers", "lobby", false))
+	gm.JoinGroup("lobby", "testers", "user1")
+	group, _ := movie.GetGroup("testers")
+	group.Subscribe("user1")
+
+	if err := gm.LeaveAllGroups("lobby", "user1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if group.IsSubscribed("user1") {
+		t.Error("subscription survived LeaveAllGroups")
+	}
+}
+
 func TestGroup_Attributes(t *testing.T) {
 	g := mus.NewGroup("testers", "lobby", false)
 
 	// Set and get
-	g.SetAttribute("color", lingo.NewLString("red"))
+	g.SetAttribute("color", lingo.NewLString("red"), "alice")
 	val := g.GetAttribute("color")
 	if s, ok := val.(*lingo.LString); !ok || s.Value != "red" {
 		t.Errorf("GetAttribute('color') = %v, want 'red'", val)
@@ -133,7 +153,7 @@ func TestGroup_Attributes(t *testing.T) {
 	}
 
 	// List attribute names
-	g.SetAttribute("size", lingo.NewLInteger(10))
+	g.SetAttribute("size", lingo.NewLInteger(10), "alice")
 	names := g.GetAttributeNames()
 	sort.Strings(names)
 	if len(names) != 2 || names[0] != "color" || names[1] != "size" {
@@ -141,9 +161,90 @@ func TestGroup_Attributes(t *testing.T) {
 	}
 
 	// Delete
-	g.DeleteAttribute("color")
+	g.DeleteAttribute("color", "alice")
 	names = g.GetAttributeNames()
 	if len(names) != 1 || names[0] != "size" {
 		t.Errorf("after delete, GetAttributeNames() = %v, want [size]", names)
 	}
 }
+
+// setupNotifyingGroup puts user1 and user2 in movie "lobby" and group
+// "@team", with attribute notifications delivered through a real Sender.
+func setupNotifyingGroup(t *testing.T, notifyMovies []string) (*mus.Group, *testutil.MockConnectionWriter) {
+	t.Helper()
+	gm, mm, sessionStore := setupGroupManager()
+	connWriter := &testutil.MockConnectionWriter{}
+	sender := mus.NewSender(connWriter, sessionStore, &testutil.MockLogger{}, nil, false, "lobby")
+	mm.EnableGroupAttributeNotifications(sender, notifyMovies)
+
+	for _, u := range []string{"user1", "user2"} {
+		mm.JoinMovie("lobby", u)
+	}
+	movie, _ := mm.GetMovie("lobby")
+	movie.AddGroup("@team", mus.NewGroup("@team", "lobby", false))
+	for _, u := range []string{"user1", "user2"} {
+		if err := gm.JoinGroup("lobby", "@team", u); err != nil {
+			t.Fatalf("JoinGroup(%s): %v", u, err)
+		}
+	}
+	group, _ := movie.GetGroup("@team")
+	return group, connWriter
+}
+
+func TestGroup_AttributeNotifications_Subscribers(t *testing.T) {
+	group, connWriter := setupNotifyingGroup(t, nil)
+
+	// Nobody subscribed yet: nothing is pushed.
+	group.SetAttribute("score", lingo.NewLInteger(1), "user1")
+	if len(connWriter.Writes) != 0 {
+		t.Fatalf("writes without subscribers = %d, want 0", len(connWriter.Writes))
+	}
+
+	group.Subscribe("user2")
+	group.SetAttribute("score", lingo.NewLInteger(2), "user1")
+	if len(connWriter.Writes) != 1 || connWriter.Writes[0].ClientID != "user2" {
+		t.Fatalf("writes = %v, want one to user2", connWriter.Writes)
+	}
+
+	msg, err := smus.ParseMUSMessage(connWriter.Writes[0].Data)
+	if err != nil {
+		t.Fatalf("parse notification: %v", err)
+	}
+	if msg.Subject.Value != mus.GroupAttributeChangedSubject {
+		t.Errorf("subject = %q, want %q", msg.Subject.Value, mus.GroupAttributeChangedSubject)
+	}
+	plist, ok := msg.MsgContent.(*lingo.LPropList)
+	if !ok {
+		t.Fatalf("content = %v, want prop-list", msg.MsgContent)
+	}
+	attr, _ := plist.GetElement("attribute")
+	value, _ := plist.GetElement("value")
+	author, _ := plist.GetElement("author")
+	updatedAt, _ := plist.GetElement("updatedAt")
+	if lingo.StringValue(attr) != "score" || value.ToInteger() != 2 || lingo.StringValue(author) != "user1" || updatedAt.ToInteger() == 0 {
+		t.Errorf("notification = %v, want score=2 by user1 with a timestamp", plist)
+	}
+
+	group.Unsubscribe("user2")
+	group.DeleteAttribute("score", "user1")
+	if len(connWriter.Writes) != 1 {
+		t.Errorf("writes after unsubscribe = %d, want 1", len(connWriter.Writes))
+	}
+}
+
+func TestGroup_AttributeNotifications_MovieLevel(t *testing.T) {
+	group, connWriter := setupNotifyingGroup(t, []string{"lobby"})
+
+	group.DeleteAttribute("score", "user1")
+	if len(connWriter.Writes) != 2 {
+		t.Fatalf("writes = %d, want 2 (every member)", len(connWriter.Writes))
+	}
+	msg, err := smus.ParseMUSMessage(connWriter.Writes[0].Data)
+	if err != nil {
+		t.Fatalf("parse notification: %v", err)
+	}
+	value, _ := msg.MsgContent.(*lingo.LPropList).GetElement("value")
+	if value.GetType() != lingo.VtVoid {
+		t.Errorf("deleted value type = %d, want VtVoid", value.GetType())
+	}
+}
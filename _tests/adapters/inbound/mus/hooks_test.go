@@ -0,0 +1,242 @@
+package mus_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/inbound/mus"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/services"
+	"fsos-server/internal/domain/types/lingo"
+	"fsos-server/internal/domain/types/smus"
+)
+
+// hookEngine serves the given hook events. Before-hooks answer with vetoCode;
+// every run is reported on fired.
+func hookEngine(vetoCode int32, fired chan<- *ports.ScriptMessage, events ...string) *testutil.MockScriptEngine {
+	hooked := make(map[string]bool)
+	for _, e := range events {
+		hooked[mus.HookScriptDir+"/"+e] = true
+	}
+	return &testutil.MockScriptEngine{
+		HasScriptFunc: func(subject string) bool { return hooked[subject] },
+		ExecuteFunc: func(msg *ports.ScriptMessage) (*ports.ScriptResult, error) {
+			if fired != nil {
+				fired <- msg
+			}
+			return &ports.ScriptResult{Content: lingo.NewLString("not today"), ErrCode: vetoCode}, nil
+		},
+	}
+}
+
+func waitHook(t *testing.T, fired <-chan *ports.ScriptMessage, subject string) *ports.ScriptMessage {
+	t.Helper()
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case msg := <-fired:
+			if msg.Subject == subject {
+				return msg
+			}
+		case <-deadline:
+			t.Fatalf("hook %s did not fire", subject)
+			return nil
+		}
+	}
+}
+
+func hookProp(t *testing.T, msg *ports.ScriptMessage, name string) string {
+	t.Helper()
+	plist, ok := msg.Content.(*lingo.LPropList)
+	if !ok {
+		t.Fatalf("hook content is %T, want prop list", msg.Content)
+	}
+	v, err := plist.GetElement(name)
+	if err != nil {
+		t.Fatalf("hook content lacks #%s", name)
+	}
+	return lingo.StringValue(v)
+}
+
+func TestHooks_BeforeJoinGroup_Vetoes(t *testing.T) {
+	gm, mm, _ := setupGroupManager()
+	fired := make(chan *ports.ScriptMessage, 4)
+	gm.UseHooks(mus.NewHooks(hookEngine(smus.ErrOperationNotAllowed, fired, mus.HookBeforeJoinGroup), &testutil.MockLogger{}))
+	mm.JoinMovie("lobby", "user1")
+
+	err := gm.JoinGroup("lobby", "@vip", "user1")
+	var veto *mus.HookVetoError
+	if !errors.As(err, &veto) {
+		t.Fatalf("err = %v, want *HookVetoError", err)
+	}
+	if veto.ErrCode != smus.ErrOperationNotAllowed {
+		t.Errorf("ErrCode = %d, want %d", veto.ErrCode, smus.ErrOperationNotAllowed)
+	}
+	if n, _ := gm.GetGroupMemberCount("lobby", "@vip"); n != 0 {
+		t.Errorf("member count = %d, want 0 after veto", n)
+	}
+
+	msg := waitHook(t, fired, "hooks/beforeJoinGroup")
+	if msg.SenderID != "user1" || hookProp(t, msg, "group") != "@vip" {
+		t.Errorf("hook saw sender %q content %v", msg.SenderID, msg.Content)
+	}
+}
+
+func TestHooks_AfterJoinGroup_FiresWithoutVeto(t *testing.T) {
+	gm, mm, _ := setupGroupManager()
+	fired := make(chan *ports.ScriptMessage, 4)
+	// An after-hook's error code is ignored.
+	gm.UseHooks(mus.NewHooks(hookEngine(smus.ErrOperationNotAllowed, fired, mus.HookAfterJoinGroup), &testutil.MockLogger{}))
+	mm.JoinMovie("lobby", "user1")
+
+	if err := gm.JoinGroup("lobby", "@team", "user1"); err != nil {
+		t.Fatalf("JoinGroup: %v", err)
+	}
+	msg := waitHook(t, fired, "hooks/afterJoinGroup")
+	if hookProp(t, msg, "movieID") != "lobby" || hookProp(t, msg, "userID") != "user1" {
+		t.Errorf("hook content = %v", msg.Content)
+	}
+}
+
+func TestHooks_AfterHooksRunInEventOrder(t *testing.T) {
+	gm, mm, _ := setupGroupManager()
+	fired := make(chan *ports.ScriptMessage, 4)
+	engine := hookEngine(0, nil, mus.HookAfterJoinGroup, mus.HookAfterLeaveGroup)
+	engine.ExecuteFunc = func(msg *ports.ScriptMessage) (*ports.ScriptResult, error) {
+		if msg.Subject == "hooks/afterJoinGroup" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		fired <- msg
+		return &ports.ScriptResult{}, nil
+	}
+	executor := services.NewScriptExecutor(engine, 4, 10, nil)
+	defer executor.Stop()
+	gm.UseHooks(mus.NewHooks(executor, &testutil.MockLogger{}))
+	mm.JoinMovie("lobby", "user1")
+
+	if err := gm.JoinGroup("lobby", "@team", "user1"); err != nil {
+		t.Fatalf("JoinGroup: %v", err)
+	}
+	if err := gm.LeaveGroup("lobby", "@team", "user1"); err != nil {
+		t.Fatalf("LeaveGroup: %v", err)
+	}
+	for _, want := range []string{"hooks/afterJoinGroup", "hooks/afterLeaveGroup"} {
+		select {
+		case msg := <-fired:
+			if msg.Subject != want {
+				t.Errorf("got %s, want %s", msg.Subject, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s did not fire", want)
+		}
+	}
+}
+
+func TestHooks_BeforeLogon_VetoReachesClient(t *testing.T) {
+	logger := &testutil.MockLogger{}
+	sessionStore := testutil.NewMockSessionStore()
+	sessionStore.RegisterConnection("client-1", "192.168.1.1")
+	connWriter := &testutil.MockConnectionWriter{}
+	db := &testutil.MockDBAdapter{}
+	movieManager := mus.NewMovieManager(sessionStore, logger)
+	groupManager := mus.NewGroupManager(sessionStore, logger)
+	logonService := services.NewLogonService(db, sessionStore, connWriter, logger, "none", 40)
+	svc := mus.NewSystemService(db, sessionStore, logger, movieManager, groupManager, connWriter, logonService,
+		services.NewAuthorizer(sessionStore, nil), nil, nil)
+
+	fired := make(chan *ports.ScriptMessage, 4)
+	hooks := mus.NewHooks(hookEngine(smus.ErrOperationNotAllowed, fired, mus.HookBeforeLogon, mus.HookLogonFailed), logger)
+	logonService.SetGuard(hooks.GuardLogon)
+	svc.UseHooks(hooks)
+
+	resp, err := svc.Handle("client-1", buildLogonMsg("mallory", ""))
+	if err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if resp.ErrCode != smus.ErrOperationNotAllowed {
+		t.Errorf("ErrCode = %d, want the veto code %d", resp.ErrCode, smus.ErrOperationNotAllowed)
+	}
+	if s, ok := resp.MsgContent.(*lingo.LString); !ok || s.Value != "not today" {
+		t.Errorf("content = %v, want the hook's reason", resp.MsgContent)
+	}
+	if ok, _ := sessionStore.IsConnected("mallory"); ok {
+		t.Error("vetoed user must not get a session")
+	}
+
+	before := waitHook(t, fired, "hooks/beforeLogon")
+	if hookProp(t, before, "ip") != "192.168.1.1" {
+		t.Errorf("beforeLogon content = %v", before.Content)
+	}
+	failed := waitHook(t, fired, "hooks/logonFailed")
+	if hookProp(t, failed, "reason") != "vetoed" {
+		t.Errorf("logonFailed #reason = %q, want vetoed", hookProp(t, failed, "reason"))
+	}
+}
+
+func TestHooks_FailingHookAllowsAction(t *testing.T) {
+	gm, mm, _ := setupGroupManager()
+	engine := &testutil.MockScriptEngine{
+		HasScriptFunc: func(string) bool { return true },
+		ExecuteFunc: func(*ports.ScriptMessage) (*ports.ScriptResult, error) {
+			return nil, errors.New("boom")
+		},
+	}
+	gm.UseHooks(mus.NewHooks(engine, &testutil.MockLogger{}))
+	mm.JoinMovie("lobby", "user1")
+
+	if err := gm.JoinGroup("lobby", "@team", "user1"); err != nil {
+		t.Fatalf("a failing before-hook must fail open, got %v", err)
+	}
+}
+
+func TestDispatcher_RefusesHookScriptsAsSubjects(t *testing.T) {
+	executed := false
+	engine := &testutil.MockScriptEngine{
+		HasScriptFunc: func(string) bool { return true },
+		ExecuteFunc: func(*ports.ScriptMessage) (*ports.ScriptResult, error) {
+			executed = true
+			return &ports.ScriptResult{Content: lingo.NewLVoid()}, nil
+		},
+	}
+	dispatcher, _, _ := newTestDispatcher(engine)
+
+	dispatcher.Dispatch("user1", chatMsg("system.script", "hooks/beforeLogon", lingo.NewLVoid()))
+	if executed {
+		t.Error("a hook script must not be reachable as a system.script subject")
+	}
+}
+
+// A Lua before-hook vetoes through mus.response with an error code.
+func TestHooks_LuaBeforeJoinGroup(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, mus.HookScriptDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	script := `
+		local c = mus.getContent()
+		if string.sub(c.group, 1, 5) == "@vip_" and c.userID ~= "admin" then
+			mus.response("members only", mus.errors.operationNotAllowed)
+		end
+	`
+	if err := os.WriteFile(filepath.Join(dir, mus.HookScriptDir, "beforeJoinGroup.lua"), []byte(script), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	logger := &testutil.MockLogger{}
+	engine := outbound.NewLuaScriptEngine(dir, logger, 5, nil, nil, nil, nil, nil, nil, nil)
+
+	gm, mm, _ := setupGroupManager()
+	gm.UseHooks(mus.NewHooks(engine, logger))
+	mm.JoinMovie("lobby", "user1")
+
+	if err := gm.JoinGroup("lobby", "@vip_lounge", "user1"); err == nil {
+		t.Error("expected the Lua hook to veto @vip_lounge")
+	}
+	if err := gm.JoinGroup("lobby", "@team", "user1"); err != nil {
+		t.Errorf("@team: %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package mus_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/inbound/mus"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+	"fsos-server/internal/domain/types/smus"
+)
+
+func chatMsg(recipient, subject string, content lingo.LValue) *smus.MUSMessage {
+	return &smus.MUSMessage{
+		Subject:  smus.MUSMsgHeaderString{Length: len(subject), Value: subject},
+		SenderID: smus.MUSMsgHeaderString{Length: 5, Value: "user1"},
+		RecptID: smus.MUSMsgHeaderStringList{
+			Count:   1,
+			Strings: []smus.MUSMsgHeaderString{{Length: len(recipient), Value: recipient}},
+		},
+		MsgContent: content,
+	}
+}
+
+func TestDispatcher_Interceptors_RewriteBeforeDelivery(t *testing.T) {
+	dispatcher, connWriter, sessionStore := newTestDispatcher(nil)
+	sessionStore.RegisterConnection("user2", "10.0.0.2")
+	dispatcher.Use(mus.InterceptorFuncs{
+		BeforeFunc: func(senderID string, msg *smus.MUSMessage) mus.InterceptDecision {
+			if msg.Subject.Value == "chat" {
+				msg.MsgContent = lingo.NewLString("***")
+			}
+			return mus.InterceptDecision{Action: mus.InterceptAllow}
+		},
+	})
+
+	if _, err := dispatcher.Dispatch("user1", chatMsg("user2", "chat", lingo.NewLString("rude word"))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(connWriter.Writes) != 1 {
+		t.Fatalf("writes = %d, want 1", len(connWriter.Writes))
+	}
+	delivered, err := smus.ParseMUSMessage(connWriter.Writes[0].Data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if lingo.StringValue(delivered.MsgContent) != "***" {
+		t.Errorf("delivered content = %v, want rewritten ***", delivered.MsgContent)
+	}
+}
+
+func TestDispatcher_Interceptors_DropAndReject(t *testing.T) {
+	dispatcher, connWriter, sessionStore := newTestDispatcher(nil)
+	sessionStore.RegisterConnection("user2", "10.0.0.2")
+	laterCalled := false
+	dispatcher.Use(mus.InterceptorFuncs{
+		BeforeFunc: func(senderID string, msg *smus.MUSMessage) mus.InterceptDecision {
+			switch lingo.StringValue(msg.MsgContent) {
+			case "spam":
+				return mus.InterceptDecision{Action: mus.InterceptDrop}
+			case "abuse":
+				return mus.InterceptDecision{Action: mus.InterceptReject, ErrCode: smus.ErrInvalidMessageFormat}
+			}
+			return mus.InterceptDecision{Action: mus.InterceptAllow}
+		},
+	})
+	dispatcher.Use(mus.InterceptorFuncs{
+		BeforeFunc: func(senderID string, msg *smus.MUSMessage) mus.InterceptDecision {
+			laterCalled = true
+			return mus.InterceptDecision{Action: mus.InterceptAllow}
+		},
+	})
+
+	resp, err := dispatcher.Dispatch("user1", chatMsg("user2", "chat", lingo.NewLString("spam")))
+	if err != nil || resp != nil || len(connWriter.Writes) != 0 || laterCalled {
+		t.Errorf("drop: resp=%v err=%v writes=%d laterCalled=%v, want silent stop", resp, err, len(connWriter.Writes), laterCalled)
+	}
+
+	resp, err = dispatcher.Dispatch("user1", chatMsg("user2", "chat", lingo.NewLString("abuse")))
+	if err != nil || resp == nil || resp.ErrCode != smus.ErrInvalidMessageFormat {
+		t.Fatalf("reject: resp=%v err=%v, want an error response", resp, err)
+	}
+	if len(connWriter.Writes) != 0 {
+		t.Errorf("rejected message was delivered")
+	}
+}
+
+func TestDispatcher_Interceptors_AfterRunsInReverseOrder(t *testing.T) {
+	dispatcher, _, sessionStore := newTestDispatcher(nil)
+	sessionStore.RegisterConnection("client-1", "10.0.0.1")
+	var order []string
+	for _, name := range []string{"outer", "inner"} {
+		name := name
+		dispatcher.Use(mus.InterceptorFuncs{
+			AfterFunc: func(senderID string, msg *smus.MUSMessage, resp *smus.MUSMessage) *smus.MUSMessage {
+				order = append(order, name)
+				return resp
+			},
+		})
+	}
+
+	resp, err := dispatcher.Dispatch("client-1", buildLogonMsg("testuser", "nopass"))
+	if err != nil || resp == nil {
+		t.Fatalf("logon: resp=%v err=%v", resp, err)
+	}
+	if len(order) != 2 || order[0] != "inner" || order[1] != "outer" {
+		t.Errorf("After order = %v, want [inner outer]", order)
+	}
+}
+
+func TestDispatcher_InterceptorScriptsNotRunnableAsScripts(t *testing.T) {
+	executed := false
+	engine := &testutil.MockScriptEngine{
+		HasScriptFunc: func(subject string) bool { return true },
+		ExecuteFunc: func(msg *ports.ScriptMessage) (*ports.ScriptResult, error) {
+			executed = true
+			return &ports.ScriptResult{Content: lingo.NewLVoid()}, nil
+		},
+	}
+	dispatcher, _, _ := newTestDispatcher(engine)
+
+	dispatcher.Dispatch("user1", chatMsg("system.script", "interceptors/chatFilter", lingo.NewLVoid()))
+	if executed {
+		t.Error("an interceptor script must not be reachable as a system.script subject")
+	}
+}
+
+// A Lua interceptor runs in the regular script sandbox and decides through
+// mus.intercept.
+func TestScriptInterceptor_LuaFilter(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, mus.InterceptorScriptDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	script := `
+		if mus.intercept.getSubject() ~= "chat" then return end
+		local text = mus.getContent()
+		if text == "spam" then
+			mus.intercept.drop()
+		elseif text == "abuse" then
+			mus.intercept.reject(-2)
+		else
+			mus.intercept.rewrite((string.gsub(text, "darn", "****")))
+		end
+	`
+	if err := os.WriteFile(filepath.Join(dir, mus.InterceptorScriptDir, "chatFilter.lua"), []byte(script), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	logger := &testutil.MockLogger{}
+	engine := outbound.NewLuaScriptEngine(dir, logger, 5, nil, nil, nil, nil, nil, nil, nil)
+	si := mus.NewScriptInterceptor(engine, logger, "chatFilter", true, false)
+
+	msg := chatMsg("@AllUsers", "chat", lingo.NewLString("oh darn"))
+	if d := si.Before("user1", msg); d.Action != mus.InterceptAllow || lingo.StringValue(msg.MsgContent) != "oh ****" {
+		t.Errorf("rewrite: action=%v content=%v, want allow with 'oh ****'", d.Action, msg.MsgContent)
+	}
+	if d := si.Before("user1", chatMsg("@AllUsers", "chat", lingo.NewLString("spam"))); d.Action != mus.InterceptDrop {
+		t.Errorf("spam action = %v, want drop", d.Action)
+	}
+	if d := si.Before("user1", chatMsg("@AllUsers", "chat", lingo.NewLString("abuse"))); d.Action != mus.InterceptReject || d.ErrCode != -2 {
+		t.Errorf("abuse decision = %+v, want reject -2", d)
+	}
+	other := chatMsg("@AllUsers", "move", lingo.NewLString("spam"))
+	if d := si.Before("user1", other); d.Action != mus.InterceptAllow || lingo.StringValue(other.MsgContent) != "spam" {
+		t.Errorf("other subject = %+v %v, want untouched", d, other.MsgContent)
+	}
+}
@@ -134,6 +134,23 @@ func TestMovieManager_LeaveMovie_LeavesAllGroups(t *testing.T) {
 	}
 }
 
+func TestMovieManager_LeaveMovie_DropsSubscriptions(t *testing.T) {
+	mm, _ := setupMovieManager()
+
+	mm.JoinMovie("lobby", "user1")
+	mm.JoinMovie("lobby", "user2")
+	movie, _ := mm.GetMovie("lobby")
+	group, _ := movie.GetGroup("@AllUsers")
+	group.Subscribe("user1")
+
+	if err := mm.LeaveMovie("lobby", "user1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if group.IsSubscribed("user1") {
+		t.Error("subscription survived LeaveMovie, a reconnecting user1 would inherit it")
+	}
+}
+
 func TestMovieManager_GetMovies(t *testing.T) {
 	mm, _ := setupMovieManager()
 
@@ -0,0 +1,184 @@
+package mus_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/inbound/mus"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/services"
+	"fsos-server/internal/domain/types/lingo"
+)
+
+// setupScriptDirectory puts user1 and user2 in movie "lobby" and returns a Lua
+// engine with the given scripts, bound to a directory over the managers.
+func setupScriptDirectory(t *testing.T, scripts map[string]string) (*outbound.LuaScriptEngine, *mus.MovieManager, *testutil.MockSessionStore) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name+".lua"), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gm, mm, ss := setupGroupManager()
+	for _, u := range []string{"user1", "user2"} {
+		mm.JoinMovie("lobby", u)
+		ss.SetUserAttribute(u, "#movieID", lingo.NewLString("lobby"))
+	}
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, ss, nil, nil)
+	engine.UseMovieDirectory(mus.NewScriptDirectory(mm, gm, ss, services.NewAuthorizer(ss, nil)))
+	return engine, mm, ss
+}
+
+func runScript(engine ports.ScriptEngine, subject, sender string, content lingo.LValue) (*ports.ScriptResult, error) {
+	return engine.Execute(&ports.ScriptMessage{Subject: subject, SenderID: sender, Content: content})
+}
+
+func TestScriptDirectory_GroupsFromLua(t *testing.T) {
+	engine, mm, _ := setupScriptDirectory(t, map[string]string{"team": `
+		local me = mus.getSender()
+		assert(mus.group.join(me, "@team"))
+		assert(mus.group.setAttribute("@team", "score", 7))
+		mus.response(mus.movie.getMovie(me) .. ":" .. mus.group.getUserCount("@team") ..
+			":" .. mus.group.getAttribute("@team", "score") .. ":" .. #mus.movie.getGroups())
+	`})
+
+	res, err := runScript(engine, "team", "user1", lingo.NewLVoid())
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if got := lingo.StringValue(res.Content); got != "lobby:1:7:2" {
+		t.Errorf("response = %q, want lobby:1:7:2", got)
+	}
+	movie, _ := mm.GetMovie("lobby")
+	group, ok := movie.GetGroup("@team")
+	if !ok || group.GetAttribute("score").ToInteger() != 7 {
+		t.Error("the script's join and setAttribute must reach the movie manager")
+	}
+}
+
+func TestScriptDirectory_OtherUsersNeedPermission(t *testing.T) {
+	engine, _, ss := setupScriptDirectory(t, map[string]string{
+		"pull":  `mus.response(mus.group.join(mus.getContent(), "@jail"))`,
+		"other": `mus.response(mus.group.getUserCount("@AllUsers", "arena"))`,
+	})
+
+	if _, err := runScript(engine, "pull", "user1", lingo.NewLString("user2")); err == nil || !strings.Contains(err.Error(), "may not act on") {
+		t.Errorf("non-admin moving user2: err = %v, want a permission error", err)
+	}
+	if _, err := runScript(engine, "other", "user1", lingo.NewLVoid()); err == nil {
+		t.Error("non-admin naming another movie must fail")
+	}
+
+	// Scheduled jobs have no session and act as the server.
+	res, err := runScript(engine, "pull", "system.jobs/cleanup", lingo.NewLString("user2"))
+	if err != nil || res.Content.ToInteger() != 1 {
+		t.Fatalf("job moving user2: res = %v, err = %v", res, err)
+	}
+
+	// A logged-on user named like a server sender gets no special rights.
+	ss.RegisterConnection("System", "10.0.0.9")
+	if _, err := runScript(engine, "pull", "System", lingo.NewLString("user1")); err == nil {
+		t.Error("a user named System must not act on others")
+	}
+
+	ss.SetUserAttribute("user1", services.UserLevelAttribute, lingo.NewLInteger(100))
+	if _, err := runScript(engine, "pull", "user1", lingo.NewLString("user2")); err != nil {
+		t.Errorf("admin moving user2: %v", err)
+	}
+}
+
+// A join's beforeJoinGroup hook runs as the joined user. It must not queue
+// behind that user's running script, which here waits for the join.
+func TestScriptDirectory_JoinHookRunsInlineForTheJoinedUser(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, mus.HookScriptDir), 0o755)
+	for name, src := range map[string]string{
+		"pull":                  `mus.response(mus.group.join(mus.getContent(), "@jail"))`,
+		"hooks/beforeJoinGroup": `mus.response(0)`,
+		"waitJoin": `
+			local deadline = mus.time.nowMs() + 2000
+			while mus.time.nowMs() < deadline do
+				for _, u in ipairs(mus.group.getUsers("@jail")) do
+					if u == mus.getSender() then mus.response("joined") return end
+				end
+			end
+			mus.response("timed out")
+		`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name+".lua"), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gm, mm, ss := setupGroupManager()
+	for _, u := range []string{"user1", "user2"} {
+		mm.JoinMovie("lobby", u)
+		ss.SetUserAttribute(u, "#movieID", lingo.NewLString("lobby"))
+	}
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, ss, nil, nil)
+	executor := services.NewScriptExecutor(engine, 4, 10, nil)
+	defer executor.Stop()
+	engine.UseInliner(executor)
+	engine.UseMovieDirectory(mus.NewScriptDirectory(mm, gm, ss, services.NewAuthorizer(ss, nil)))
+	gm.UseHooks(mus.NewHooks(executor, &testutil.MockLogger{}))
+
+	waited := make(chan string, 1)
+	go func() {
+		res, err := runScript(executor, "waitJoin", "user2", lingo.NewLVoid())
+		if err != nil {
+			waited <- err.Error()
+			return
+		}
+		waited <- lingo.StringValue(res.Content)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	res, err := runScript(executor, "pull", "system.jobs/pull", lingo.NewLString("user2"))
+	if err != nil || res.Content.ToInteger() != 1 {
+		t.Fatalf("job moving user2: res = %v, err = %v", res, err)
+	}
+	if got := <-waited; got != "joined" {
+		t.Errorf("user2's script saw %q, want joined: the hook waited behind it", got)
+	}
+}
+
+// States pooled before the directory was bound are rebuilt with mus.group.
+func TestScriptDirectory_BindAfterFirstRun(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "probe.lua"), []byte(`mus.response(mus.group ~= nil)`), 0o644)
+	gm, mm, ss := setupGroupManager()
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, ss, nil, nil)
+
+	res, err := runScript(engine, "probe", "user1", lingo.NewLVoid())
+	if err != nil || res.Content.ToInteger() != 0 {
+		t.Fatalf("before binding: res = %v, err = %v", res, err)
+	}
+	engine.UseMovieDirectory(mus.NewScriptDirectory(mm, gm, ss, nil))
+	res, err = runScript(engine, "probe", "user1", lingo.NewLVoid())
+	if err != nil || res.Content.ToInteger() != 1 {
+		t.Errorf("after binding: res = %v, err = %v, want mus.group", res, err)
+	}
+}
+
+func TestScriptDirectory_ContextFromLua(t *testing.T) {
+	engine, _, ss := setupScriptDirectory(t, map[string]string{"whoami": `
+		assert(mus.group.join(mus.getSender(), "@team"))
+		local ctx = mus.getContext()
+		table.sort(ctx.groups)
+		mus.response(ctx.userLevel .. ":" .. ctx.movieID .. ":" .. table.concat(ctx.groups, ","))
+	`})
+	ss.SetUserAttribute("user1", services.UserLevelAttribute, lingo.NewLInteger(50))
+
+	res, err := runScript(engine, "whoami", "user1", lingo.NewLVoid())
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if got := lingo.StringValue(res.Content); got != "50:lobby:@AllUsers,@team" {
+		t.Errorf("context = %q, want 50:lobby:@AllUsers,@team", got)
+	}
+}
@@ -0,0 +1,143 @@
+package mus_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/domain/services"
+	"fsos-server/internal/domain/types/lingo"
+	"fsos-server/internal/domain/types/smus"
+)
+
+func TestDispatcher_EnforcesScriptHeader(t *testing.T) {
+	dir := t.TempDir()
+	scripts := map[string]string{
+		"adminOnly": "-- @script level=80\nmus.response(\"ok\")\n",
+		"limited":   "-- @script rate=2/1m\nmus.response(\"ok\")\n",
+	}
+	for name, src := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name+".lua"), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+	// Through the executor, as in production.
+	executor := services.NewScriptExecutor(engine, 2, 8, nil)
+	defer executor.Stop()
+	dispatcher, _, sessionStore := newTestDispatcher(executor)
+	sessionStore.RegisterConnection("user1", "10.0.0.1")
+	sessionStore.RegisterConnection("user2", "10.0.0.2")
+
+	run := func(sender, script string) int32 {
+		t.Helper()
+		resp, err := dispatcher.Dispatch(sender, chatMsg("system.script", script, lingo.NewLVoid()))
+		if err != nil || resp == nil {
+			t.Fatalf("%s by %s: resp = %v, err = %v", script, sender, resp, err)
+		}
+		return resp.ErrCode
+	}
+
+	if code := run("user1", "adminOnly"); code != smus.ErrNotPermittedWithUserLevel {
+		t.Errorf("level-0 sender: code = %d, want ErrNotPermittedWithUserLevel", code)
+	}
+	sessionStore.SetUserAttribute("user1", services.UserLevelAttribute, lingo.NewLInteger(80))
+	if code := run("user1", "adminOnly"); code != smus.ErrNoError {
+		t.Errorf("level-80 sender: code = %d, want no error", code)
+	}
+
+	for i := 0; i < 2; i++ {
+		if code := run("user1", "limited"); code != smus.ErrNoError {
+			t.Fatalf("call %d: code = %d, want no error", i+1, code)
+		}
+	}
+	if code := run("user1", "limited"); code != smus.ErrOperationNotAllowed {
+		t.Errorf("third call in the window: code = %d, want ErrOperationNotAllowed", code)
+	}
+	if code := run("user2", "limited"); code != smus.ErrNoError {
+		t.Errorf("the limit is per user: code = %d", code)
+	}
+}
+
+// Prefix-routed subjects run the same script, so they share its rate.
+func TestDispatcher_ScriptRateCoversPrefixRoutedSubjects(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "save.lua"), []byte("-- @script rate=2/1m\nmus.response(\"ok\")\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+	dispatcher, _, sessionStore := newTestDispatcher(engine)
+	sessionStore.RegisterConnection("user1", "10.0.0.1")
+
+	for i, subject := range []string{"save-1", "save-2"} {
+		resp, err := dispatcher.Dispatch("user1", chatMsg("system.script", subject, lingo.NewLVoid()))
+		if err != nil || resp.ErrCode != smus.ErrNoError {
+			t.Fatalf("call %d (%s): resp = %v, err = %v", i+1, subject, resp, err)
+		}
+	}
+	resp, err := dispatcher.Dispatch("user1", chatMsg("system.script", "save-3", lingo.NewLVoid()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ErrCode != smus.ErrOperationNotAllowed {
+		t.Errorf("a third suffix in the window: code = %d, want ErrOperationNotAllowed", resp.ErrCode)
+	}
+}
+
+// mus.call is held to what the sender could run directly.
+func TestDispatcher_GuardsScriptCalls(t *testing.T) {
+	dir := t.TempDir()
+	scripts := map[string]string{
+		"adminOnly":        "-- @script level=80\nmus.response(\"secret\")\n",
+		"limited":          "-- @script rate=1/1m\nmus.response(\"ok\")\n",
+		"hooks/afterLogon": "mus.response(\"hooked\")\n",
+		"caller": `
+local ok, err = pcall(mus.call, mus.getContent())
+mus.response(ok and "ran" or err)
+`,
+	}
+	for name, src := range scripts {
+		path := filepath.Join(dir, name+".lua")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+	dispatcher, _, sessionStore := newTestDispatcher(engine)
+	engine.UseScriptCallGuard(dispatcher)
+	sessionStore.RegisterConnection("user1", "10.0.0.1")
+
+	call := func(subject string) string {
+		t.Helper()
+		resp, err := dispatcher.Dispatch("user1", chatMsg("system.script", "caller", lingo.NewLString(subject)))
+		if err != nil || resp == nil {
+			t.Fatalf("caller(%s): resp = %v, err = %v", subject, resp, err)
+		}
+		return lingo.StringValue(resp.MsgContent)
+	}
+
+	for subject, want := range map[string]string{
+		"adminOnly":        "user level too low",
+		"hooks/afterLogon": "run by the server only",
+	} {
+		if got := call(subject); !strings.Contains(got, want) {
+			t.Errorf("mus.call(%s) = %q, want %q", subject, got, want)
+		}
+	}
+	if got := call("limited"); got != "ran" {
+		t.Errorf("first mus.call(limited) = %q, want ran", got)
+	}
+	if got := call("limited"); !strings.Contains(got, "rate limit exceeded") {
+		t.Errorf("second mus.call(limited) = %q, want the rate limit", got)
+	}
+	sessionStore.SetUserAttribute("user1", services.UserLevelAttribute, lingo.NewLInteger(80))
+	if got := call("adminOnly"); got != "ran" {
+		t.Errorf("level-80 mus.call(adminOnly) = %q, want ran", got)
+	}
+}
@@ -333,6 +333,98 @@ func TestSystemCommand_GroupAttributes(t *testing.T) {
 	}
 }
 
+func TestSystemCommand_GroupSubscribeAttributes(t *testing.T) {
+	db := &testutil.MockDBAdapter{}
+	logger := &testutil.MockLogger{}
+	sessionStore := testutil.NewMockSessionStore()
+	sessionStore.RegisterConnection("conn-1", "192.168.1.10")
+	connWriter := &testutil.MockConnectionWriter{}
+	movieManager := mus.NewMovieManager(sessionStore, logger)
+	movieManager.EnableGroupAttributeNotifications(mus.NewSender(connWriter, sessionStore, logger, nil, false, ""), nil)
+	groupManager := mus.NewGroupManager(sessionStore, logger)
+	svc := mus.NewSystemService(db, sessionStore, logger, movieManager, groupManager, connWriter, services.NewLogonService(db, sessionStore, connWriter, logger, "none", 40),
+		services.NewAuthorizer(sessionStore, nil), nil, nil)
+
+	logonMsg := buildLogonMsg("user1", "")
+	logonMsg.MsgContent.(*lingo.LList).Values[0] = lingo.NewLString("testMovie")
+	if resp, err := svc.Handle("conn-1", logonMsg); err != nil || resp.ErrCode != smus.ErrNoError {
+		t.Fatalf("logon failed: err=%v", err)
+	}
+	svc.Handle("user1", buildSystemMsg("system.group.join", lingo.NewLString("myGroup")))
+
+	notifications := func() int {
+		n := 0
+		for _, w := range connWriter.Writes {
+			if msg, err := smus.ParseMUSMessage(w.Data); err == nil && msg.Subject.Value == mus.GroupAttributeChangedSubject {
+				n++
+			}
+		}
+		return n
+	}
+	setColor := func() {
+		plist := lingo.NewLPropList()
+		plist.AddElement(lingo.NewLSymbol("group"), lingo.NewLString("myGroup"))
+		plist.AddElement(lingo.NewLSymbol("attribute"), lingo.NewLString("color"))
+		plist.AddElement(lingo.NewLSymbol("value"), lingo.NewLString("blue"))
+		svc.Handle("user1", buildSystemMsg("system.group.setAttribute", plist))
+	}
+
+	setColor()
+	if n := notifications(); n != 0 {
+		t.Fatalf("notifications before subscribing = %d, want 0", n)
+	}
+
+	resp, err := svc.Handle("user1", buildSystemMsg("system.group.subscribeAttributes", lingo.NewLString("myGroup")))
+	if err != nil || resp.ErrCode != smus.ErrNoError {
+		t.Fatalf("subscribeAttributes: err=%v errCode=%d", err, resp.ErrCode)
+	}
+	setColor()
+	if n := notifications(); n != 1 {
+		t.Errorf("notifications after subscribing = %d, want 1", n)
+	}
+
+	svc.Handle("user1", buildSystemMsg("system.group.unsubscribeAttributes", lingo.NewLString("myGroup")))
+	setColor()
+	if n := notifications(); n != 1 {
+		t.Errorf("notifications after unsubscribing = %d, want 1", n)
+	}
+}
+
+func TestSystemCommand_GroupSubscribeAttributes_NonMember(t *testing.T) {
+	db := &testutil.MockDBAdapter{}
+	logger := &testutil.MockLogger{}
+	sessionStore := testutil.NewMockSessionStore()
+	sessionStore.RegisterConnection("conn-1", "192.168.1.10")
+	sessionStore.RegisterConnection("conn-2", "192.168.1.11")
+	connWriter := &testutil.MockConnectionWriter{}
+	movieManager := mus.NewMovieManager(sessionStore, logger)
+	groupManager := mus.NewGroupManager(sessionStore, logger)
+	svc := mus.NewSystemService(db, sessionStore, logger, movieManager, groupManager, connWriter, services.NewLogonService(db, sessionStore, connWriter, logger, "none", 40),
+		services.NewAuthorizer(sessionStore, nil), nil, nil)
+
+	for conn, user := range map[string]string{"conn-1": "user1", "conn-2": "user2"} {
+		logonMsg := buildLogonMsg(user, "")
+		logonMsg.MsgContent.(*lingo.LList).Values[0] = lingo.NewLString("testMovie")
+		if resp, err := svc.Handle(conn, logonMsg); err != nil || resp.ErrCode != smus.ErrNoError {
+			t.Fatalf("logon %s failed: err=%v", user, err)
+		}
+	}
+	svc.Handle("user1", buildSystemMsg("system.group.join", lingo.NewLString("myGroup")))
+
+	resp, err := svc.Handle("user2", buildSystemMsg("system.group.subscribeAttributes", lingo.NewLString("myGroup")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ErrCode != smus.ErrOperationNotAllowed {
+		t.Errorf("ErrCode = %d, want %d", resp.ErrCode, smus.ErrOperationNotAllowed)
+	}
+	movie, _ := movieManager.GetMovie("testMovie")
+	group, _ := movie.GetGroup("myGroup")
+	if group.IsSubscribed("user2") {
+		t.Error("a non-member was subscribed")
+	}
+}
+
 // --- User commands ---
 
 func TestSystemCommand_UserGetAddress(t *testing.T) {
@@ -1,6 +1,7 @@
 package inbound_test
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"sync"
@@ -58,8 +59,8 @@ func TestScheduler_RunsJobOnInterval(t *testing.T) {
 	if !ok {
 		t.Fatal("expected subject jobs/tick to have been executed")
 	}
-	if sender != "system.jobs" {
-		t.Fatalf("expected sender system.jobs, got %v", sender)
+	if sender != "system.jobs/tick" {
+		t.Fatalf("expected sender system.jobs/tick, got %v", sender)
 	}
 }
 
@@ -175,3 +176,301 @@ func TestScheduler_RunsRealLuaJobEndToEnd(t *testing.T) {
 		t.Fatal("expected the real jobs/heartbeat.lua to have logged 'scheduler heartbeat'")
 	}
 }
+
+func TestScheduler_ReconcileSwapsRunningJobs(t *testing.T) {
+	engine := &countingEngine{}
+	s := inbound.NewScheduler(engine, []inbound.ScheduledJob{
+		{Name: "old", Interval: 20 * time.Millisecond},
+	}, &testutil.MockLogger{})
+	s.Start()
+	defer s.Stop()
+	time.Sleep(50 * time.Millisecond)
+
+	s.Reconcile([]inbound.ScheduledJob{{Name: "new", Interval: 20 * time.Millisecond}})
+	time.Sleep(5 * time.Millisecond) // let an in-flight old run finish
+	engine.subjects.Delete("jobs/old")
+	time.Sleep(70 * time.Millisecond)
+
+	if _, ran := engine.subjects.Load("jobs/new"); !ran {
+		t.Error("expected the reconciled job jobs/new to run")
+	}
+	if _, ran := engine.subjects.Load("jobs/old"); ran {
+		t.Error("jobs/old should stop once reconciled away")
+	}
+	if jobs := s.Jobs(); len(jobs) != 1 || jobs[0].Name != "new" {
+		t.Errorf("Jobs() = %v, want only new", jobs)
+	}
+}
+
+// gateEngine blocks every run until released, reporting when one starts.
+type gateEngine struct {
+	started chan struct{}
+	release chan struct{}
+	err     error
+}
+
+func newGateEngine() *gateEngine {
+	return &gateEngine{started: make(chan struct{}, 10), release: make(chan struct{}, 10)}
+}
+
+func (e *gateEngine) HasScript(string) bool { return true }
+
+func (e *gateEngine) Execute(*ports.ScriptMessage) (*ports.ScriptResult, error) {
+	e.started <- struct{}{}
+	<-e.release
+	return &ports.ScriptResult{Content: lingo.NewLVoid()}, e.err
+}
+
+func waitStarted(t *testing.T, e *gateEngine) {
+	t.Helper()
+	select {
+	case <-e.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("job did not start")
+	}
+}
+
+func waitRuns(t *testing.T, s *inbound.Scheduler, name string, n int) []inbound.JobRun {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if runs := s.History(name); len(runs) >= n {
+			return runs
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("expected %d recorded runs of %s, got %d", n, name, len(s.History(name)))
+	return nil
+}
+
+func TestScheduler_TriggerSkipsWhileRunning(t *testing.T) {
+	engine := newGateEngine()
+	s := inbound.NewScheduler(engine, []inbound.ScheduledJob{
+		{Name: "slow", Interval: time.Hour},
+	}, &testutil.MockLogger{})
+	defer s.Stop()
+
+	if err := s.Trigger("slow"); err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	waitStarted(t, engine)
+	if err := s.Trigger("slow"); !errors.Is(err, inbound.ErrJobRunning) {
+		t.Fatalf("second Trigger = %v, want ErrJobRunning", err)
+	}
+	engine.release <- struct{}{}
+
+	runs := waitRuns(t, s, "slow", 1)
+	if !runs[0].Manual || runs[0].Err != "" || runs[0].Started.IsZero() {
+		t.Errorf("run = %+v, want a successful manual run", runs[0])
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := len(s.History("slow")); n != 1 {
+		t.Errorf("skipped trigger must not run, got %d runs", n)
+	}
+}
+
+func TestScheduler_OverlapQueueRunsAfterCurrent(t *testing.T) {
+	engine := newGateEngine()
+	s := inbound.NewScheduler(engine, []inbound.ScheduledJob{
+		{Name: "slow", Interval: time.Hour, Overlap: inbound.OverlapQueue},
+	}, &testutil.MockLogger{})
+	defer s.Stop()
+
+	s.Trigger("slow")
+	waitStarted(t, engine)
+	if err := s.Trigger("slow"); err != nil {
+		t.Fatalf("queued Trigger = %v, want nil", err)
+	}
+	if err := s.Trigger("slow"); !errors.Is(err, inbound.ErrJobRunning) {
+		t.Fatalf("third Trigger = %v, want ErrJobRunning (one waiting run at most)", err)
+	}
+
+	engine.release <- struct{}{}
+	waitStarted(t, engine) // the queued run starts only now
+	engine.release <- struct{}{}
+
+	runs := waitRuns(t, s, "slow", 2)
+	if !runs[0].Started.Before(runs[1].Started) || !runs[1].Manual {
+		t.Errorf("runs = %+v, want two manual runs in order", runs)
+	}
+}
+
+func TestScheduler_ScheduledRunsDoNotOverlap(t *testing.T) {
+	var running, maxRunning int32
+	engine := &testutil.MockScriptEngine{
+		HasScriptFunc: func(string) bool { return true },
+		ExecuteFunc: func(*ports.ScriptMessage) (*ports.ScriptResult, error) {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(40 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return &ports.ScriptResult{Content: lingo.NewLVoid()}, nil
+		},
+	}
+	s := inbound.NewScheduler(engine, []inbound.ScheduledJob{
+		{Name: "slow", Interval: 10 * time.Millisecond},
+	}, &testutil.MockLogger{})
+	s.Start()
+	time.Sleep(150 * time.Millisecond)
+	s.Stop()
+
+	if m := atomic.LoadInt32(&maxRunning); m != 1 {
+		t.Errorf("max concurrent runs = %d, want 1", m)
+	}
+	if n := len(s.History("slow")); n < 2 {
+		t.Errorf("expected at least 2 runs, got %d", n)
+	}
+}
+
+func TestScheduler_RecordsFailedRuns(t *testing.T) {
+	engine := newGateEngine()
+	engine.err = errors.New("boom")
+	engine.release <- struct{}{}
+	s := inbound.NewScheduler(engine, []inbound.ScheduledJob{
+		{Name: "flaky", Interval: time.Hour},
+	}, &testutil.MockLogger{})
+	defer s.Stop()
+
+	s.Trigger("flaky")
+	runs := waitRuns(t, s, "flaky", 1)
+	if runs[0].Err != "boom" {
+		t.Errorf("Err = %q, want boom", runs[0].Err)
+	}
+
+	if st := s.Status(); len(st) != 1 || st[0].LastRun == nil || st[0].LastRun.Err != "boom" {
+		t.Errorf("Status = %+v, want the failed run as LastRun", st)
+	}
+}
+
+func TestScheduler_TriggerUnknownJob(t *testing.T) {
+	s := inbound.NewScheduler(&countingEngine{}, nil, &testutil.MockLogger{})
+	if err := s.Trigger("nope"); !errors.Is(err, inbound.ErrJobNotFound) {
+		t.Errorf("Trigger = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestScheduler_InitialDelay(t *testing.T) {
+	engine := &countingEngine{}
+	s := inbound.NewScheduler(engine, []inbound.ScheduledJob{
+		{Name: "warmup", Interval: time.Hour, Delay: 20 * time.Millisecond},
+	}, &testutil.MockLogger{})
+	s.Start()
+	defer s.Stop()
+
+	if st := s.Status(); len(st) != 1 || time.Until(st[0].Next) > 20*time.Millisecond {
+		t.Errorf("Status = %+v, want the first run within the delay", st)
+	}
+	waitRuns(t, s, "warmup", 1)
+}
+
+func TestScheduler_SkipsInvalidCron(t *testing.T) {
+	s := inbound.NewScheduler(&countingEngine{}, []inbound.ScheduledJob{
+		{Name: "bad", Cron: "not a cron"},
+		{Name: "nightly", Cron: "0 3 * * *", TimeZone: "UTC"},
+	}, &testutil.MockLogger{})
+	if jobs := s.Jobs(); len(jobs) != 1 || jobs[0].Name != "nightly" {
+		t.Errorf("Jobs() = %v, want only nightly", jobs)
+	}
+}
+
+// Two instances sharing a lease store split the ticks of a job: each tick runs
+// on exactly one of them.
+func TestScheduler_LeasesRunEachTickOnce(t *testing.T) {
+	cache := outbound.NewMemoryCache()
+	defer cache.Close()
+	leases := outbound.NewCacheLeaseStore(cache)
+	jobs := []inbound.ScheduledJob{{Name: "payout", Interval: 20 * time.Millisecond}}
+
+	a := inbound.NewScheduler(&countingEngine{}, jobs, &testutil.MockLogger{})
+	b := inbound.NewScheduler(&countingEngine{}, jobs, &testutil.MockLogger{})
+	a.UseLeases(leases, "node-a")
+	b.UseLeases(leases, "node-b")
+	a.Start()
+	b.Start()
+	time.Sleep(150 * time.Millisecond)
+	a.Stop()
+	b.Stop()
+
+	ticks := map[time.Time]int{}
+	for _, run := range append(a.History("payout"), b.History("payout")...) {
+		ticks[run.Started.Truncate(20*time.Millisecond)]++
+	}
+	if len(ticks) < 4 {
+		t.Fatalf("expected at least 4 ticks in ~150ms, got %d", len(ticks))
+	}
+	for tick, n := range ticks {
+		if n != 1 {
+			t.Errorf("tick %v ran %d times, want once", tick, n)
+		}
+	}
+	owner := a.Status()[0].LeaseOwner
+	if owner != "node-a" && owner != "node-b" {
+		t.Errorf("LeaseOwner = %q, want one of the nodes", owner)
+	}
+}
+
+// recordingLeases remembers the keys it handed out.
+type recordingLeases struct {
+	ports.LeaseStore
+	mu   sync.Mutex
+	keys []string
+}
+
+func (r *recordingLeases) Acquire(key, owner string, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	r.keys = append(r.keys, key)
+	r.mu.Unlock()
+	return r.LeaseStore.Acquire(key, owner, ttl)
+}
+
+// An instance whose timer fires periods late, after a GC pause or clock
+// skew, must still find the tick it claims taken.
+func TestScheduler_LateClaimerFindsTickTaken(t *testing.T) {
+	cache := outbound.NewMemoryCache()
+	defer cache.Close()
+	leases := &recordingLeases{LeaseStore: outbound.NewCacheLeaseStore(cache)}
+	a := inbound.NewScheduler(&countingEngine{}, []inbound.ScheduledJob{{Name: "payout", Interval: 20 * time.Millisecond}}, &testutil.MockLogger{})
+	a.UseLeases(leases, "node-a")
+	a.Start()
+	for deadline := time.Now().Add(2 * time.Second); len(a.History("payout")) == 0; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("payout never ran")
+		}
+	}
+	a.Stop()
+
+	time.Sleep(100 * time.Millisecond) // five periods
+	leases.mu.Lock()
+	first := leases.keys[0]
+	leases.mu.Unlock()
+	if ok, err := leases.Acquire(first, "node-b", 20*time.Millisecond); err != nil || ok {
+		t.Errorf("late claim of %s: ok=%v err=%v, want it still held by node-a", first, ok, err)
+	}
+}
+
+type failingLeases struct{}
+
+func (failingLeases) Acquire(string, string, time.Duration) (bool, error) {
+	return false, errors.New("cache down")
+}
+func (failingLeases) Holder(string) (string, error) { return "", errors.New("cache down") }
+
+func TestScheduler_LeaseErrorSkipsRun(t *testing.T) {
+	engine := &countingEngine{}
+	s := inbound.NewScheduler(engine, []inbound.ScheduledJob{
+		{Name: "payout", Interval: 10 * time.Millisecond},
+	}, &testutil.MockLogger{})
+	s.UseLeases(failingLeases{}, "node-a")
+	s.Start()
+	time.Sleep(60 * time.Millisecond)
+	s.Stop()
+
+	if n := atomic.LoadInt32(&engine.calls); n != 0 {
+		t.Errorf("expected no runs without a lease, got %d", n)
+	}
+}
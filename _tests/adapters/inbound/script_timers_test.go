@@ -0,0 +1,191 @@
+package inbound_test
+
+import (
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/external/migrations"
+	"fsos-server/internal/adapters/inbound"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/services"
+	"fsos-server/internal/domain/types/lingo"
+)
+
+func newTimerDB(t *testing.T) *outbound.SQLiteDB {
+	t.Helper()
+	db, err := outbound.NewSQLiteDB(filepath.Join(t.TempDir(), "timers.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := services.NewMigrationRunner(db, db, migrations.All).RunPending(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestScriptTimers_FireOnceWithPayload(t *testing.T) {
+	var got atomic.Value
+	var calls int32
+	engine := &testutil.MockScriptEngine{
+		HasScriptFunc: func(subject string) bool { return true },
+		ExecuteFunc: func(msg *ports.ScriptMessage) (*ports.ScriptResult, error) {
+			atomic.AddInt32(&calls, 1)
+			got.Store(msg)
+			return &ports.ScriptResult{Content: lingo.NewLVoid()}, nil
+		},
+	}
+	db := newTimerDB(t)
+	timers := inbound.NewScriptTimerService(engine, db, &testutil.MockLogger{})
+	defer timers.Stop()
+
+	if _, err := timers.After(20*time.Millisecond, "auction/close", "alice", lingo.NewLInteger(42)); err != nil {
+		t.Fatalf("After: %v", err)
+	}
+	waitUntil(t, func() bool { return atomic.LoadInt32(&calls) == 1 })
+
+	msg := got.Load().(*ports.ScriptMessage)
+	if msg.Subject != "auction/close" || msg.SenderID != "alice" || !msg.System || msg.Content.ToInteger() != 42 {
+		t.Errorf("ran %+v, want auction/close for alice with 42 as a system run", msg)
+	}
+	if pending, _ := db.ListScriptTimers(); len(pending) != 0 {
+		t.Errorf("fired timer still stored: %+v", pending)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("fired %d times, want once", n)
+	}
+}
+
+func TestScriptTimers_CancelStopsTimer(t *testing.T) {
+	engine := &countingEngine{}
+	db := newTimerDB(t)
+	timers := inbound.NewScriptTimerService(engine, db, &testutil.MockLogger{})
+	defer timers.Stop()
+
+	id, err := timers.After(30*time.Millisecond, "respawn", "bob", nil)
+	if err != nil {
+		t.Fatalf("After: %v", err)
+	}
+	if err := timers.Cancel(id, "mallory"); !errors.Is(err, ports.ErrTimerNotFound) {
+		t.Fatalf("another sender's Cancel err = %v, want ErrTimerNotFound", err)
+	}
+	if err := timers.Cancel(id, "bob"); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if err := timers.Cancel(id, "bob"); !errors.Is(err, ports.ErrTimerNotFound) {
+		t.Errorf("second Cancel err = %v, want ErrTimerNotFound", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if n := atomic.LoadInt32(&engine.calls); n != 0 {
+		t.Errorf("cancelled timer fired %d times", n)
+	}
+}
+
+func TestScriptTimers_RetryWhenQueueIsFull(t *testing.T) {
+	var calls int32
+	engine := &testutil.MockScriptEngine{
+		HasScriptFunc: func(subject string) bool { return true },
+		ExecuteFunc: func(msg *ports.ScriptMessage) (*ports.ScriptResult, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return nil, ports.ErrScriptQueueFull
+			}
+			return &ports.ScriptResult{Content: lingo.NewLVoid()}, nil
+		},
+	}
+	db := newTimerDB(t)
+	timers := inbound.NewScriptTimerService(engine, db, &testutil.MockLogger{})
+	defer timers.Stop()
+
+	if _, err := timers.After(10*time.Millisecond, "respawn", "bob", nil); err != nil {
+		t.Fatalf("After: %v", err)
+	}
+	// The refused timer is stored again for its retry
+	waitUntil(t, func() bool {
+		pending, _ := db.ListScriptTimers()
+		return atomic.LoadInt32(&calls) == 1 && len(pending) == 1
+	})
+	waitUntil(t, func() bool { return atomic.LoadInt32(&calls) == 2 })
+	time.Sleep(20 * time.Millisecond)
+	if pending, _ := db.ListScriptTimers(); len(pending) != 0 {
+		t.Errorf("stored timers = %+v after the retry ran", pending)
+	}
+}
+
+func TestScriptTimers_RejectsBadArguments(t *testing.T) {
+	engine := &countingEngine{has: func(subject string) bool { return subject == "respawn" }}
+	timers := inbound.NewScriptTimerService(engine, newTimerDB(t), &testutil.MockLogger{})
+	defer timers.Stop()
+
+	for name, delay := range map[string]time.Duration{"zero": 0, "too long": inbound.MaxScriptTimerDelay + time.Second} {
+		if _, err := timers.After(delay, "respawn", "bob", nil); err == nil {
+			t.Errorf("%s delay accepted", name)
+		}
+	}
+	if _, err := timers.After(time.Second, "missing", "bob", nil); err == nil {
+		t.Error("a subject without a script should be refused")
+	}
+}
+
+func TestScriptTimers_SurviveRestart(t *testing.T) {
+	db := newTimerDB(t)
+	before := inbound.NewScriptTimerService(&countingEngine{}, db, &testutil.MockLogger{})
+	if _, err := before.After(time.Hour, "auction/close", "alice", nil); err != nil {
+		t.Fatalf("After: %v", err)
+	}
+	before.Stop()
+
+	// Overdue by the time the server is back: fires at once, exactly once,
+	// even with two instances sharing the DB.
+	rewindTimers(t, db)
+	engine := &countingEngine{}
+	first := inbound.NewScriptTimerService(engine, db, &testutil.MockLogger{})
+	second := inbound.NewScriptTimerService(engine, db, &testutil.MockLogger{})
+	defer first.Stop()
+	defer second.Stop()
+	if err := first.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := second.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	waitUntil(t, func() bool { return atomic.LoadInt32(&engine.calls) == 1 })
+	time.Sleep(30 * time.Millisecond)
+	if n := atomic.LoadInt32(&engine.calls); n != 1 {
+		t.Errorf("recovered timer fired %d times, want once", n)
+	}
+}
+
+// rewindTimers moves every stored timer's fire time into the past.
+func rewindTimers(t *testing.T, db *outbound.SQLiteDB) {
+	t.Helper()
+	timers, err := db.ListScriptTimers()
+	if err != nil || len(timers) == 0 {
+		t.Fatalf("stored timers = %v, %v", timers, err)
+	}
+	for _, timer := range timers {
+		timer.FireAt = time.Now().Add(-time.Minute)
+		if err := db.DeleteScriptTimer(timer.ID, ""); err != nil {
+			t.Fatalf("rewind: %v", err)
+		}
+		if err := db.CreateScriptTimer(timer); err != nil {
+			t.Fatalf("rewind: %v", err)
+		}
+	}
+}
+
+func waitUntil(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,96 @@
+package inbound_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/inbound"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+)
+
+func writeLua(t *testing.T, dir, name, src string) {
+	t.Helper()
+	path := filepath.Join(dir, name+".lua")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+}
+
+func jobIntervals(s *inbound.Scheduler) map[string]time.Duration {
+	got := make(map[string]time.Duration)
+	for _, j := range s.Jobs() {
+		got[j.Name] = j.Interval
+	}
+	return got
+}
+
+func TestScriptWatcher_ReschedulesChangedJobs(t *testing.T) {
+	dir := t.TempDir()
+	writeLua(t, dir, "jobs/a", "-- @job interval=60\nmus.response(1)")
+	log := &testutil.MockLogger{}
+	engine := outbound.NewLuaScriptEngine(dir, log, 5, nil, nil, nil, nil, nil, nil, nil)
+
+	jobs := inbound.DiscoverJobs(dir, log)
+	scheduler := inbound.NewScheduler(engine, jobs, log)
+	scheduler.Start()
+	defer scheduler.Stop()
+	watcher := inbound.NewScriptWatcher(dir, time.Hour, engine, scheduler, jobs, log)
+
+	writeLua(t, dir, "jobs/a", "-- @job interval=120\nmus.response(1)")
+	writeLua(t, dir, "jobs/b", "-- @job interval=30\nmus.response(2)")
+	watcher.Poll()
+	if got := jobIntervals(scheduler); len(got) != 2 || got["a"] != 120*time.Second || got["b"] != 30*time.Second {
+		t.Fatalf("after edit/add jobs = %v, want a=2m b=30s", got)
+	}
+
+	os.Remove(filepath.Join(dir, "jobs", "a.lua"))
+	watcher.Poll()
+	if got := jobIntervals(scheduler); len(got) != 1 || got["b"] != 30*time.Second {
+		t.Fatalf("after removal jobs = %v, want only b", got)
+	}
+}
+
+func TestScriptWatcher_BrokenFileKeepsLastGoodVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeLua(t, dir, "jobs/b", "-- @job interval=30\nmus.response(2)")
+	writeLua(t, dir, "greet", `mus.response("v1")`)
+	log := &testutil.MockLogger{}
+	engine := outbound.NewLuaScriptEngine(dir, log, 5, nil, nil, nil, nil, nil, nil, nil)
+	if _, err := engine.Execute(&ports.ScriptMessage{Subject: "greet", Content: lingo.NewLVoid()}); err != nil {
+		t.Fatalf("greet: %v", err)
+	}
+
+	jobs := inbound.DiscoverJobs(dir, log)
+	scheduler := inbound.NewScheduler(engine, jobs, log)
+	scheduler.Start()
+	defer scheduler.Stop()
+	watcher := inbound.NewScriptWatcher(dir, time.Hour, engine, scheduler, jobs, log)
+
+	writeLua(t, dir, "jobs/b", "-- @job interval=5\nmus.response(")
+	writeLua(t, dir, "greet", `mus.response("v2" ..`)
+	writeLua(t, dir, "jobs/c", "-- @job interval=10\nthis is not lua")
+	watcher.Poll()
+
+	if got := jobIntervals(scheduler); len(got) != 1 || got["b"] != 30*time.Second {
+		t.Errorf("jobs = %v, want b kept at 30s and broken new job c not scheduled", got)
+	}
+	result, err := engine.Execute(&ports.ScriptMessage{Subject: "greet", Content: lingo.NewLVoid()})
+	if err != nil || lingo.StringValue(result.Content) != "v1" {
+		t.Errorf("greet = %v, %v; want last good version v1", result, err)
+	}
+
+	writeLua(t, dir, "greet", `mus.response("v3")`)
+	watcher.Poll()
+	result, err = engine.Execute(&ports.ScriptMessage{Subject: "greet", Content: lingo.NewLVoid()})
+	if err != nil || lingo.StringValue(result.Content) != "v3" {
+		t.Errorf("greet = %v, %v; want fixed version v3", result, err)
+	}
+}
@@ -0,0 +1,39 @@
+package outbound_test
+
+import (
+	"testing"
+	"time"
+
+	"fsos-server/internal/adapters/outbound"
+)
+
+func TestCacheLeaseStore_ExclusiveUntilExpiry(t *testing.T) {
+	cache := outbound.NewMemoryCache()
+	defer cache.Close()
+	leases := outbound.NewCacheLeaseStore(cache)
+
+	if ok, err := leases.Acquire("jobs/daily/100", "node-a", 50*time.Millisecond); err != nil || !ok {
+		t.Fatalf("Acquire node-a = %v, %v; want true", ok, err)
+	}
+	if ok, _ := leases.Acquire("jobs/daily/100", "node-b", 50*time.Millisecond); ok {
+		t.Error("node-b must not take a held lease")
+	}
+	if owner, _ := leases.Holder("jobs/daily/100"); owner != "node-a" {
+		t.Errorf("Holder = %q, want node-a", owner)
+	}
+	if ok, _ := leases.Acquire("jobs/daily/200", "node-b", 50*time.Millisecond); !ok {
+		t.Error("a different key is a different lease")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if owner, _ := leases.Holder("jobs/daily/100"); owner != "" {
+		t.Errorf("Holder after expiry = %q, want empty", owner)
+	}
+	if ok, _ := leases.Acquire("jobs/daily/100", "node-b", time.Second); !ok {
+		t.Error("an expired lease must be free again")
+	}
+	// Leases don't collide with script cache keys.
+	if v, _ := cache.Get("jobs/daily/100"); v != nil {
+		t.Errorf("lease stored under the bare key: %q", v)
+	}
+}
@@ -0,0 +1,134 @@
+package outbound_test
+
+import (
+	"testing"
+	"time"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+)
+
+type fakeKillTimers struct {
+	set map[string]int
+}
+
+func (f *fakeKillTimers) SetServerKillTimer(int)     {}
+func (f *fakeKillTimers) CancelServerKillTimer()     {}
+func (f *fakeKillTimers) CancelUserKillTimer(string) {}
+func (f *fakeKillTimers) Stop()                      {}
+func (f *fakeKillTimers) SetUserKillTimer(clientID string, minutes int) {
+	f.set[clientID] = minutes
+}
+
+func TestAdmin_KickTimerAndAudit(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "mod", `
+local target = mus.getContent()
+assert(mus.admin.kick(target, "spam"))
+assert(mus.admin.setKillTimer("carol", 5))
+local ok, err = mus.admin.kick("nobody")
+mus.response(ok == false and err ~= nil)
+`)
+	ss := testutil.NewMockSessionStore()
+	ss.RegisterConnection("alice", "10.0.0.1")
+	ss.RegisterConnection("bob", "10.0.0.2")
+	conns := &testutil.MockConnectionWriter{}
+	timers := &fakeKillTimers{set: map[string]int{}}
+	logger := &testutil.MockLogger{}
+	engine := outbound.NewLuaScriptEngine(dir, logger, 5, nil, nil, nil, nil, ss, nil, nil)
+	engine.UseModeration(conns, timers)
+
+	res, err := engine.Execute(&ports.ScriptMessage{Subject: "mod", SenderID: "alice", Content: lingo.NewLString("bob")})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if res.Content.ToInteger() != 1 {
+		t.Error("kicking an offline user must return false, err")
+	}
+	if len(conns.Disconnects) != 1 || conns.Disconnects[0] != "bob" {
+		t.Errorf("disconnects = %v, want [bob]", conns.Disconnects)
+	}
+	if online, _ := ss.IsConnected("bob"); online {
+		t.Error("a kicked user's session must be dropped")
+	}
+	if timers.set["carol"] != 5 {
+		t.Errorf("kill timers = %v", timers.set)
+	}
+
+	var audited []testutil.LogEntry
+	for _, m := range logger.Messages {
+		if m.Msg == "Moderation action" || m.Msg == "Moderation action failed" {
+			audited = append(audited, m)
+		}
+	}
+	if len(audited) != 3 {
+		t.Fatalf("audit entries = %d, want 3", len(audited))
+	}
+	if f := audited[0].Fields; f["action"] != "kick" || f["target"] != "bob" || f["script"] != "mod" || f["sender"] != "alice" || f["reason"] != "spam" {
+		t.Errorf("kick audit = %v", f)
+	}
+	if audited[2].Level != ports.WARN || audited[2].Fields["error"] == nil {
+		t.Errorf("failed kick audit = %+v", audited[2])
+	}
+}
+
+func TestAdmin_BanIPKicksSessionsFromIP(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "banip", `assert(mus.admin.banIP("10.0.0.7", "botnet", 3600))`)
+	ss := testutil.NewMockSessionStore()
+	ss.RegisterConnection("bot1", "10.0.0.7")
+	ss.RegisterConnection("bot2", "10.0.0.7")
+	ss.RegisterConnection("alice", "10.0.0.1")
+
+	var bannedIP string
+	var expires *time.Time
+	db := &testutil.MockDBAdapter{CreateBanFunc: func(userID *int64, ip *string, reason string, expiresAt *time.Time) error {
+		if userID != nil || ip == nil {
+			t.Errorf("want an IP-only ban")
+		} else {
+			bannedIP = *ip
+		}
+		expires = expiresAt
+		return nil
+	}}
+	conns := &testutil.MockConnectionWriter{}
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, db, nil, ss, nil, nil)
+	engine.UseModeration(conns, nil)
+
+	if _, err := engine.Execute(&ports.ScriptMessage{Subject: "banip", SenderID: "system.jobs", Content: lingo.NewLVoid()}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if bannedIP != "10.0.0.7" || expires == nil || time.Until(*expires) < 59*time.Minute {
+		t.Errorf("ban = %q until %v", bannedIP, expires)
+	}
+	if len(conns.Disconnects) != 2 {
+		t.Errorf("disconnects = %v, want both bots", conns.Disconnects)
+	}
+	if online, _ := ss.IsConnected("alice"); !online {
+		t.Error("other IPs must stay connected")
+	}
+}
+
+func TestAdmin_SetUserLevelUpdatesLiveSession(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "promote", `assert(mus.admin.setUserLevel("bob", 60))`)
+	ss := testutil.NewMockSessionStore()
+	ss.RegisterConnection("bob", "10.0.0.2")
+	stored := 0
+	db := &testutil.MockDBAdapter{UpdateUserLevelFunc: func(username string, level int) error {
+		stored = level
+		return nil
+	}}
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, db, nil, ss, nil, nil)
+	engine.UseModeration(&testutil.MockConnectionWriter{}, nil)
+
+	if _, err := engine.Execute(&ports.ScriptMessage{Subject: "promote", SenderID: "alice", Content: lingo.NewLVoid()}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	level, _ := ss.GetUserAttribute("bob", "#userLevel")
+	if stored != 60 || level == nil || level.ToInteger() != 60 {
+		t.Errorf("stored level %d, session level %v; want 60", stored, level)
+	}
+}
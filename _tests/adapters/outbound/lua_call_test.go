@@ -0,0 +1,151 @@
+package outbound_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+)
+
+func TestCall_ComposesScriptsAndMergesReplies(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "price", `
+mus.reply("priced", mus.getContent())
+mus.response(mus.getContent() * 2, 7)
+`)
+	writeScript(t, dir, "shop", `
+local price, code = mus.call("price", 21)
+mus.response(price + code)
+`)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+
+	res, err := engine.Execute(&ports.ScriptMessage{Subject: "shop", SenderID: "alice", Content: lingo.NewLVoid()})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if got := res.Content.ToInteger(); got != 49 {
+		t.Errorf("content = %d, want 49", got)
+	}
+	if len(res.Replies) != 1 || res.Replies[0].Subject != "priced" {
+		t.Errorf("replies = %+v, want the callee's priced reply", res.Replies)
+	}
+}
+
+func TestCall_FailureRaisesInCaller(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "broken", `error("out of stock")`)
+	writeScript(t, dir, "shop", `
+local ok, err = pcall(mus.call, "broken")
+mus.response(tostring(err))
+`)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+
+	res, err := engine.Execute(&ports.ScriptMessage{Subject: "shop", SenderID: "alice", Content: lingo.NewLVoid()})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if msg := lingo.StringValue(res.Content); !strings.Contains(msg, "out of stock") {
+		t.Errorf("error = %q, want the callee's message", msg)
+	}
+}
+
+func TestCall_StopsRecursionAtDepthLimit(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "loop", `mus.call("loop")`)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+
+	start := time.Now()
+	_, err := engine.Execute(&ports.ScriptMessage{Subject: "loop", SenderID: "alice", Content: lingo.NewLVoid()})
+	if err == nil || !strings.Contains(err.Error(), "nested more than") {
+		t.Fatalf("err = %v, want the depth limit", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("recursion should fail at once, not at the timeout")
+	}
+}
+
+func TestCall_SharesCallersDeadline(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "spin", `while true do end`)
+	writeScript(t, dir, "outer", `mus.call("spin")`)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 1, nil, nil, nil, nil, nil, nil, nil)
+
+	start := time.Now()
+	if _, err := engine.Execute(&ports.ScriptMessage{Subject: "outer", SenderID: "alice", Content: lingo.NewLVoid()}); err == nil {
+		t.Fatal("a callee spinning forever should fail the caller")
+	}
+	if elapsed := time.Since(start); elapsed > 1800*time.Millisecond {
+		t.Errorf("took %v, want the caller's 1s budget, not a fresh one per call", elapsed)
+	}
+}
+
+type fakeSystemRunner struct {
+	senderID, subject string
+	content           lingo.LValue
+	err               error
+}
+
+func (f *fakeSystemRunner) RunSystemCommand(senderID, subject string, content lingo.LValue) (int32, lingo.LValue, error) {
+	f.senderID, f.subject, f.content = senderID, subject, content
+	if f.err != nil {
+		return 0, nil, f.err
+	}
+	return -5, lingo.NewLString("answer"), nil
+}
+
+func TestSystem_RunsCommandAsSender(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "join", `
+local code, answer = mus.system("system.group.join", "@team")
+mus.response({code = code, answer = answer})
+`)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+	runner := &fakeSystemRunner{}
+	engine.UseSystemCommands(runner)
+
+	res, err := engine.Execute(&ports.ScriptMessage{Subject: "join", SenderID: "alice", Content: lingo.NewLVoid()})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if runner.senderID != "alice" || runner.subject != "system.group.join" || lingo.StringValue(runner.content) != "@team" {
+		t.Errorf("ran %+v, want system.group.join @team as alice", runner)
+	}
+	props := res.Content.(*lingo.LPropList)
+	if got := contextField(props, "code"); got != "-5" {
+		t.Errorf("code = %s, want -5", got)
+	}
+	if got := contextField(props, "answer"); got != "answer" {
+		t.Errorf("answer = %s, want answer", got)
+	}
+}
+
+func TestSystem_ErrorRaises(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "logon", `mus.system("Logon")`)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+	engine.UseSystemCommands(&fakeSystemRunner{err: errors.New("refused")})
+
+	_, err := engine.Execute(&ports.ScriptMessage{Subject: "logon", SenderID: "alice", Content: lingo.NewLVoid()})
+	if err == nil || !strings.Contains(err.Error(), "refused") {
+		t.Errorf("err = %v, want the runner's error", err)
+	}
+}
+
+func TestSystem_AbsentWithoutRunner(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "probe", `mus.response(mus.system == nil)`)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+
+	res, err := engine.Execute(&ports.ScriptMessage{Subject: "probe", SenderID: "alice", Content: lingo.NewLVoid()})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if res.Content.ToInteger() != 1 {
+		t.Error("mus.system should be absent until System commands are bound")
+	}
+}
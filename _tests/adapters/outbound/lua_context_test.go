@@ -0,0 +1,82 @@
+package outbound_test
+
+import (
+	"testing"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+)
+
+const contextScript = `
+local ctx = mus.getContext()
+mus.response({
+	userID = ctx.userID, system = ctx.system, userLevel = ctx.userLevel or -1,
+	movieID = ctx.movieID or "", ip = ctx.ip or "", transport = ctx.transport or "",
+	connected = ctx.connectedAt ~= nil, groups = #(ctx.groups or {}),
+})
+`
+
+func runContextScript(t *testing.T, ss ports.SessionStore, msg *ports.ScriptMessage) *lingo.LPropList {
+	t.Helper()
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "ctx", contextScript)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, ss, nil, nil)
+	msg.Subject = "ctx"
+	res, err := engine.Execute(msg)
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	props, ok := res.Content.(*lingo.LPropList)
+	if !ok {
+		t.Fatalf("content = %v, want a prop list", res.Content)
+	}
+	return props
+}
+
+func contextField(props *lingo.LPropList, name string) string {
+	for i, k := range props.Properties {
+		if k.String() == name {
+			return lingo.StringValue(props.Values[i])
+		}
+	}
+	return "<missing>"
+}
+
+func TestGetContext_TCPClient(t *testing.T) {
+	ss := outbound.NewMemorySessionStore()
+	ss.RegisterConnection("alice", "10.0.0.7")
+	ss.SetUserAttribute("alice", "#userLevel", lingo.NewLInteger(40))
+	ss.SetUserAttribute("alice", "#movieID", lingo.NewLString("lobby"))
+
+	props := runContextScript(t, ss, &ports.ScriptMessage{SenderID: "alice", Content: lingo.NewLVoid()})
+	for field, want := range map[string]string{
+		"userID": "alice", "userLevel": "40", "movieID": "lobby",
+		"ip": "10.0.0.7", "transport": "tcp", "connected": "1",
+	} {
+		if got := contextField(props, field); got != want {
+			t.Errorf("%s = %s, want %s", field, got, want)
+		}
+	}
+}
+
+func TestGetContext_UDPSender(t *testing.T) {
+	props := runContextScript(t, outbound.NewMemorySessionStore(), &ports.ScriptMessage{SenderID: "10.0.0.9:4000", Content: lingo.NewLVoid()})
+	if ip, transport := contextField(props, "ip"), contextField(props, "transport"); ip != "10.0.0.9" || transport != "udp" {
+		t.Errorf("ip = %s, transport = %s, want 10.0.0.9 over udp", ip, transport)
+	}
+	if got := contextField(props, "userLevel"); got != "0" {
+		t.Errorf("userLevel = %s, want 0 without a session", got)
+	}
+}
+
+func TestGetContext_SystemCaller(t *testing.T) {
+	props := runContextScript(t, outbound.NewMemorySessionStore(), &ports.ScriptMessage{SenderID: "system.jobs", Content: lingo.NewLVoid(), System: true})
+	if got := contextField(props, "system"); got != "1" {
+		t.Errorf("system = %s, want true (1)", got)
+	}
+	if got := contextField(props, "transport"); got != "" {
+		t.Errorf("transport = %q, want none for a system caller", got)
+	}
+}
@@ -0,0 +1,69 @@
+package outbound_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+)
+
+func runCryptoScript(t *testing.T, src string) []lingo.LValue {
+	t.Helper()
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "crypto", src)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+	res, err := engine.Execute(&ports.ScriptMessage{Subject: "crypto", SenderID: "alice", Content: lingo.NewLVoid()})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	return res.Content.(*lingo.LList).Values
+}
+
+func TestCryptoModule_DigestsAndHMAC(t *testing.T) {
+	got := runCryptoScript(t, `
+mus.response({
+	mus.crypto.sha256("invite:42"),
+	mus.crypto.hmac("k3y", "invite:42"),
+	mus.crypto.hmac("k3y", "invite:42", "sha512"),
+	pcall(mus.crypto.hmac, "k3y", "x", "md5"),
+})
+`)
+	sum := sha256.Sum256([]byte("invite:42"))
+	mac := hmac.New(sha256.New, []byte("k3y"))
+	mac.Write([]byte("invite:42"))
+	mac512 := hmac.New(sha512.New, []byte("k3y"))
+	mac512.Write([]byte("invite:42"))
+
+	for i, want := range []string{hex.EncodeToString(sum[:]), hex.EncodeToString(mac.Sum(nil)), hex.EncodeToString(mac512.Sum(nil)), "0"} {
+		if g := lingo.StringValue(got[i]); g != want {
+			t.Errorf("result %d = %s, want %s", i+1, g, want)
+		}
+	}
+}
+
+func TestCryptoModule_RandomTokens(t *testing.T) {
+	got := runCryptoScript(t, `
+local a, b = mus.crypto.token(), mus.crypto.token()
+mus.response({
+	a, a ~= b, #mus.crypto.randomBytes(16), #mus.crypto.token(9),
+	pcall(mus.crypto.randomBytes, 0), pcall(mus.crypto.token, 4096),
+	mus.crypto.equal(a, a), mus.crypto.equal(a, b),
+})
+`)
+	raw, err := base64.RawURLEncoding.DecodeString(lingo.StringValue(got[0]))
+	if err != nil || len(raw) != 32 {
+		t.Errorf("token %q: %d bytes, %v; want 32 URL-safe base64 bytes", lingo.StringValue(got[0]), len(raw), err)
+	}
+	for i, want := range []int32{1, 16, 12, 0, 0, 1, 0} {
+		if g := got[i+1].ToInteger(); g != want {
+			t.Errorf("result %d = %d, want %d", i+2, g, want)
+		}
+	}
+}
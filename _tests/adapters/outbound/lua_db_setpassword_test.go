@@ -18,8 +18,9 @@ mus.db.createUser("hero", "oldpass", 20)
 mus.db.setPassword("hero", "newpass")
 `)
 
+	// Two DefaultCost hashes can take seconds under -race.
 	db := newTestDB(t)
-	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, db, db.QueryBuilder(), nil, nil, nil)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 30, nil, nil, db, db.QueryBuilder(), nil, nil, nil)
 
 	if _, err := engine.Execute(&ports.ScriptMessage{Subject: "setpw", SenderID: "sys", Content: lingo.NewLVoid()}); err != nil {
 		t.Fatalf("execute: %v", err)
@@ -44,3 +45,37 @@ mus.db.setPassword("hero", "newpass")
 		t.Error("stored hash must no longer validate against the old password")
 	}
 }
+
+func TestLuaDB_CheckPassword(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, dir, "checkpw", `
+mus.response({
+	mus.db.checkPassword("hero", "secret"),
+	mus.db.checkPassword("hero", "guess"),
+	mus.db.checkPassword("nobody", "secret"),
+})
+`)
+
+	// The fixture hash is MinCost so that only the unknown-user path pays for
+	// DefaultCost bcrypt, which is slow under -race.
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	db := newTestDB(t)
+	if err := db.CreateUser("hero", string(hash), 20); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 30, nil, nil, db, db.QueryBuilder(), nil, nil, nil)
+
+	res, err := engine.Execute(&ports.ScriptMessage{Subject: "checkpw", SenderID: "sys", Content: lingo.NewLVoid()})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	list := res.Content.(*lingo.LList)
+	for i, want := range []int32{1, 0, 0} {
+		if got := list.Values[i].ToInteger(); got != want {
+			t.Errorf("check %d = %d, want %d", i+1, got, want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package outbound_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+)
+
+func newHTTPEngine(t *testing.T, timeoutSecs int, policy outbound.ScriptHTTPPolicy, scripts map[string]string) *outbound.LuaScriptEngine {
+	t.Helper()
+	dir := setupScriptsDir(t)
+	for name, src := range scripts {
+		writeScript(t, dir, name, src)
+	}
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, timeoutSecs, nil, nil, nil, nil, nil, nil, nil)
+	engine.UseHTTP(policy)
+	return engine
+}
+
+func localPolicy() outbound.ScriptHTTPPolicy {
+	return outbound.ScriptHTTPPolicy{AllowedHosts: []string{"127.0.0.1"}, MaxResponseBytes: 1024, MaxConcurrent: 4}
+}
+
+// fetchScript requests the URL in its content and answers with the response,
+// or with the error.
+const fetchScript = `
+local res, err = mus.http.request{url = mus.getContent()}
+if not res then
+	mus.response("error: " .. err)
+	return
+end
+mus.response(res.body)
+`
+
+func fetch(t *testing.T, engine *outbound.LuaScriptEngine, url string) string {
+	t.Helper()
+	res, err := engine.Execute(&ports.ScriptMessage{Subject: "fetch", SenderID: "alice", Content: lingo.NewLString(url)})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	return lingo.StringValue(res.Content)
+}
+
+func TestHTTPModule_RequestAndResponse(t *testing.T) {
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Receipt", "valid")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, r.Method+" "+r.URL.Path+" "+r.Header.Get("Authorization")+" "+string(body))
+	}))
+	defer stub.Close()
+
+	engine := newHTTPEngine(t, 5, localPolicy(), map[string]string{"validate": `
+local res, err = mus.http.request{
+	method = "post",
+	url = mus.getContent() .. "/receipts",
+	headers = {Authorization = "Bearer s3cret"},
+	body = "receipt-42",
+	timeout = 2,
+}
+mus.response({status = res.status, receipt = res.headers["x-receipt"], body = res.body})
+`})
+	res, err := engine.Execute(&ports.ScriptMessage{Subject: "validate", SenderID: "alice", Content: lingo.NewLString(stub.URL)})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	props := res.Content.(*lingo.LPropList)
+	if got := contextField(props, "status"); got != "201" {
+		t.Errorf("status = %s, want 201", got)
+	}
+	if got := contextField(props, "receipt"); got != "valid" {
+		t.Errorf("x-receipt = %s, want valid", got)
+	}
+	if got := contextField(props, "body"); got != "POST /receipts Bearer s3cret receipt-42" {
+		t.Errorf("body = %q, want the request echoed", got)
+	}
+}
+
+func TestHTTPModule_OnlyAllowedHosts(t *testing.T) {
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/away" {
+			http.Redirect(w, r, strings.Replace("http://"+r.Host, "127.0.0.1", "localhost", 1)+"/", http.StatusFound)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer stub.Close()
+	engine := newHTTPEngine(t, 5, localPolicy(), map[string]string{"fetch": fetchScript})
+
+	if got := fetch(t, engine, stub.URL); got != "ok" {
+		t.Errorf("allowed host answered %q, want ok", got)
+	}
+	for name, url := range map[string]string{
+		"other host":   strings.Replace(stub.URL, "127.0.0.1", "localhost", 1),
+		"redirect":     stub.URL + "/away",
+		"other scheme": "file:///etc/passwd",
+	} {
+		if got := fetch(t, engine, url); !strings.Contains(got, "not allowed") {
+			t.Errorf("%s: got %q, want a host not allowed error", name, got)
+		}
+	}
+}
+
+func TestHTTPModule_CapsResponseSize(t *testing.T) {
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, strings.Repeat("x", 2048))
+	}))
+	defer stub.Close()
+	engine := newHTTPEngine(t, 5, localPolicy(), map[string]string{"fetch": fetchScript})
+
+	if got := fetch(t, engine, stub.URL); !strings.Contains(got, "larger than 1024 bytes") {
+		t.Errorf("got %q, want the size cap error", got)
+	}
+}
+
+func TestHTTPModule_Timeouts(t *testing.T) {
+	release := make(chan struct{})
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer stub.Close()
+	defer close(release)
+	engine := newHTTPEngine(t, 1, localPolicy(), map[string]string{
+		"fetch": fetchScript,
+		"quick": `
+local res, err = mus.http.request{url = mus.getContent(), timeout = 0.05}
+mus.response(err)
+`,
+	})
+
+	res, err := engine.Execute(&ports.ScriptMessage{Subject: "quick", SenderID: "alice", Content: lingo.NewLString(stub.URL)})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if got := lingo.StringValue(res.Content); !strings.Contains(got, "deadline exceeded") {
+		t.Errorf("err = %q, want the request timeout", got)
+	}
+
+	// Without a timeout of its own the request ends with the script
+	start := time.Now()
+	if _, err := engine.Execute(&ports.ScriptMessage{Subject: "fetch", SenderID: "alice", Content: lingo.NewLString(stub.URL)}); err == nil {
+		t.Error("a request hanging past SCRIPT_TIMEOUT should fail the script")
+	}
+	if elapsed := time.Since(start); elapsed > 1800*time.Millisecond {
+		t.Errorf("took %v, want the script's 1s budget", elapsed)
+	}
+}
+
+func TestHTTPModule_LimitsRequestsInFlightPerScript(t *testing.T) {
+	arrived := make(chan struct{}, 1)
+	release := make(chan struct{})
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+		io.WriteString(w, "done")
+	}))
+	defer stub.Close()
+	policy := localPolicy()
+	policy.MaxConcurrent = 1
+	engine := newHTTPEngine(t, 5, policy, map[string]string{"fetch": fetchScript, "other": fetchScript})
+
+	var wg sync.WaitGroup
+	var first string
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		first = fetch(t, engine, stub.URL)
+	}()
+	<-arrived
+
+	if got := fetch(t, engine, stub.URL); !strings.Contains(got, "too many requests in flight") {
+		t.Errorf("second run got %q, want the concurrency limit", got)
+	}
+	// The limit is per script: another one still gets through
+	done := make(chan string)
+	go func() {
+		res, err := engine.Execute(&ports.ScriptMessage{Subject: "other", SenderID: "bob", Content: lingo.NewLString(stub.URL)})
+		if err != nil {
+			done <- err.Error()
+			return
+		}
+		done <- lingo.StringValue(res.Content)
+	}()
+	<-arrived
+	close(release)
+	if got := <-done; got != "done" {
+		t.Errorf("other script got %q, want done", got)
+	}
+	wg.Wait()
+	if first != "done" {
+		t.Errorf("first run got %q, want done", first)
+	}
+	if got := fetch(t, engine, stub.URL); got != "done" {
+		t.Errorf("after release got %q, want the slot back", got)
+	}
+}
+
+func TestHTTPModule_AbsentWithoutPolicy(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "probe", `mus.response(mus.http == nil)`)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+
+	res, err := engine.Execute(&ports.ScriptMessage{Subject: "probe", SenderID: "alice", Content: lingo.NewLVoid()})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if res.Content.ToInteger() != 1 {
+		t.Error("mus.http should be absent until hosts are allowed")
+	}
+}
@@ -0,0 +1,141 @@
+package outbound_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+)
+
+// runLimited runs src as the script "limited" under limits and returns the
+// execution error.
+func runLimited(t *testing.T, limits outbound.ScriptLimits, sender ports.MessageSender, scripts map[string]string) error {
+	t.Helper()
+	dir := setupScriptsDir(t)
+	for name, src := range scripts {
+		writeScript(t, dir, name, src)
+	}
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, sender, nil, nil, nil, nil, nil)
+	engine.UseLimits(limits)
+	_, err := engine.Execute(&ports.ScriptMessage{Subject: "limited", SenderID: "alice", Content: lingo.NewLVoid()})
+	return err
+}
+
+func wantScriptError(t *testing.T, err error, want string) {
+	t.Helper()
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("err = %v, want one mentioning %q", err, want)
+	}
+}
+
+func TestLimits_InstructionBudget(t *testing.T) {
+	start := time.Now()
+	err := runLimited(t, outbound.ScriptLimits{MaxInstructions: 10000}, nil, map[string]string{"limited": `
+pcall(function() while true do end end)
+mus.response("escaped")
+`})
+	wantScriptError(t, err, "instruction budget of 10000 exceeded")
+	if time.Since(start) > time.Second {
+		t.Error("the budget should stop the loop long before the timeout")
+	}
+}
+
+func TestLimits_BudgetCoversCalledScripts(t *testing.T) {
+	err := runLimited(t, outbound.ScriptLimits{MaxInstructions: 10000}, nil, map[string]string{
+		"work":    `for i = 1, 4000 do end`,
+		"limited": `for i = 1, 5 do mus.call("work") end`,
+	})
+	wantScriptError(t, err, "instruction budget")
+}
+
+func TestLimits_Memory(t *testing.T) {
+	limits := outbound.ScriptLimits{MaxMemory: 1 << 20}
+	err := runLimited(t, limits, nil, map[string]string{"limited": `
+local t = {}
+for i = 1, 1000000 do t[i] = "item " .. i end
+`})
+	wantScriptError(t, err, "memory limit of 1048576 bytes exceeded")
+
+	err = runLimited(t, limits, nil, map[string]string{"limited": `local s = string.rep("x", 2000000)`})
+	wantScriptError(t, err, "string.rep: result over the memory limit")
+
+	err = runLimited(t, limits, nil, map[string]string{"limited": `
+local t = {}
+for i = 1, 10000 do t[i] = i end
+mus.response(#string.rep("ab", 1000))
+`})
+	if err != nil {
+		t.Errorf("a script within the limit failed: %v", err)
+	}
+}
+
+func TestLimits_MemoryBetweenSamples(t *testing.T) {
+	limits := outbound.ScriptLimits{MaxMemory: 1 << 20}
+	for name, src := range map[string]string{
+		"doubling":      `local s = ("x"):rep(16); for i = 1, 24 do s = s .. s end`,
+		"kept copies":   `local s, t = ("x"):rep(262144), {}; for i = 1, 16 do t[i] = s .. i end`,
+		"string.format": `local s = string.format("%999999999d", 1)`,
+		"table.concat":  `local t = {}; for i = 1, 5 do t[i] = ("x"):rep(262144) end; local s = table.concat(t)`,
+	} {
+		err := runLimited(t, limits, nil, map[string]string{"limited": src})
+		if err == nil || !strings.Contains(err.Error(), "memory limit") {
+			t.Errorf("%s: err = %v, want the memory limit", name, err)
+		}
+	}
+
+	err := runLimited(t, limits, nil, map[string]string{"limited": `
+local parts = {}
+for i = 1, 100 do parts[i] = string.format("%5.2f", i) .. ("y"):rep(100) end
+local s = table.concat(parts, ",")
+for i = 1, 200 do s = s:sub(1, 1000) .. i end
+mus.response(#s)
+`})
+	if err != nil {
+		t.Errorf("a script within the limit failed: %v", err)
+	}
+}
+
+func TestLimits_MessageSize(t *testing.T) {
+	limits := outbound.ScriptLimits{MaxMessageBytes: 100}
+	for fn, src := range map[string]string{
+		"mus.response":    `mus.response(string.rep("x", 200))`,
+		"mus.reply":       `mus.reply("news", string.rep("x", 200))`,
+		"mus.sendMessage": `mus.sendMessage("bob", "news", string.rep("x", 200))`,
+	} {
+		err := runLimited(t, limits, &testutil.MockMessageSender{}, map[string]string{"limited": src})
+		wantScriptError(t, err, fn+": content is")
+	}
+	if err := runLimited(t, limits, nil, map[string]string{"limited": `mus.response(string.rep("x", 50))`}); err != nil {
+		t.Errorf("a small response failed: %v", err)
+	}
+}
+
+func TestLimits_Sends(t *testing.T) {
+	sender := &testutil.MockMessageSender{}
+	err := runLimited(t, outbound.ScriptLimits{MaxSends: 3}, sender, map[string]string{"limited": `
+for i = 1, 5 do mus.sendMessage("bob", "spam", i) end
+`})
+	wantScriptError(t, err, "mus.sendMessage: more than 3 messages sent in one run")
+	if len(sender.Calls) != 3 {
+		t.Errorf("sent %d messages, want 3", len(sender.Calls))
+	}
+}
+
+func TestLimits_CallStackAndRegistry(t *testing.T) {
+	err := runLimited(t, outbound.ScriptLimits{CallStackSize: 50}, nil, map[string]string{"limited": `
+local function down(n) if n == 0 then return 0 end return 1 + down(n - 1) end
+mus.response(down(100))
+`})
+	wantScriptError(t, err, "stack overflow")
+
+	err = runLimited(t, outbound.ScriptLimits{RegistrySize: 1000}, nil, map[string]string{"limited": `
+local t = {}
+for i = 1, 5000 do t[i] = i end
+mus.response(select("#", unpack(t)))
+`})
+	wantScriptError(t, err, "registry overflow")
+}
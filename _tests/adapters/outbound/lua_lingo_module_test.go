@@ -0,0 +1,139 @@
+package outbound_test
+
+import (
+	"testing"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+)
+
+func TestLingoModule_ConstructorsKeepTheirType(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "lingo", `
+local L = mus.lingo
+mus.response({
+	state = L.symbol("ready"), at = L.point(10, 20), box = L.rect(0, 0, 640, 480),
+	tint = L.rgb(255, 128, 0), dir = L.vector(0, 1, 0), pose = L.transform(),
+	when = L.date("\7\232\10\19\0\0\0\1"), tags = {L.symbol("a"), L.symbol("b")},
+})
+`)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+
+	res, err := engine.Execute(&ports.ScriptMessage{Subject: "lingo", SenderID: "alice", Content: lingo.NewLVoid()})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	props := res.Content.(*lingo.LPropList)
+	want := map[string]string{
+		"at":   "point(10, 20)",
+		"box":  "rect(0, 0, 640, 480)",
+		"tint": lingo.NewLColor(255, 128, 0).String(),
+		"dir":  lingo.NewL3dVector(0, 1, 0).String(),
+		"when": lingo.NewLDate([8]byte{7, 232, 10, 19, 0, 0, 0, 1}).String(),
+	}
+	for i, key := range props.Properties {
+		name := lingo.StringValue(key)
+		if w, ok := want[name]; ok && props.Values[i].String() != w {
+			t.Errorf("%s = %s, want %s", name, props.Values[i], w)
+		}
+		switch v := props.Values[i]; name {
+		case "state":
+			if sym, ok := v.(*lingo.LSymbol); !ok || sym.Value != "ready" {
+				t.Errorf("state = %#v, want #ready", v)
+			}
+		case "tags":
+			list, ok := v.(*lingo.LList)
+			if !ok || len(list.Values) != 2 || list.Values[0].GetType() != lingo.VtSymbol {
+				t.Errorf("tags = %#v, want a list of symbols", v)
+			}
+		case "pose":
+			if v.GetType() != lingo.Vt3dTransform {
+				t.Errorf("pose = %s, want a transform", v)
+			}
+		}
+	}
+}
+
+func TestLingoModule_TypeOf(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "ilk", `
+local L = mus.lingo
+mus.response({
+	L.typeOf(1), L.typeOf(1.5), L.typeOf("s"), L.typeOf(L.symbol("s")), L.typeOf({1, 2}),
+	L.typeOf({a = 1}), L.typeOf(L.point(1, 2)), L.typeOf(L.rgb(0, 0, 0)), L.typeOf(nil), L.typeOf(mus.getContent()),
+})
+`)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+
+	content := lingo.NewLRect(lingo.NewLInteger(0), lingo.NewLInteger(0), lingo.NewLInteger(1), lingo.NewLInteger(1))
+	res, err := engine.Execute(&ports.ScriptMessage{Subject: "ilk", SenderID: "alice", Content: content})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	want := []string{"integer", "float", "string", "symbol", "list", "propList", "point", "color", "void", "rect"}
+	list := res.Content.(*lingo.LList)
+	for i, w := range want {
+		if got := lingo.StringValue(list.Values[i]); got != w {
+			t.Errorf("typeOf #%d = %s, want %s", i+1, got, w)
+		}
+	}
+}
+
+func TestLingoModule_ValuesSurviveSendPublishAndDB(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "spread", `
+local p = mus.lingo.point(3, 4)
+mus.sendMessage("bob", "moved", p)
+mus.publish("moves", p)
+mus.db.setUserAttribute("alice", "spawn", p)
+mus.response(mus.db.getUserAttribute("alice", "spawn"))
+`)
+	queue := testutil.NewMockMessageQueue()
+	sender := &testutil.MockMessageSender{}
+	db := newTestDB(t)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, queue, sender, db, db.QueryBuilder(), nil, nil, nil)
+
+	res, err := engine.Execute(&ports.ScriptMessage{Subject: "spread", SenderID: "alice", Content: lingo.NewLVoid()})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	const want = "point(3, 4)"
+	if len(sender.Calls) != 1 || sender.Calls[0].Content.String() != want {
+		t.Errorf("sent %+v, want %s", sender.Calls, want)
+	}
+	if len(queue.PublishCalls) != 1 || lingo.FromRawBytes(queue.PublishCalls[0].Payload, 0).GetType() != lingo.VtPoint {
+		t.Errorf("published %+v, want a point", queue.PublishCalls)
+	}
+	if res.Content.String() != want {
+		t.Errorf("stored and read back %s, want %s", res.Content, want)
+	}
+}
+
+func TestLingoModule_ReceivedSymbolsArriveAsStrings(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "relay", `
+local msg = mus.getContent()
+assert(msg.state == "ready")
+mus.response({echoed = msg.state, state = mus.lingo.symbol(msg.state), json = mus.json.encode({state = mus.lingo.symbol("ready")})})
+`)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+	content := lingo.NewLPropList()
+	content.AddElement(lingo.NewLSymbol("state"), lingo.NewLSymbol("ready"))
+
+	res, err := engine.Execute(&ports.ScriptMessage{Subject: "relay", SenderID: "alice", Content: content})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	props := res.Content.(*lingo.LPropList)
+	if echoed, _ := props.GetElement("echoed"); echoed.GetType() != lingo.VtString {
+		t.Errorf("echoed = %s, want the string a received symbol reads as", echoed)
+	}
+	if state, _ := props.GetElement("state"); state.GetType() != lingo.VtSymbol || lingo.StringValue(state) != "ready" {
+		t.Errorf("state = %s, want #ready", state)
+	}
+	if got := contextField(props, "json"); got != `{"state":"ready"}` {
+		t.Errorf("json = %s, want the symbol's name", got)
+	}
+}
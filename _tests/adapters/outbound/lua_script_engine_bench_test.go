@@ -0,0 +1,102 @@
+package outbound_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+)
+
+// Run with: go test ./_tests/adapters/outbound -run '^$' -bench LuaScriptEngine -benchmem
+//
+// Compiled-proto cache + pooled states vs. a fresh VM and DoFile per call
+// (single-core figures from one Xeon run; compare ratios, not absolutes):
+//
+//	Echo      237µs, 199KB, 666 allocs  ->  43µs, 9KB, 155 allocs
+//	Require   283µs, 256KB, 820 allocs  ->  59µs, 16KB, 188 allocs
+//
+// Loop is what the per-instruction metering costs a CPU-bound script:
+//
+//	Off 31ms  ->  Instructions 35ms  ->  Memory 52ms
+
+func benchEngine(b *testing.B, scripts map[string]string) *outbound.LuaScriptEngine {
+	b.Helper()
+	dir := b.TempDir()
+	for name, src := range scripts {
+		path := filepath.Join(dir, name+".lua")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			b.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+}
+
+func benchExecute(b *testing.B, engine *outbound.LuaScriptEngine, subject string) {
+	b.Helper()
+	msg := &ports.ScriptMessage{Subject: subject, SenderID: "bench", Content: lingo.NewLString("hello")}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := engine.Execute(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLuaScriptEngine_Echo(b *testing.B) {
+	echo, err := os.ReadFile("../../../external/scripts/echo.lua")
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchExecute(b, benchEngine(b, map[string]string{"echo": string(echo)}), "echo")
+}
+
+func BenchmarkLuaScriptEngine_EchoParallel(b *testing.B) {
+	echo, err := os.ReadFile("../../../external/scripts/echo.lua")
+	if err != nil {
+		b.Fatal(err)
+	}
+	engine := benchEngine(b, map[string]string{"echo": string(echo)})
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		msg := &ports.ScriptMessage{Subject: "echo", SenderID: "bench", Content: lingo.NewLString("hello")}
+		for pb.Next() {
+			if _, err := engine.Execute(msg); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkLuaScriptEngine_Require(b *testing.B) {
+	engine := benchEngine(b, map[string]string{
+		"lib/util": `local M = {} function M.greet(s) return "hi " .. s end return M`,
+		"greet":    `local util = require("lib/util") mus.response(util.greet(mus.getContent()))`,
+	})
+	benchExecute(b, engine, "greet")
+}
+
+func BenchmarkLuaScriptEngine_Loop(b *testing.B) {
+	loop := `local n = 0 for i = 1, 100000 do n = n + i % 7 end mus.response(n)`
+	for _, bc := range []struct {
+		name   string
+		limits outbound.ScriptLimits
+	}{
+		{"Off", outbound.ScriptLimits{}},
+		{"Instructions", outbound.ScriptLimits{MaxInstructions: 1 << 40}},
+		{"Memory", outbound.ScriptLimits{MaxMemory: 64 << 20}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			engine := benchEngine(b, map[string]string{"loop": loop})
+			engine.UseLimits(bc.limits)
+			benchExecute(b, engine, "loop")
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"fsos-server/internal/adapters/outbound"
 	"fsos-server/internal/domain/ports"
 	"fsos-server/internal/domain/types/lingo"
+	"fsos-server/internal/domain/types/smus"
 )
 
 func setupScriptsDir(t *testing.T) string {
@@ -282,6 +283,36 @@ func TestExecute_DBSetGetPlayerAttribute(t *testing.T) {
 	}
 }
 
+func TestExecute_DBUserAttributes(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "dbuser", `
+		mus.db.setUserAttribute("user1", "gems", 5)
+		mus.db.setUserAttribute("user1", "title", "Sir")
+		mus.db.deleteUserAttribute("user1", "title")
+		local names = mus.db.getUserAttributeNames("user1")
+		mus.response({mus.db.getUserAttribute("user1", "gems"), #names})
+	`)
+
+	db := newTestDB(t)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, db, nil, nil, nil, nil)
+
+	result, err := engine.Execute(&ports.ScriptMessage{Subject: "dbuser", SenderID: "user1", Content: lingo.NewLVoid()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	list, ok := result.Content.(*lingo.LList)
+	if !ok || len(list.Values) != 2 {
+		t.Fatalf("expected 2-element *LList, got %T %v", result.Content, result.Content)
+	}
+	if list.Values[0].ToInteger() != 5 {
+		t.Errorf("gems = %d, want 5", list.Values[0].ToInteger())
+	}
+	if list.Values[1].ToInteger() != 1 {
+		t.Errorf("attribute count = %d, want 1", list.Values[1].ToInteger())
+	}
+}
+
 func TestExecute_ServerGetUserCount(t *testing.T) {
 	dir := setupScriptsDir(t)
 	writeScript(t, dir, "srvtest", `
@@ -705,3 +736,110 @@ func TestExecute_LogWithoutFields(t *testing.T) {
 		t.Errorf("expected nil fields, got %v", logger.Messages[0].Fields)
 	}
 }
+
+func TestExecute_ResponseErrorCodeAndReplies(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "buy", `
+		mus.reply("goldBalance", 3)
+		mus.reply("tradeCancelled", nil, mus.errors.requestedDataNotFound, "user2")
+		mus.response("not enough gold", mus.errors.operationNotAllowed, "buyFailed")
+	`)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+
+	result, err := engine.Execute(&ports.ScriptMessage{Subject: "buy", SenderID: "user1", Content: lingo.NewLVoid()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lingo.StringValue(result.Content) != "not enough gold" || result.ErrCode != smus.ErrOperationNotAllowed || result.Subject != "buyFailed" {
+		t.Errorf("main reply = %+v, want buyFailed / ErrOperationNotAllowed", result)
+	}
+	if len(result.Replies) != 2 {
+		t.Fatalf("replies = %d, want 2", len(result.Replies))
+	}
+	if r := result.Replies[0]; r.Subject != "goldBalance" || r.Recipient != "" || r.Content.ToInteger() != 3 {
+		t.Errorf("first reply = %+v, want goldBalance=3 to the sender", r)
+	}
+	if r := result.Replies[1]; r.Recipient != "user2" || r.ErrCode != smus.ErrRequestedDataNotFound {
+		t.Errorf("second reply = %+v, want user2 with ErrRequestedDataNotFound", r)
+	}
+}
+
+func TestExecute_NoResponse(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "quiet", `mus.noResponse()`)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+
+	result, err := engine.Execute(&ports.ScriptMessage{Subject: "quiet", SenderID: "user1", Content: lingo.NewLVoid()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.NoReply {
+		t.Error("NoReply = false, want true after mus.noResponse()")
+	}
+}
+
+func TestExecute_PooledStateDoesNotLeakBetweenCalls(t *testing.T) {
+	dir := setupScriptsDir(t)
+	os.MkdirAll(filepath.Join(dir, "lib"), 0755)
+	writeScript(t, dir, "lib/counter", `local M = {n = 0} function M.bump() M.n = M.n + 1 return M.n end return M`)
+	writeScript(t, dir, "dirty", `
+		leaked = "yes"
+		mus.extra = true
+		string.upper = function() return "hijacked" end
+		setmetatable(mus, {__index = function() return "meta" end})
+		mus.response(require("lib/counter").bump())
+	`)
+	writeScript(t, dir, "probe", `
+		mus.response(tostring(leaked) .. "," .. tostring(rawget(mus, "extra")) .. "," .. string.upper("a") .. "," .. tostring(getmetatable(mus)))
+	`)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+
+	for i := 0; i < 3; i++ {
+		result, err := engine.Execute(&ports.ScriptMessage{Subject: "dirty", SenderID: "user1", Content: lingo.NewLVoid()})
+		if err != nil {
+			t.Fatalf("dirty: %v", err)
+		}
+		if result.Content.ToInteger() != 1 {
+			t.Errorf("run %d: counter = %d, want 1 (modules load fresh each execution)", i, result.Content.ToInteger())
+		}
+		result, err = engine.Execute(&ports.ScriptMessage{Subject: "probe", SenderID: "user1", Content: lingo.NewLVoid()})
+		if err != nil {
+			t.Fatalf("probe: %v", err)
+		}
+		if got := lingo.StringValue(result.Content); got != "nil,nil,A,nil" {
+			t.Errorf("run %d: probe = %q, want %q", i, got, "nil,nil,A,nil")
+		}
+	}
+}
+
+func TestExecute_RecompilesChangedScript(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "version", `mus.response(1)`)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+
+	result, err := engine.Execute(&ports.ScriptMessage{Subject: "version", Content: lingo.NewLVoid()})
+	if err != nil || result.Content.ToInteger() != 1 {
+		t.Fatalf("first run = %v, %v; want 1", result, err)
+	}
+
+	writeScript(t, dir, "version", `mus.response(22)`)
+	result, err = engine.Execute(&ports.ScriptMessage{Subject: "version", Content: lingo.NewLVoid()})
+	if err != nil || result.Content.ToInteger() != 22 {
+		t.Fatalf("after edit = %v, %v; want 22", result, err)
+	}
+}
+
+func TestExecute_FailedScriptDoesNotAffectNextCall(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "broken", `mus.response = nil error("boom")`)
+	writeScript(t, dir, "ok", `mus.response("fine")`)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+
+	if _, err := engine.Execute(&ports.ScriptMessage{Subject: "broken", Content: lingo.NewLVoid()}); err == nil {
+		t.Fatal("expected an error from the broken script")
+	}
+	result, err := engine.Execute(&ports.ScriptMessage{Subject: "ok", Content: lingo.NewLVoid()})
+	if err != nil || lingo.StringValue(result.Content) != "fine" {
+		t.Errorf("ok = %v, %v; want fine", result, err)
+	}
+}
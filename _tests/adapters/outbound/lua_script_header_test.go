@@ -0,0 +1,58 @@
+package outbound_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+)
+
+func TestScriptPolicy_ParsesHeader(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "admin", "-- Admin tools\n-- @script level=80 timeout=2 rate=5/10s\nmus.response(1)\n")
+	writeScript(t, dir, "open", "mus.response(1)\n")
+	writeScript(t, dir, "perMinute", "-- @script rate=1/m timeout=500ms\n")
+	writeScript(t, dir, "broken", "-- @script level=high\nmus.response(1)\n")
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		subject string
+		want    ports.ScriptPolicy
+	}{
+		{"admin", ports.ScriptPolicy{Level: 80, Timeout: 2 * time.Second, Rate: 5, RateWindow: 10 * time.Second}},
+		{"open", ports.ScriptPolicy{}},
+		{"perMinute", ports.ScriptPolicy{Timeout: 500 * time.Millisecond, Rate: 1, RateWindow: time.Minute}},
+	}
+	for _, tt := range tests {
+		tt.want.Script = filepath.Join(dir, tt.subject+".lua")
+		got, err := engine.ScriptPolicy(tt.subject)
+		if err != nil || got != tt.want {
+			t.Errorf("ScriptPolicy(%q) = %+v, %v; want %+v", tt.subject, got, err, tt.want)
+		}
+	}
+
+	if _, err := engine.ScriptPolicy("broken"); err == nil {
+		t.Error("an invalid header must fail like a compile error")
+	}
+	if _, err := engine.Execute(&ports.ScriptMessage{Subject: "broken", SenderID: "u", Content: lingo.NewLVoid()}); err == nil {
+		t.Error("a script with an invalid header must not run")
+	}
+}
+
+func TestScriptPolicy_TimeoutOverridesDefault(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "spin", "-- @script timeout=100ms\nwhile true do end\n")
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 30, nil, nil, nil, nil, nil, nil, nil)
+
+	start := time.Now()
+	if _, err := engine.Execute(&ports.ScriptMessage{Subject: "spin", SenderID: "u", Content: lingo.NewLVoid()}); err == nil {
+		t.Fatal("expected a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("took %v; the header timeout should stop it after 100ms", elapsed)
+	}
+}
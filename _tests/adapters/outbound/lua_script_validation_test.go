@@ -0,0 +1,59 @@
+package outbound_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/outbound"
+)
+
+func TestValidateScripts_ReportsEveryBrokenFile(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "ok", "mus.response(1)\n")
+	writeScript(t, dir, "syntax", "local x = 1\nif x then\n  mus.response(x\nend\n")
+	writeScript(t, dir, "header", "-- Admin tools\n-- @script level=high\nmus.response(1)\n")
+	writeScriptNested(t, dir, "lib", "broken", "local = 1\n")
+	writeScriptNested(t, dir, "hooks", "afterLogon", "mus.log.info('hi')\n")
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+
+	problems, err := engine.ValidateScripts()
+	if err != nil {
+		t.Fatalf("ValidateScripts: %v", err)
+	}
+	want := map[string]int{
+		filepath.Join(dir, "header.lua"):     2,
+		filepath.Join(dir, "lib/broken.lua"): 1,
+		filepath.Join(dir, "syntax.lua"):     4,
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("problems = %v, want %d", problems, len(want))
+	}
+	for _, p := range problems {
+		line, ok := want[p.Path]
+		if !ok || p.Line != line || p.Message == "" {
+			t.Errorf("unexpected problem %v", p)
+		}
+	}
+}
+
+func TestDisableBrokenScripts_HidesSubjectUntilFixed(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "shop", "mus.response(\n")
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+
+	if !engine.HasScript("shop") {
+		t.Fatal("without DisableBrokenScripts a broken script is still found")
+	}
+	engine.DisableBrokenScripts()
+	if engine.HasScript("shop") {
+		t.Fatal("a script that never compiled should be unavailable")
+	}
+
+	time.Sleep(10 * time.Millisecond) // a new mtime marks the file changed
+	writeScript(t, dir, "shop", "mus.response(1)\n")
+	if !engine.HasScript("shop") {
+		t.Error("the fixed script should be available again")
+	}
+}
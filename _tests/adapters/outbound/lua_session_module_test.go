@@ -0,0 +1,56 @@
+package outbound_test
+
+import (
+	"strings"
+	"testing"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+)
+
+func TestSession_SetGetOwnAttributes(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "sess", `
+local me = mus.getSender()
+assert(mus.session.set(me, "mood", "happy"))
+mus.response(mus.session.get(me, "mood") .. ":" .. #mus.session.getNames(me))
+`)
+	ss := testutil.NewMockSessionStore()
+	ss.RegisterConnection("alice", "10.0.0.1")
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, ss, nil, nil)
+
+	res, err := engine.Execute(&ports.ScriptMessage{Subject: "sess", SenderID: "alice", Content: lingo.NewLVoid()})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if got := lingo.StringValue(res.Content); got != "happy:1" {
+		t.Errorf("response = %q, want happy:1", got)
+	}
+}
+
+func TestSession_RefusesServerAttributesAndOtherUsers(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "promote", `mus.session.set(mus.getSender(), "#userLevel", 100)`)
+	writeScript(t, dir, "peek", `mus.response(mus.session.get("bob", "mood"))`)
+	ss := testutil.NewMockSessionStore()
+	ss.RegisterConnection("alice", "10.0.0.1")
+	ss.RegisterConnection("bob", "10.0.0.2")
+	ss.SetUserAttribute("bob", "mood", lingo.NewLString("grumpy"))
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, ss, nil, nil)
+
+	_, err := engine.Execute(&ports.ScriptMessage{Subject: "promote", SenderID: "alice", Content: lingo.NewLVoid()})
+	if err == nil || !strings.Contains(err.Error(), "server attribute") {
+		t.Errorf("setting #userLevel: err = %v, want a server attribute error", err)
+	}
+	if v, err := ss.GetUserAttribute("alice", "#userLevel"); err == nil && v.ToInteger() == 100 {
+		t.Error("#userLevel must not be written")
+	}
+
+	// Without a movie directory a script only reaches its own sender.
+	_, err = engine.Execute(&ports.ScriptMessage{Subject: "peek", SenderID: "alice", Content: lingo.NewLVoid()})
+	if err == nil || !strings.Contains(err.Error(), "may not access") {
+		t.Errorf("reading bob's session: err = %v, want a permission error", err)
+	}
+}
@@ -0,0 +1,184 @@
+package outbound_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/outbound"
+)
+
+func newScriptTestRunner(t *testing.T, dir string, timeoutSecs int) *outbound.ScriptTestRunner {
+	t.Helper()
+	return outbound.NewScriptTestRunner(dir, &testutil.MockLogger{}, timeoutSecs, func() (*outbound.ScriptTestDeps, error) {
+		cache := outbound.NewMemoryCache()
+		queue := outbound.NewMemoryQueue()
+		return &outbound.ScriptTestDeps{
+			SessionStore: outbound.NewMemorySessionStore(),
+			Cache:        cache,
+			Queue:        queue,
+			Close: func() {
+				queue.Close()
+				cache.Close()
+			},
+		}, nil
+	})
+}
+
+func runScriptTests(t *testing.T, runner *outbound.ScriptTestRunner) map[string]outbound.ScriptTestResult {
+	t.Helper()
+	files, err := runner.Discover()
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	byName := make(map[string]outbound.ScriptTestResult)
+	for _, r := range runner.Run(files) {
+		byName[r.Name] = r
+	}
+	return byName
+}
+
+func TestScriptTestRunner_DiscoversTestFiles(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "echo", "mus.response(mus.getContent())\n")
+	writeScript(t, dir, "echo_test", "")
+	writeScriptNested(t, dir, "shop", "buy_test", "")
+
+	files, err := newScriptTestRunner(t, dir, 5).Discover()
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if strings.Join(files, ",") != "echo_test.lua,shop/buy_test.lua" {
+		t.Errorf("files = %v", files)
+	}
+}
+
+func TestScriptTestRunner_RunsScriptUnderTest(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "greet", `
+mus.sendMessage("@lobby", "joined", mus.getSender())
+mus.reply("welcome", "hi " .. mus.getSender())
+mus.response(mus.getContent(), 0, "greeted")
+`)
+	writeScript(t, dir, "greet_test", `
+test.case("response and replies", function()
+  local res = test.run("greet", { sender = "alice", content = { 1, 2 } })
+  test.equal(res.content, { 1, 2 })
+  test.equal(res.subject, "greeted")
+  test.equal(res.replies[1].content, "hi alice")
+  test.ok(not res.noReply)
+end)
+
+test.case("sent messages", function()
+  test.run("greet", { sender = "bob" })
+  test.equal(test.sent(), {
+    { from = "system.script", sender = "bob", to = "@lobby", subject = "joined", content = "bob" },
+  })
+end)
+
+test.case("stubbed sender", function()
+  mus.getSender = function() return "stub" end
+  local res = test.run("greet")
+  test.equal(res.replies[1].content, "hi stub")
+end)
+
+test.case("cases start clean", function()
+  test.equal(#test.sent(), 0)
+  test.equal(mus.getSender(), "tester")
+end)
+`)
+
+	results := runScriptTests(t, newScriptTestRunner(t, dir, 5))
+	if len(results) != 4 {
+		t.Fatalf("ran %d cases, want 4", len(results))
+	}
+	for name, r := range results {
+		if !r.Passed() {
+			t.Errorf("%s: %v", name, r.Err)
+		}
+	}
+}
+
+func TestScriptTestRunner_ReportsFailures(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "cases_test", `
+test.case("unequal", function() test.equal({ a = 1 }, { a = 2 }, "table") end)
+test.case("script error", function()
+  local res, err = test.run("boom")
+  test.equal(res, nil)
+  error(err)
+end)
+test.case("loops", function() while true do end end)
+`)
+	writeScript(t, dir, "boom", `error("kaboom")`)
+	writeScript(t, dir, "broken_test", `test.case(`)
+
+	results := runScriptTests(t, newScriptTestRunner(t, dir, 1))
+	if r := results["unequal"]; r.Passed() || !strings.Contains(r.Err.Error(), "table: expected {a=2}, got {a=1}") {
+		t.Errorf("unequal: %v", r.Err)
+	}
+	if r := results["script error"]; r.Passed() || !strings.Contains(r.Err.Error(), "kaboom") {
+		t.Errorf("script error: %v", r.Err)
+	}
+	if r := results["loops"]; r.Passed() {
+		t.Error("loops: expected the timeout to fail it")
+	}
+	if r := results["broken_test.lua"]; r.Passed() {
+		t.Error("a file that does not compile should be reported as a failure")
+	}
+}
+
+func TestScriptTestRunner_Reports(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "a_test", `
+test.case("passes", function() test.ok(true) end)
+test.case("fails", function() test.fail("nope") end)
+`)
+	results := newScriptTestRunner(t, dir, 5).Run([]string{"a_test.lua"})
+
+	var tap bytes.Buffer
+	if err := outbound.WriteScriptTestTAP(&tap, results); err != nil {
+		t.Fatalf("TAP: %v", err)
+	}
+	for _, want := range []string{"TAP version 13\n1..2\n", "ok 1 - a_test.lua: passes\n", "not ok 2 - a_test.lua: fails\n", "nope"} {
+		if !strings.Contains(tap.String(), want) {
+			t.Errorf("TAP output missing %q:\n%s", want, tap.String())
+		}
+	}
+
+	var junit bytes.Buffer
+	if err := outbound.WriteScriptTestJUnit(&junit, results); err != nil {
+		t.Fatalf("JUnit: %v", err)
+	}
+	var doc struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Suites   []struct {
+			Name  string `xml:"name,attr"`
+			Cases []struct {
+				Name    string    `xml:"name,attr"`
+				Failure *struct{} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(junit.Bytes(), &doc); err != nil {
+		t.Fatalf("JUnit output is not XML: %v", err)
+	}
+	if doc.Tests != 2 || doc.Failures != 1 || len(doc.Suites) != 1 || doc.Suites[0].Name != "a_test.lua" {
+		t.Errorf("JUnit summary = %+v", doc)
+	}
+}
+
+func TestHasScript_RefusesTestFiles(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "echo_test", "mus.response(1)\n")
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+
+	for _, subject := range []string{"echo_test", "echo_test-1"} {
+		if engine.HasScript(subject) {
+			t.Errorf("HasScript(%q) = true, test files must not be invocable", subject)
+		}
+	}
+}
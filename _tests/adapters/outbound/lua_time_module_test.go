@@ -0,0 +1,95 @@
+package outbound_test
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+)
+
+// runTimeScript runs src and returns its response list as strings.
+func runTimeScript(t *testing.T, src string, content lingo.LValue) []string {
+	t.Helper()
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "clock", src)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+	res, err := engine.Execute(&ports.ScriptMessage{Subject: "clock", SenderID: "alice", Content: content})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	list, ok := res.Content.(*lingo.LList)
+	if !ok {
+		t.Fatalf("content = %v, want a list", res.Content)
+	}
+	out := make([]string, len(list.Values))
+	for i, v := range list.Values {
+		out[i] = lingo.StringValue(v)
+	}
+	return out
+}
+
+func TestTimeModule_Clocks(t *testing.T) {
+	got := runTimeScript(t, `
+local a = mus.time.monotonic()
+local ms = mus.time.nowMs()
+mus.response({ms >= mus.time.now() * 1000, mus.time.monotonic() >= a})
+`, lingo.NewLVoid())
+	if got[0] != "1" || got[1] != "1" {
+		t.Errorf("clocks = %v", got)
+	}
+}
+
+func TestTimeModule_FormatAndParse(t *testing.T) {
+	ts := time.Date(2024, time.March, 9, 14, 5, 7, 0, time.UTC).Unix()
+	got := runTimeScript(t, `
+local ts = mus.getContent()
+local parsed = mus.time.parse("2024-03-09 15:05:07", "%Y-%m-%d %H:%M:%S", "Europe/Berlin")
+local bad, err = mus.time.parse("yesterday", "%Y-%m-%d")
+mus.response({
+	mus.time.format(ts, "%Y-%m-%d %H:%M:%S %%", "UTC"),
+	mus.time.format(ts, "%a %e %b, %I:%M %p", "America/New_York"),
+	mus.time.format(ts, nil, "UTC"),
+	parsed == ts, bad == nil, err ~= nil,
+	mus.time.parse(mus.time.format(ts, nil, "Asia/Tokyo")) == ts,
+})
+`, lingo.NewLInteger(int32(ts)))
+	want := []string{"2024-03-09 14:05:07 %", "Sat  9 Mar, 09:05 AM", "2024-03-09T14:05:07Z", "1", "1", "1", "1"}
+	for i, w := range want {
+		if got[i] != w {
+			t.Errorf("result %d = %q, want %q", i+1, got[i], w)
+		}
+	}
+}
+
+func TestTimeModule_CalendarHelpers(t *testing.T) {
+	// Sunday 2024-03-31 01:30 UTC is 03:30 in Berlin, hours after the switch
+	// to summer time.
+	ts := time.Date(2024, time.March, 31, 1, 30, 0, 0, time.UTC).Unix()
+	got := runTimeScript(t, `
+local ts, tz = mus.getContent(), "Europe/Berlin"
+local f = function(t) return mus.time.format(t, "%Y-%m-%d %H:%M", tz) end
+mus.response({
+	f(mus.time.startOfDay(ts, tz)),
+	f(mus.time.startOfWeek(ts, tz)),
+	f(mus.time.startOfWeek(ts, tz, "sunday")),
+	f(mus.time.addDays(ts, -1, tz)),
+	mus.time.daysBetween(mus.time.addDays(ts, -1, tz), ts, tz),
+	mus.time.daysBetween(ts, ts + 3600, tz),
+	mus.time.parseDuration("1h30m"),
+	mus.time.formatDuration(90),
+})
+`, lingo.NewLInteger(int32(ts)))
+	want := []string{
+		"2024-03-31 00:00", "2024-03-25 00:00", "2024-03-31 00:00", "2024-03-30 03:30",
+		"1", "0", "5400", "1m30s",
+	}
+	for i, w := range want {
+		if got[i] != w {
+			t.Errorf("result %d = %q, want %q", i+1, got[i], w)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package outbound_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+)
+
+type fakeScriptTimers struct {
+	delay             time.Duration
+	subject, senderID string
+	content           lingo.LValue
+	cancelled         string
+	cancelledBy       string
+}
+
+func (f *fakeScriptTimers) After(delay time.Duration, subject, senderID string, content lingo.LValue) (string, error) {
+	if subject == "missing" {
+		return "", errors.New("no script")
+	}
+	f.delay, f.subject, f.senderID, f.content = delay, subject, senderID, content
+	return "t-1", nil
+}
+
+func (f *fakeScriptTimers) Cancel(id, senderID string) error {
+	if id != "t-1" {
+		return ports.ErrTimerNotFound
+	}
+	f.cancelled, f.cancelledBy = id, senderID
+	return nil
+}
+
+func TestTimerModule_AfterAndCancel(t *testing.T) {
+	dir := setupScriptsDir(t)
+	writeScript(t, dir, "auction", `
+local id = mus.timer.after(1.5, "auction/close", {lot = 3})
+local bad, err = mus.timer.after(1, "missing")
+local ok = mus.timer.cancel(id)
+local again, cerr = mus.timer.cancel("nope")
+mus.response({id = id, bad = bad == nil, err = err, ok = ok, again = again, cerr = cerr})
+`)
+	engine := outbound.NewLuaScriptEngine(dir, &testutil.MockLogger{}, 5, nil, nil, nil, nil, nil, nil, nil)
+	timers := &fakeScriptTimers{}
+	engine.UseScriptTimers(timers)
+
+	res, err := engine.Execute(&ports.ScriptMessage{Subject: "auction", SenderID: "alice", Content: lingo.NewLVoid()})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if timers.delay != 1500*time.Millisecond || timers.subject != "auction/close" || timers.senderID != "alice" {
+		t.Errorf("set %+v, want auction/close in 1.5s for alice", timers)
+	}
+	if _, ok := timers.content.(*lingo.LPropList); !ok {
+		t.Errorf("content = %v, want the prop list", timers.content)
+	}
+	props := res.Content.(*lingo.LPropList)
+	for field, want := range map[string]string{
+		"id": "t-1", "bad": "1", "err": "no script", "ok": "1", "again": "0", "cerr": "timer not found",
+	} {
+		if got := contextField(props, field); got != want {
+			t.Errorf("%s = %s, want %s", field, got, want)
+		}
+	}
+	if timers.cancelled != "t-1" || timers.cancelledBy != "alice" {
+		t.Errorf("cancelled %q as %q, want t-1 as alice", timers.cancelled, timers.cancelledBy)
+	}
+
+	// A server run may cancel anyone's timer
+	writeScript(t, dir, "cleanup", `mus.timer.cancel("t-1")`)
+	if _, err := engine.Execute(&ports.ScriptMessage{Subject: "cleanup", SenderID: "system.jobs/cleanup", System: true, Content: lingo.NewLVoid()}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if timers.cancelledBy != "" {
+		t.Errorf("server run cancelled as %q, want any sender", timers.cancelledBy)
+	}
+}
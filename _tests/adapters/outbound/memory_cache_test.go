@@ -91,6 +91,26 @@ func TestMemoryCache_TTLExpiry(t *testing.T) {
 	}
 }
 
+func TestMemoryCache_SetNX(t *testing.T) {
+	c := outbound.NewMemoryCache()
+	defer c.Close()
+
+	if ok, _ := c.SetNX("lock", []byte("a"), 50*time.Millisecond); !ok {
+		t.Fatal("first SetNX should store")
+	}
+	if ok, _ := c.SetNX("lock", []byte("b"), 50*time.Millisecond); ok {
+		t.Error("SetNX over a live key should not store")
+	}
+	if got, _ := c.Get("lock"); string(got) != "a" {
+		t.Errorf("Get = %q, want the first value", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if ok, _ := c.SetNX("lock", []byte("b"), 0); !ok {
+		t.Error("SetNX after expiry should store")
+	}
+}
+
 func TestMemoryCache_Overwrite(t *testing.T) {
 	c := outbound.NewMemoryCache()
 	defer c.Close()
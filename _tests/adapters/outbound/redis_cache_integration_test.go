@@ -100,3 +100,25 @@ func TestIntegrationRedisCache_SetOps(t *testing.T) {
 		t.Error("SetIsMember(a) should be false after SetRemove")
 	}
 }
+
+func TestIntegrationRedisCache_SetNX(t *testing.T) {
+	c := newRedisCache(t)
+
+	ok, err := c.SetNX("lock", []byte("a"), 100*time.Millisecond)
+	mustNoErr(t, err)
+	if !ok {
+		t.Fatal("first SetNX should store")
+	}
+	ok, err = c.SetNX("lock", []byte("b"), 100*time.Millisecond)
+	mustNoErr(t, err)
+	if ok {
+		t.Error("SetNX over a live key should not store")
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	ok, err = c.SetNX("lock", []byte("b"), 0)
+	mustNoErr(t, err)
+	if !ok {
+		t.Error("SetNX after expiry should store")
+	}
+}
@@ -313,6 +313,69 @@ func TestPlayerAttribute_SetGetDelete(t *testing.T) {
 	}
 }
 
+// --- DBUser attributes ---
+
+func TestUserAttribute_SetGetDelete(t *testing.T) {
+	db := newTestDB(t)
+
+	// No application needed — user attributes span every application.
+	mustNoErr(t, db.SetUserAttribute("user1", "gems", lingo.NewLInteger(10)))
+	mustNoErr(t, db.SetUserAttribute("user1", "gems", lingo.NewLInteger(12)))
+
+	got, err := db.GetUserAttribute("user1", "gems")
+	mustNoErr(t, err)
+	if got.ToInteger() != 12 {
+		t.Errorf("expected 12, got %d", got.ToInteger())
+	}
+
+	other, err := db.GetUserAttribute("user2", "gems")
+	mustNoErr(t, err)
+	if other.GetType() != lingo.VtVoid {
+		t.Error("different user should not have the attribute")
+	}
+
+	mustNoErr(t, db.SetUserAttribute("user1", "title", lingo.NewLString("Sir")))
+	names, err := db.GetUserAttributeNames("user1")
+	mustNoErr(t, err)
+	if len(names) != 2 {
+		t.Errorf("expected 2 names, got %d", len(names))
+	}
+
+	mustNoErr(t, db.DeleteUserAttribute("user1", "gems"))
+	gone, err := db.GetUserAttribute("user1", "gems")
+	mustNoErr(t, err)
+	if gone.GetType() != lingo.VtVoid {
+		t.Error("expected void after delete")
+	}
+}
+
+func TestScriptTimer_CreateListDelete(t *testing.T) {
+	db := newTestDB(t)
+
+	later := time.Now().Add(time.Hour).Truncate(time.Second)
+	sooner := time.Now().Add(time.Minute).Truncate(time.Second)
+	mustNoErr(t, db.CreateScriptTimer(ports.ScriptTimer{ID: "a", Subject: "auction/close", SenderID: "alice", Content: lingo.NewLInteger(7), FireAt: later}))
+	mustNoErr(t, db.CreateScriptTimer(ports.ScriptTimer{ID: "b", Subject: "respawn", SenderID: "bob", Content: lingo.NewLString("x"), FireAt: sooner}))
+
+	timers, err := db.ListScriptTimers()
+	mustNoErr(t, err)
+	if len(timers) != 2 || timers[0].ID != "b" || timers[1].ID != "a" {
+		t.Fatalf("timers = %+v, want b then a by fire time", timers)
+	}
+	a := timers[1]
+	if a.Subject != "auction/close" || a.SenderID != "alice" || a.Content.ToInteger() != 7 || !a.FireAt.Equal(later) {
+		t.Errorf("timer a = %+v", a)
+	}
+
+	if err := db.DeleteScriptTimer("a", "bob"); !errors.Is(err, ports.ErrTimerNotFound) {
+		t.Errorf("delete by another sender err = %v, want ErrTimerNotFound", err)
+	}
+	mustNoErr(t, db.DeleteScriptTimer("a", "alice"))
+	if err := db.DeleteScriptTimer("a", ""); !errors.Is(err, ports.ErrTimerNotFound) {
+		t.Errorf("second delete err = %v, want ErrTimerNotFound", err)
+	}
+}
+
 // --- DBUser ---
 
 func TestCreateUser(t *testing.T) {
@@ -419,6 +482,43 @@ func TestUpdateUserPassword(t *testing.T) {
 	}
 }
 
+func TestListUsersAndCount(t *testing.T) {
+	db := newTestDB(t)
+	for _, name := range []string{"carol", "alice", "bob"} {
+		mustNoErr(t, db.CreateUser(name, "hash", ports.DefaultUserLevel))
+	}
+
+	n, err := db.CountUsers()
+	mustNoErr(t, err)
+	if n != 3 {
+		t.Errorf("CountUsers = %d, want 3", n)
+	}
+
+	page, err := db.ListUsers(1, 1)
+	mustNoErr(t, err)
+	if len(page) != 1 || page[0].Username != "bob" {
+		t.Errorf("ListUsers(1, 1) = %v, want [bob]", page)
+	}
+
+	all, err := db.ListUsers(0, 0)
+	mustNoErr(t, err)
+	if len(all) != 3 || all[0].Username != "alice" {
+		t.Errorf("ListUsers(0, 0) = %v, want all three ordered by name", all)
+	}
+}
+
+func TestListApplications(t *testing.T) {
+	db := newTestDB(t)
+	mustNoErr(t, db.CreateApplication("poker"))
+	mustNoErr(t, db.CreateApplication("chess"))
+
+	names, err := db.ListApplications(0, 10)
+	mustNoErr(t, err)
+	if len(names) != 2 || names[0] != "chess" || names[1] != "poker" {
+		t.Errorf("ListApplications = %v, want [chess poker]", names)
+	}
+}
+
 // --- DBBan ---
 
 func TestCreateBan_ByUserID(t *testing.T) {
@@ -526,6 +626,45 @@ func TestRevokeBan(t *testing.T) {
 
 // --- MigrationTracker ---
 
+func TestListBans(t *testing.T) {
+	db := newTestDB(t)
+	mustNoErr(t, db.CreateUser("alice", "hash123", ports.DefaultUserLevel))
+	u, err := db.GetUser("alice")
+	mustNoErr(t, err)
+
+	ip := "10.0.0.9"
+	past := time.Now().Add(-time.Hour)
+	mustNoErr(t, db.CreateBan(&u.ID, nil, "cheating", nil))
+	mustNoErr(t, db.CreateBan(nil, &ip, "flooding", nil))
+	mustNoErr(t, db.CreateBan(&u.ID, nil, "old", &past))
+
+	active, err := db.ListBans(0, 0, true)
+	mustNoErr(t, err)
+	if len(active) != 2 {
+		t.Fatalf("active bans = %d, want 2", len(active))
+	}
+	for _, b := range active {
+		switch b.Reason {
+		case "cheating":
+			if b.Username == nil || *b.Username != "alice" {
+				t.Errorf("user ban Username = %v, want alice", b.Username)
+			}
+		case "flooding":
+			if b.Username != nil || b.IPAddress == nil || *b.IPAddress != ip {
+				t.Errorf("IP ban = %+v, want IP-only", b)
+			}
+		default:
+			t.Errorf("unexpected active ban %q", b.Reason)
+		}
+	}
+
+	all, err := db.ListBans(0, 0, false)
+	mustNoErr(t, err)
+	if len(all) != 3 {
+		t.Errorf("all bans = %d, want 3", len(all))
+	}
+}
+
 func TestMigrationTracker_EmptyInitially(t *testing.T) {
 	dir := t.TempDir()
 	db, err := outbound.NewSQLiteDB(filepath.Join(dir, "test.db"))
@@ -169,6 +169,21 @@ func TestLoadServerConfig_CommandLevels_EnvOverride(t *testing.T) {
 	}
 }
 
+func TestCommandLevelFromEnv_ExtensionSubjects(t *testing.T) {
+	t.Setenv("USERLEVEL_GAME_SHOP_ALL", "40")
+	t.Setenv("USERLEVEL_GAME_SCORES_SUBMIT", "60")
+
+	if level, ok := config.CommandLevelFromEnv("game.shop.*"); !ok || level != 40 {
+		t.Errorf("game.shop.* = %d, %v, want 40 from USERLEVEL_GAME_SHOP_ALL", level, ok)
+	}
+	if level, ok := config.CommandLevelFromEnv("game.scores.submit"); !ok || level != 60 {
+		t.Errorf("game.scores.submit = %d, %v, want 60", level, ok)
+	}
+	if _, ok := config.CommandLevelFromEnv("game.unset"); ok {
+		t.Error("a subject without a variable should report no override")
+	}
+}
+
 func TestLoadServerConfig_RateLimitDefaults(t *testing.T) {
 	cfg := config.LoadServerConfig()
 
@@ -225,3 +240,44 @@ func TestLoadServerConfig_PartialOverride(t *testing.T) {
 		t.Error("Protocol should not be empty")
 	}
 }
+
+func TestLoadServerConfig_ScriptHTTP(t *testing.T) {
+	cfg := config.LoadServerConfig()
+	if len(cfg.ScriptHTTP.AllowedHosts) != 0 {
+		t.Errorf("AllowedHosts = %v, want none (mus.http disabled)", cfg.ScriptHTTP.AllowedHosts)
+	}
+	if cfg.ScriptHTTP.MaxResponseBytes != 1<<20 || cfg.ScriptHTTP.MaxConcurrent != 4 {
+		t.Errorf("ScriptHTTP = %+v, want 1 MiB responses and 4 requests in flight", cfg.ScriptHTTP)
+	}
+
+	t.Setenv("SCRIPT_HTTP_ALLOWED_HOSTS", "api.example.com, .forum.example.com")
+	t.Setenv("SCRIPT_HTTP_MAX_RESPONSE_BYTES", "4096")
+	t.Setenv("SCRIPT_HTTP_MAX_CONCURRENT", "2")
+	cfg = config.LoadServerConfig()
+	hosts := cfg.ScriptHTTP.AllowedHosts
+	if len(hosts) != 2 || hosts[0] != "api.example.com" || hosts[1] != ".forum.example.com" {
+		t.Errorf("AllowedHosts = %v", hosts)
+	}
+	if cfg.ScriptHTTP.MaxResponseBytes != 4096 || cfg.ScriptHTTP.MaxConcurrent != 2 {
+		t.Errorf("ScriptHTTP = %+v, want 4096 bytes and 2 in flight", cfg.ScriptHTTP)
+	}
+}
+
+func TestLoadServerConfig_ScriptLimits(t *testing.T) {
+	t.Setenv("MAX_MESSAGE_SIZE", "4096")
+	cfg := config.LoadServerConfig()
+	limits := cfg.ScriptLimits
+	if limits.CallStackSize != 256 || limits.RegistrySize != 5120 || limits.MaxMemory != 0 || limits.MaxInstructions != 0 || limits.MaxSends != 1000 {
+		t.Errorf("ScriptLimits = %+v, want the defaults", limits)
+	}
+	if limits.MaxMessageBytes != 4096 {
+		t.Errorf("MaxMessageBytes = %d, want MAX_MESSAGE_SIZE", limits.MaxMessageBytes)
+	}
+
+	t.Setenv("SCRIPT_MAX_INSTRUCTIONS", "5000000")
+	t.Setenv("SCRIPT_MAX_MESSAGE_BYTES", "1024")
+	cfg = config.LoadServerConfig()
+	if cfg.ScriptLimits.MaxInstructions != 5000000 || cfg.ScriptLimits.MaxMessageBytes != 1024 {
+		t.Errorf("ScriptLimits = %+v, want the overrides", cfg.ScriptLimits)
+	}
+}
@@ -70,3 +70,29 @@ func TestAuthorizer_AdminLevel_TracksConfiguredDBAdminLevel(t *testing.T) {
 		t.Errorf("AdminLevel() = %d, want the default 80", got)
 	}
 }
+
+func TestAuthorizer_DeclareCommandLevel_ConfigWins(t *testing.T) {
+	authz := services.NewAuthorizer(sessionWithLevel(t, "user", 40), map[string]int{"game.configured": 80})
+	authz.DeclareCommandLevel("game.configured", 20)
+	authz.DeclareCommandLevel("game.declared", 40)
+
+	if authz.CanRun("user", "game.configured") {
+		t.Error("a declared level must not override the configured one")
+	}
+	if !authz.CanRun("user", "game.declared") {
+		t.Error("a declared level must make an unlisted command runnable")
+	}
+}
+
+func TestAuthorizer_DeclareCommandLevel_LeavesConfigMapAlone(t *testing.T) {
+	levels := map[string]int{"game.configured": 80}
+	authz := services.NewAuthorizer(sessionWithLevel(t, "user", 40), levels)
+	authz.DeclareCommandLevel("game.declared", 40)
+
+	if _, ok := levels["game.declared"]; ok || len(levels) != 1 {
+		t.Errorf("configured map = %v, want it unchanged", levels)
+	}
+	if !authz.CanRun("user", "game.declared") || authz.CanRun("user", "game.configured") {
+		t.Error("the authorizer should see both the configured and the declared level")
+	}
+}
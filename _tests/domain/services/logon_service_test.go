@@ -125,6 +125,36 @@ func TestLogonService_RefusesWhenRemapLosesRace(t *testing.T) {
 	}
 }
 
+func TestLogonService_GuardVetoesLogon(t *testing.T) {
+	svc, sessions := logonSvc(t, &testutil.MockDBAdapter{}, "none", 20)
+
+	var seen services.LogonAttempt
+	veto := errors.New("banned")
+	svc.SetGuard(func(a services.LogonAttempt) error {
+		seen = a
+		return veto
+	})
+
+	res := svc.Logon(services.LogonRequest{
+		ConnectionID: "client-1",
+		SenderID:     "client-1",
+		Credentials:  creds("arcade", "mallory", ""),
+	})
+
+	if res.Code != services.LogonVetoed {
+		t.Fatalf("Code = %v, want LogonVetoed", res.Code)
+	}
+	if !errors.Is(res.Err, veto) {
+		t.Errorf("Err = %v, want the guard's error", res.Err)
+	}
+	if seen.UserID != "mallory" || seen.MovieID != "arcade" || seen.IP != "192.168.1.1" || seen.UserLevel != 20 {
+		t.Errorf("attempt = %+v", seen)
+	}
+	if ok, _ := sessions.IsConnected("mallory"); ok {
+		t.Error("vetoed logon must not remap the connection")
+	}
+}
+
 func TestLogonService_StrictMode_UnknownUser(t *testing.T) {
 	svc, _ := logonSvc(t, &testutil.MockDBAdapter{}, "strict", 20)
 
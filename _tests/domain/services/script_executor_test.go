@@ -0,0 +1,293 @@
+package services_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fsos-server/_tests/testutil"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/services"
+	"fsos-server/internal/domain/types/lingo"
+)
+
+func TestScriptExecutor_RunsEachSendersScriptsInOrder(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string][]int{}
+	engine := &testutil.MockScriptEngine{
+		ExecuteFunc: func(msg *ports.ScriptMessage) (*ports.ScriptResult, error) {
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			seen[msg.SenderID] = append(seen[msg.SenderID], int(msg.Content.(*lingo.LInteger).Value))
+			mu.Unlock()
+			return &ports.ScriptResult{Content: lingo.NewLVoid()}, nil
+		},
+	}
+	x := services.NewScriptExecutor(engine, 4, 100, nil)
+	defer x.Stop()
+
+	// Each sender submits from one goroutine, one message after another, the
+	// way a connection's read loop does.
+	var wg sync.WaitGroup
+	for _, sender := range []string{"alice", "bob", "carol"} {
+		wg.Add(1)
+		go func(sender string) {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				if _, err := x.Execute(&ports.ScriptMessage{Subject: "s", SenderID: sender, Content: lingo.NewLInteger(int32(i))}); err != nil {
+					t.Errorf("Execute: %v", err)
+				}
+			}
+		}(sender)
+	}
+	wg.Wait()
+
+	for sender, order := range seen {
+		for i, v := range order {
+			if v != i {
+				t.Fatalf("%s ran %v, want 0..9 in order", sender, order)
+			}
+		}
+	}
+}
+
+func TestScriptExecutor_SameSenderNeverRunsConcurrently(t *testing.T) {
+	var running, overlap int32
+	var mu sync.Mutex
+	engine := &testutil.MockScriptEngine{
+		ExecuteFunc: func(msg *ports.ScriptMessage) (*ports.ScriptResult, error) {
+			mu.Lock()
+			running++
+			if running > 1 {
+				overlap++
+			}
+			mu.Unlock()
+			time.Sleep(2 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return &ports.ScriptResult{}, nil
+		},
+	}
+	x := services.NewScriptExecutor(engine, 8, 100, nil)
+	defer x.Stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			x.Execute(&ports.ScriptMessage{Subject: "s", SenderID: "alice"})
+		}()
+	}
+	wg.Wait()
+
+	if overlap != 0 {
+		t.Errorf("scripts of one sender overlapped %d times", overlap)
+	}
+}
+
+func TestScriptExecutor_RejectsWhenQueueIsFull(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	engine := &testutil.MockScriptEngine{
+		ExecuteFunc: func(msg *ports.ScriptMessage) (*ports.ScriptResult, error) {
+			started <- struct{}{}
+			<-release
+			return &ports.ScriptResult{}, nil
+		},
+	}
+	metrics := &testutil.MockMetrics{}
+	x := services.NewScriptExecutor(engine, 1, 1, metrics)
+	defer x.Stop()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); x.Execute(&ports.ScriptMessage{Subject: "s", SenderID: "a"}) }()
+	<-started // the only worker is busy
+	go func() { defer wg.Done(); x.Execute(&ports.ScriptMessage{Subject: "s", SenderID: "b"}) }()
+	waitFor(t, func() bool { return metrics.ScriptQueueDepth.Load() == 1 })
+
+	if _, err := x.Execute(&ports.ScriptMessage{Subject: "s", SenderID: "c"}); !errors.Is(err, ports.ErrScriptQueueFull) {
+		t.Errorf("err = %v, want ErrScriptQueueFull", err)
+	}
+	if metrics.ScriptsRejected.Load() != 1 {
+		t.Errorf("rejected = %d, want 1", metrics.ScriptsRejected.Load())
+	}
+
+	close(release)
+	<-started
+	wg.Wait()
+	if metrics.ScriptExecutions.Load() != 2 || metrics.ScriptWaits.Load() != 2 {
+		t.Errorf("executions=%d waits=%d, want 2 each", metrics.ScriptExecutions.Load(), metrics.ScriptWaits.Load())
+	}
+	if metrics.ScriptQueueDepth.Load() != 0 {
+		t.Errorf("queue depth = %d, want 0", metrics.ScriptQueueDepth.Load())
+	}
+}
+
+func TestScriptExecutor_SubmitRunsInOrderWithoutWaiting(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var order []string
+	engine := &testutil.MockScriptEngine{
+		ExecuteFunc: func(msg *ports.ScriptMessage) (*ports.ScriptResult, error) {
+			if msg.Subject == "slow" {
+				<-release
+			}
+			mu.Lock()
+			order = append(order, msg.Subject)
+			mu.Unlock()
+			return &ports.ScriptResult{}, nil
+		},
+	}
+	x := services.NewScriptExecutor(engine, 4, 10, nil)
+	defer x.Stop()
+
+	results := make(chan string, 2)
+	for _, subject := range []string{"slow", "fast"} {
+		err := x.Submit(&ports.ScriptMessage{Subject: subject, SenderID: "alice"}, func(_ *ports.ScriptResult, err error) {
+			if err != nil {
+				t.Errorf("%s: %v", subject, err)
+			}
+			results <- subject
+		})
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	close(release) // Submit returned while slow still held alice's turn
+	for _, want := range []string{"slow", "fast"} {
+		if got := <-results; got != want {
+			t.Errorf("done got %s, want %s", got, want)
+		}
+	}
+	if strings.Join(order, ",") != "slow,fast" {
+		t.Errorf("ran %v, want slow then fast", order)
+	}
+}
+
+func TestScriptExecutor_RecoversEnginePanic(t *testing.T) {
+	engine := &testutil.MockScriptEngine{
+		ExecuteFunc: func(msg *ports.ScriptMessage) (*ports.ScriptResult, error) {
+			panic("boom")
+		},
+	}
+	x := services.NewScriptExecutor(engine, 1, 10, nil)
+	defer x.Stop()
+
+	if _, err := x.Execute(&ports.ScriptMessage{Subject: "s", SenderID: "a"}); err == nil {
+		t.Error("expected an error from a panicking script")
+	}
+	if _, err := x.Execute(&ports.ScriptMessage{Subject: "s", SenderID: "a"}); err == nil {
+		t.Error("worker should survive the panic and run the next script")
+	}
+}
+
+func TestScriptExecutor_ExecuteAfterStopFails(t *testing.T) {
+	x := services.NewScriptExecutor(&testutil.MockScriptEngine{}, 1, 10, nil)
+	x.Stop()
+
+	if _, err := x.Execute(&ports.ScriptMessage{Subject: "s", SenderID: "a"}); err == nil {
+		t.Error("expected an error after Stop")
+	}
+}
+
+func TestScriptExecutor_InlineScriptDoesNotWaitBehindCaller(t *testing.T) {
+	var x *services.ScriptExecutor
+	engine := &testutil.MockScriptEngine{
+		ExecuteFunc: func(msg *ports.ScriptMessage) (*ports.ScriptResult, error) {
+			if msg.Subject == "outer" {
+				// A hook for the same sender, fired from inside its script
+				var err error
+				x.Inline(msg.SenderID, func() {
+					_, err = x.Execute(&ports.ScriptMessage{Subject: "hook", SenderID: msg.SenderID, Nested: true})
+				})
+				return &ports.ScriptResult{Content: lingo.NewLVoid()}, err
+			}
+			return &ports.ScriptResult{Content: lingo.NewLVoid()}, nil
+		},
+	}
+	x = services.NewScriptExecutor(engine, 1, 10, nil)
+	defer x.Stop()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := x.Execute(&ports.ScriptMessage{Subject: "outer", SenderID: "alice"})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the inlined script waited behind its caller")
+	}
+}
+
+func TestScriptExecutor_InlineKeepsOtherWorkForTheSenderInOrder(t *testing.T) {
+	var x *services.ScriptExecutor
+	inside := make(chan struct{})
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var order []string
+	engine := &testutil.MockScriptEngine{
+		ExecuteFunc: func(msg *ports.ScriptMessage) (*ports.ScriptResult, error) {
+			if msg.Subject == "outer" {
+				x.Inline(msg.SenderID, func() {
+					close(inside)
+					<-release
+					x.Execute(&ports.ScriptMessage{Subject: "hook", SenderID: msg.SenderID, Nested: true})
+				})
+			}
+			mu.Lock()
+			order = append(order, msg.Subject)
+			mu.Unlock()
+			return &ports.ScriptResult{Content: lingo.NewLVoid()}, nil
+		},
+	}
+	x = services.NewScriptExecutor(engine, 2, 10, nil)
+	defer x.Stop()
+
+	outerDone := make(chan struct{})
+	go func() {
+		x.Execute(&ports.ScriptMessage{Subject: "outer", SenderID: "alice"})
+		close(outerDone)
+	}()
+	<-inside
+
+	// A new message for alice while her script is inside Inline
+	nextDone := make(chan struct{})
+	go func() {
+		x.Execute(&ports.ScriptMessage{Subject: "next", SenderID: "alice"})
+		close(nextDone)
+	}()
+	select {
+	case <-nextDone:
+		t.Error("a new message for the sender skipped the queue during Inline")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-outerDone
+	<-nextDone
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(order, ","); got != "hook,outer,next" {
+		t.Errorf("order = %s, want hook,outer,next", got)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
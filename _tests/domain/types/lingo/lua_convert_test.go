@@ -191,3 +191,84 @@ func TestLuaToLValue_LNil(t *testing.T) {
 		t.Errorf("expected *LVoid, got %T", result)
 	}
 }
+
+func TestRoundTrip_TaggedTypes(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	var matrix [16]float32
+	for i := range matrix {
+		matrix[i] = float32(i)
+	}
+	for _, original := range []lingo.LValue{
+		lingo.NewLSymbol("ready"),
+		lingo.NewLPoint(lingo.NewLInteger(10), lingo.NewLFloat(2.5)),
+		lingo.NewLRect(lingo.NewLInteger(0), lingo.NewLInteger(0), lingo.NewLInteger(640), lingo.NewLInteger(480)),
+		lingo.NewLColor(255, 128, 0),
+		lingo.NewLDate([8]byte{7, 232, 10, 19, 0, 0, 0, 1}),
+		lingo.NewL3dVector(1, 2, 3),
+		lingo.NewL3dTransform(matrix),
+		lingo.NewLMedia([]byte{0, 1, 2}),
+	} {
+		var lv lua.LValue
+		if sym, ok := original.(*lingo.LSymbol); ok {
+			lv = lingo.SymbolToLua(L, sym.Value)
+		} else {
+			lv = lingo.LValueToLua(L, original)
+		}
+		result := lingo.LuaToLValue(lv)
+		if result.GetType() != original.GetType() || result.String() != original.String() {
+			t.Errorf("%s came back as %s", original, result)
+		}
+	}
+}
+
+func TestLValueToLua_PointKeepsFields(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	tbl := lingo.LValueToLua(L, lingo.NewLPoint(lingo.NewLInteger(3), lingo.NewLInteger(4))).(*lua.LTable)
+	if tbl.RawGetString("locH") != lua.LNumber(3) || tbl.RawGetString("locV") != lua.LNumber(4) {
+		t.Errorf("point fields = %v, %v", tbl.RawGetString("locH"), tbl.RawGetString("locV"))
+	}
+}
+
+func TestLValueToLua_SymbolIsPlainString(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	plist := lingo.NewLPropList()
+	plist.AddElement(lingo.NewLSymbol("state"), lingo.NewLSymbol("ready"))
+	L.SetGlobal("msg", lingo.LValueToLua(L, plist))
+	L.SetGlobal("ready", lingo.LValueToLua(L, lingo.NewLSymbol("ready")))
+
+	if err := L.DoString(`
+		assert(msg.state == "ready", "scripts compare received symbols against strings")
+		assert(({ready = true})[ready], "and use them as table keys")
+		assert(msg.state:upper() == "READY")
+	`); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSymbolToLua_ComparesByName(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	L.SetGlobal("ready", lingo.SymbolToLua(L, "ready"))
+	L.SetGlobal("again", lingo.SymbolToLua(L, "ready"))
+	L.SetGlobal("other", lingo.SymbolToLua(L, "waiting"))
+
+	if err := L.DoString(`
+		assert(ready == again, "same-named symbols are equal")
+		assert(ready ~= other, "different symbols are not")
+		assert(tostring(ready) == "ready")
+		assert("state: " .. ready == "state: ready")
+		assert(ready .. 1 == "ready1")
+	`); err != nil {
+		t.Fatal(err)
+	}
+	if back := lingo.LuaToLValue(L.GetGlobal("ready")); back.GetType() != lingo.VtSymbol {
+		t.Errorf("ready came back as %s, want the symbol #ready", back)
+	}
+}
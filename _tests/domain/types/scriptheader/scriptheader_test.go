@@ -0,0 +1,44 @@
+package scriptheader_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"fsos-server/internal/domain/types/scriptheader"
+)
+
+func TestFind_ReadsOptions(t *testing.T) {
+	src := "-- daily cleanup\n-- @job cron=\"0 3 * * *\" tz=Europe/Madrid enabled=false\nreturn 1\n"
+	header, ok := scriptheader.Find(strings.NewReader(src), "job")
+	if !ok {
+		t.Fatal("header not found")
+	}
+	if header.Line != 2 {
+		t.Errorf("Line = %d, want 2", header.Line)
+	}
+	want := []scriptheader.Option{{Key: "cron", Value: "0 3 * * *"}, {Key: "tz", Value: "Europe/Madrid"}, {Key: "enabled", Value: "false"}}
+	if !reflect.DeepEqual(header.Options, want) {
+		t.Errorf("Options = %v, want %v", header.Options, want)
+	}
+	if v, _ := header.Get("tz"); v != "Europe/Madrid" {
+		t.Errorf("Get(tz) = %q", v)
+	}
+}
+
+func TestFind_OnlyHeaderComments(t *testing.T) {
+	for name, src := range map[string]string{
+		"other tag":       "-- @script level=80\n",
+		"longer tag":      "-- @jobs interval=5\n",
+		"not first word":  "-- a helper, not a @job interval=5\n",
+		"not a comment":   "local s = \"@job interval=5\"\n",
+		"past the header": strings.Repeat("--\n", scriptheader.MaxLines) + "-- @job interval=5\n",
+	} {
+		if header, ok := scriptheader.Find(strings.NewReader(src), "job"); ok {
+			t.Errorf("%s: found %+v, want no header", name, header)
+		}
+	}
+	if _, ok := scriptheader.Find(strings.NewReader("---@job interval=5\n"), "job"); !ok {
+		t.Error("a header after three dashes should be found")
+	}
+}
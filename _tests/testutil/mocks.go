@@ -348,6 +348,10 @@ type MockDBAdapter struct {
 	GetActiveBanByUserIDFunc         func(userID int64) (*ports.Ban, error)
 	CreateApplicationFunc            func(appName string) error
 	DeleteApplicationFunc            func(appName string) error
+	ListApplicationsFunc             func(offset, limit int) ([]string, error)
+	ListUsersFunc                    func(offset, limit int) ([]ports.User, error)
+	CountUsersFunc                   func() (int, error)
+	ListBansFunc                     func(offset, limit int, activeOnly bool) ([]ports.Ban, error)
 	SetApplicationAttributeFunc      func(appName, attrName string, value lingo.LValue) error
 	GetApplicationAttributeFunc      func(appName, attrName string) (lingo.LValue, error)
 	GetApplicationAttributeNamesFunc func(appName string) ([]string, error)
@@ -356,11 +360,20 @@ type MockDBAdapter struct {
 	GetPlayerAttributeFunc           func(appName, userID, attrName string) (lingo.LValue, error)
 	GetPlayerAttributeNamesFunc      func(appName, userID string) ([]string, error)
 	DeletePlayerAttributeFunc        func(appName, userID, attrName string) error
+	SetUserAttributeFunc             func(userID, attrName string, value lingo.LValue) error
+	GetUserAttributeFunc             func(userID, attrName string) (lingo.LValue, error)
+	GetUserAttributeNamesFunc        func(userID string) ([]string, error)
+	DeleteUserAttributeFunc          func(userID, attrName string) error
 	CreateUserFunc                   func(username, passwordHash string, userLevel int) error
 	DeleteUserFunc                   func(username string) error
+	UpdateUserLevelFunc              func(username string, level int) error
+	UpdateUserPasswordFunc           func(username, passwordHash string) error
 	CreateBanFunc                    func(userID *int64, ipAddress *string, reason string, expiresAt *time.Time) error
 	RevokeBanFunc                    func(banID int64) error
 	GetActiveBanByIPFunc             func(ipAddress string) (*ports.Ban, error)
+	CreateScriptTimerFunc            func(t ports.ScriptTimer) error
+	DeleteScriptTimerFunc            func(id, senderID string) error
+	ListScriptTimersFunc             func() ([]ports.ScriptTimer, error)
 }
 
 func (m *MockDBAdapter) CreateApplication(appName string) error {
@@ -375,6 +388,30 @@ func (m *MockDBAdapter) DeleteApplication(appName string) error {
 	}
 	return nil
 }
+func (m *MockDBAdapter) ListApplications(offset, limit int) ([]string, error) {
+	if m.ListApplicationsFunc != nil {
+		return m.ListApplicationsFunc(offset, limit)
+	}
+	return nil, nil
+}
+func (m *MockDBAdapter) ListUsers(offset, limit int) ([]ports.User, error) {
+	if m.ListUsersFunc != nil {
+		return m.ListUsersFunc(offset, limit)
+	}
+	return nil, nil
+}
+func (m *MockDBAdapter) CountUsers() (int, error) {
+	if m.CountUsersFunc != nil {
+		return m.CountUsersFunc()
+	}
+	return 0, nil
+}
+func (m *MockDBAdapter) ListBans(offset, limit int, activeOnly bool) ([]ports.Ban, error) {
+	if m.ListBansFunc != nil {
+		return m.ListBansFunc(offset, limit, activeOnly)
+	}
+	return nil, nil
+}
 func (m *MockDBAdapter) SetApplicationAttribute(appName, attrName string, value lingo.LValue) error {
 	if m.SetApplicationAttributeFunc != nil {
 		return m.SetApplicationAttributeFunc(appName, attrName, value)
@@ -423,6 +460,30 @@ func (m *MockDBAdapter) DeletePlayerAttribute(appName, userID, attrName string)
 	}
 	return nil
 }
+func (m *MockDBAdapter) SetUserAttribute(userID, attrName string, value lingo.LValue) error {
+	if m.SetUserAttributeFunc != nil {
+		return m.SetUserAttributeFunc(userID, attrName, value)
+	}
+	return nil
+}
+func (m *MockDBAdapter) GetUserAttribute(userID, attrName string) (lingo.LValue, error) {
+	if m.GetUserAttributeFunc != nil {
+		return m.GetUserAttributeFunc(userID, attrName)
+	}
+	return lingo.NewLVoid(), nil
+}
+func (m *MockDBAdapter) GetUserAttributeNames(userID string) ([]string, error) {
+	if m.GetUserAttributeNamesFunc != nil {
+		return m.GetUserAttributeNamesFunc(userID)
+	}
+	return nil, nil
+}
+func (m *MockDBAdapter) DeleteUserAttribute(userID, attrName string) error {
+	if m.DeleteUserAttributeFunc != nil {
+		return m.DeleteUserAttributeFunc(userID, attrName)
+	}
+	return nil
+}
 func (m *MockDBAdapter) CreateUser(username, passwordHash string, userLevel int) error {
 	if m.CreateUserFunc != nil {
 		return m.CreateUserFunc(username, passwordHash, userLevel)
@@ -441,8 +502,18 @@ func (m *MockDBAdapter) DeleteUser(username string) error {
 	}
 	return nil
 }
-func (m *MockDBAdapter) UpdateUserLevel(username string, level int) error       { return nil }
-func (m *MockDBAdapter) UpdateUserPassword(username, passwordHash string) error { return nil }
+func (m *MockDBAdapter) UpdateUserLevel(username string, level int) error {
+	if m.UpdateUserLevelFunc != nil {
+		return m.UpdateUserLevelFunc(username, level)
+	}
+	return nil
+}
+func (m *MockDBAdapter) UpdateUserPassword(username, passwordHash string) error {
+	if m.UpdateUserPasswordFunc != nil {
+		return m.UpdateUserPasswordFunc(username, passwordHash)
+	}
+	return nil
+}
 func (m *MockDBAdapter) CreateBan(userID *int64, ipAddress *string, reason string, expiresAt *time.Time) error {
 	if m.CreateBanFunc != nil {
 		return m.CreateBanFunc(userID, ipAddress, reason, expiresAt)
@@ -467,6 +538,24 @@ func (m *MockDBAdapter) RevokeBan(banID int64) error {
 	}
 	return nil
 }
+func (m *MockDBAdapter) CreateScriptTimer(t ports.ScriptTimer) error {
+	if m.CreateScriptTimerFunc != nil {
+		return m.CreateScriptTimerFunc(t)
+	}
+	return nil
+}
+func (m *MockDBAdapter) DeleteScriptTimer(id, senderID string) error {
+	if m.DeleteScriptTimerFunc != nil {
+		return m.DeleteScriptTimerFunc(id, senderID)
+	}
+	return nil
+}
+func (m *MockDBAdapter) ListScriptTimers() ([]ports.ScriptTimer, error) {
+	if m.ListScriptTimersFunc != nil {
+		return m.ListScriptTimersFunc()
+	}
+	return nil, nil
+}
 func (m *MockDBAdapter) CreateTable(def ports.Table) error              { return nil }
 func (m *MockDBAdapter) DropTable(name string) error                    { return nil }
 func (m *MockDBAdapter) AddColumn(table string, col ports.Column) error { return nil }
@@ -479,6 +568,7 @@ type MockConnectionWriter struct {
 	Writes      []WriteCall
 	RemapFn     func(oldID, newID string)
 	RemapResult *bool // when non-nil, RemapClientID returns *RemapResult (default true)
+	Disconnects []string
 }
 
 type WriteCall struct {
@@ -496,6 +586,9 @@ func (m *MockConnectionWriter) WriteToClient(clientID string, data []byte) error
 }
 
 func (m *MockConnectionWriter) DisconnectClient(clientID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Disconnects = append(m.Disconnects, clientID)
 	return nil
 }
 
@@ -544,6 +637,18 @@ func (m *MockCache) Set(key string, value []byte, ttl time.Duration) error {
 	return nil
 }
 
+func (m *MockCache) SetNX(key string, value []byte, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.entries[key]; ok {
+		return false, nil
+	}
+	copied := make([]byte, len(value))
+	copy(copied, value)
+	m.entries[key] = copied
+	return true, nil
+}
+
 func (m *MockCache) Delete(key string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -664,9 +769,19 @@ type MockMetrics struct {
 	Errors      atomic.Int64
 	RateLimited atomic.Int64
 	BannedConns atomic.Int64
+
+	ScriptQueueDepth atomic.Int64
+	ScriptWaits      atomic.Int64
+	ScriptExecutions atomic.Int64
+	ScriptsRejected  atomic.Int64
 }
 
 func (m *MockMetrics) IncrementMessages()    { m.Messages.Add(1) }
 func (m *MockMetrics) IncrementErrors()      { m.Errors.Add(1) }
 func (m *MockMetrics) IncrementRateLimited() { m.RateLimited.Add(1) }
 func (m *MockMetrics) IncrementBannedConns() { m.BannedConns.Add(1) }
+
+func (m *MockMetrics) SetScriptQueueDepth(depth int)        { m.ScriptQueueDepth.Store(int64(depth)) }
+func (m *MockMetrics) ObserveScriptWait(time.Duration)      { m.ScriptWaits.Add(1) }
+func (m *MockMetrics) ObserveScriptExecution(time.Duration) { m.ScriptExecutions.Add(1) }
+func (m *MockMetrics) IncrementScriptsRejected()            { m.ScriptsRejected.Add(1) }
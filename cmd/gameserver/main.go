@@ -9,6 +9,7 @@ import (
 	"syscall"
 	"time"
 
+	"fsos-server/external/extensions"
 	"fsos-server/external/migrations"
 	"fsos-server/external/queues"
 	"fsos-server/internal/adapters/inbound"
@@ -16,6 +17,7 @@ import (
 	"fsos-server/internal/adapters/outbound"
 	"fsos-server/internal/config"
 	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/services"
 	"fsos-server/internal/factory"
 )
 
@@ -28,6 +30,14 @@ func main() {
 		os.Exit(1)
 	}
 
+	// `gameserver script <command>` runs a tool over the scripts instead of
+	// the server.
+	if len(os.Args) > 1 && os.Args[1] == "script" {
+		code := runScriptCommand(&cfg, gameLogger, os.Args[2:])
+		gameLogger.Flush()
+		os.Exit(code)
+	}
+
 	gameLogger.Info("Starting " + cfg.ApplicationName + "...")
 	gameLogger.Info("Server configuration", map[string]interface{}{
 		"port":          cfg.Port,
@@ -152,18 +162,85 @@ func main() {
 		emailSender = outbound.NewSMTPEmailSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPass, cfg.SMTPFrom)
 	}
 
+	// 3c. Metrics — created early so the script executor can report to it;
+	// served in step 9
+	var metrics ports.Metrics
+	var metricsServer *inbound.MetricsServer
+	if cfg.MetricsPort != "" {
+		metricsServer = inbound.NewMetricsServer(cfg.MetricsPort, cfg.MetricsBindAddr, sessionStore, gameLogger)
+		metrics = metricsServer
+	}
+
 	// 4. ScriptEngine — can send messages via Sender + access DB + server info + cache
-	scriptEngine := factory.NewScriptEngine(cfg.ScriptsPath, gameLogger, cfg.ScriptTimeout, queue, sender, dbResult.Adapter, dbResult.QueryBuilder, sessionStore, cache, emailSender)
+	scriptEngine := factory.NewScriptEngine(cfg.ScriptsPath, gameLogger, cfg.ScriptTimeout, queue, sender, dbResult.Adapter, dbResult.QueryBuilder, sessionStore, cache, emailSender, cfg.ScriptHTTP, cfg.ScriptLimits)
 	if cfg.ScriptsPath != "" {
 		scripts, _ := filepath.Glob(filepath.Join(cfg.ScriptsPath, "*.lua"))
 		gameLogger.Info("Script engine initialized", map[string]interface{}{
 			"scripts_path":   cfg.ScriptsPath,
 			"scripts_loaded": len(scripts),
+			"http_hosts":     cfg.ScriptHTTP.AllowedHosts,
 		})
 	} else {
 		gameLogger.Info("Script engine disabled (no scripts path configured)")
 	}
 
+	// 4a. Compile every script now so a broken one shows at boot rather than
+	// when a player first runs it; SCRIPT_CHECK=refuse stops the server and
+	// SCRIPT_CHECK=disable makes the broken subjects unavailable
+	if validator, ok := scriptEngine.(ports.ScriptValidator); ok {
+		problems, err := checkScripts(validator, cfg.ScriptsPath)
+		if err != nil {
+			gameLogger.Warn("Script check failed", map[string]interface{}{
+				"scripts_path": cfg.ScriptsPath,
+				"error":        err.Error(),
+			})
+		}
+		for _, p := range problems {
+			gameLogger.Error("Script has errors", map[string]interface{}{
+				"file":  p.Path,
+				"line":  p.Line,
+				"error": p.Message,
+			})
+		}
+		if len(problems) > 0 {
+			switch cfg.ScriptCheck {
+			case "refuse":
+				gameLogger.Fatal("Refusing to start with broken scripts (SCRIPT_CHECK=refuse)", map[string]interface{}{
+					"count": len(problems),
+				})
+			case "disable":
+				validator.DisableBrokenScripts()
+			}
+		}
+	}
+
+	// Kept before 4b wraps the engine: the script watcher reloads through it
+	// and the handler binds mus.movie/mus.group and mus.system to it; mus.admin
+	// is bound in 6 and mus.timer in 12a
+	reloader, _ := scriptEngine.(ports.ScriptReloader)
+	scriptMovies, _ := scriptEngine.(ports.MovieDirectoryUser)
+	scriptModeration, _ := scriptEngine.(ports.ScriptModerationUser)
+	scriptSystem, _ := scriptEngine.(ports.SystemCommandUser)
+	scriptInline, _ := scriptEngine.(ports.ScriptInlinerUser)
+	scriptTimerUser, _ := scriptEngine.(ports.ScriptTimerUser)
+
+	// 4b. ScriptExecutor — every script caller below goes through a bounded
+	// worker pool that keeps each sender's scripts in order
+	if scriptEngine != nil && cfg.ScriptWorkers > 0 {
+		executor := services.NewScriptExecutor(scriptEngine, cfg.ScriptWorkers, cfg.ScriptQueueSize, metrics)
+		defer executor.Stop()
+		if scriptInline != nil {
+			// A hook or System command a script triggers for its own sender
+			// must not queue behind that script
+			scriptInline.UseInliner(executor)
+		}
+		scriptEngine = executor
+		gameLogger.Info("Script executor started", map[string]interface{}{
+			"workers":    cfg.ScriptWorkers,
+			"queue_size": cfg.ScriptQueueSize,
+		})
+	}
+
 	var registeredTopics []string
 	for _, q := range queues.All {
 		topic := q.Topic
@@ -189,9 +266,21 @@ func main() {
 		c <- syscall.SIGTERM
 	})
 	defer timerManager.Stop()
+	if scriptModeration != nil {
+		scriptModeration.UseModeration(pool, timerManager)
+	}
+
+	// 6b. Interceptors — Go ones from external/extensions, then Lua ones from
+	// scripts/interceptors/*.lua in name order
+	interceptors := append([]mus.Interceptor{}, extensions.Interceptors...)
+	if cfg.ScriptsPath != "" {
+		for _, s := range inbound.DiscoverInterceptors(cfg.ScriptsPath, gameLogger) {
+			interceptors = append(interceptors, mus.NewScriptInterceptor(scriptEngine, gameLogger, s.Name, s.Before, s.After))
+		}
+	}
 
 	// 7. Handler — Dispatcher receives ScriptEngine + Sender + pool
-	handler, err := factory.NewHandler(cfg.Protocol, gameLogger, cipher, scriptEngine, dbResult.Adapter, sessionStore, queue, pool, sender, cfg.AuthMode, cfg.DefaultUserLevel, cfg.AllEncrypted, cfg.CommandLevels, emailSender, timerManager)
+	handler, err := factory.NewHandler(cfg.Protocol, gameLogger, cipher, scriptEngine, dbResult.Adapter, sessionStore, queue, pool, sender, cfg.AuthMode, cfg.DefaultUserLevel, cfg.AllEncrypted, cfg.CommandLevels, emailSender, timerManager, cfg.GroupAttrNotify, extensions.All, interceptors, scriptMovies, scriptSystem)
 	if err != nil {
 		gameLogger.Fatal("Failed to initialize protocol handler", map[string]interface{}{
 			"error": err,
@@ -216,10 +305,9 @@ func main() {
 		})
 	}
 
-	// 9. Metrics Server (optional)
-	var metrics ports.Metrics
-	if cfg.MetricsPort != "" {
-		ms := inbound.NewMetricsServer(cfg.MetricsPort, cfg.MetricsBindAddr, sessionStore, gameLogger)
+	// 9. Metrics Server (optional) — built in 3c
+	if metricsServer != nil {
+		ms := metricsServer
 		go func() {
 			if err := ms.Start(); err != nil {
 				gameLogger.Error("Metrics server error", map[string]interface{}{
@@ -228,7 +316,6 @@ func main() {
 			}
 		}()
 		defer ms.Shutdown()
-		metrics = ms
 	}
 
 	// 10. TCPServer — fully constructed
@@ -277,17 +364,53 @@ func main() {
 	}
 
 	// 12. Job Scheduler — runs external/scripts/jobs/<name>.lua on their intervals
+	var scheduler *inbound.Scheduler
+	var scheduledJobs []inbound.ScheduledJob
 	if cfg.JobsEnabled {
 		// Jobs are discovered from external/scripts/jobs/*.lua by their
-		// "-- @job interval=N" header — no Go registration per job.
-		scheduledJobs := inbound.DiscoverJobs(cfg.ScriptsPath, gameLogger)
-		scheduler := inbound.NewScheduler(scriptEngine, scheduledJobs, gameLogger)
+		// "-- @job interval=N" or "-- @job cron=..." header — no Go
+		// registration per job.
+		scheduledJobs = inbound.DiscoverJobs(cfg.ScriptsPath, gameLogger)
+		scheduler = inbound.NewScheduler(scriptEngine, scheduledJobs, gameLogger)
+		if cfg.JobsCluster {
+			if cfg.CacheType == "memory" {
+				gameLogger.Warn("JOBS_CLUSTER with the memory cache only coordinates this instance; use CACHE_TYPE=redis")
+			}
+			scheduler.UseLeases(outbound.NewCacheLeaseStore(cache), cfg.NodeID)
+			gameLogger.Info("Job leases enabled", map[string]interface{}{
+				"node_id": cfg.NodeID,
+			})
+		}
 		scheduler.Start()
 		defer scheduler.Stop()
+		if metricsServer != nil {
+			metricsServer.UseScheduler(scheduler)
+		}
 	} else {
 		gameLogger.Info("Job scheduler disabled (JOBS_ENABLED != 1)")
 	}
 
+	// 12a. Script timers — mus.timer.after runs, re-armed from the DB so
+	// timers set before a restart still fire
+	if scriptTimerUser != nil {
+		scriptTimers := inbound.NewScriptTimerService(scriptEngine, dbResult.Adapter, gameLogger)
+		if err := scriptTimers.Start(); err != nil {
+			gameLogger.Fatal("Failed to load script timers", map[string]interface{}{
+				"error": err,
+			})
+		}
+		defer scriptTimers.Stop()
+		scriptTimerUser.UseScriptTimers(scriptTimers)
+	}
+
+	// 12b. Script watcher — polls the scripts path, recompiles changed files
+	// and reschedules jobs whose header changed
+	if reloader != nil && cfg.ScriptReload > 0 {
+		watcher := inbound.NewScriptWatcher(cfg.ScriptsPath, time.Duration(cfg.ScriptReload)*time.Second, reloader, scheduler, scheduledJobs, gameLogger)
+		watcher.Start()
+		defer watcher.Stop()
+	}
+
 	// 13. UDP Server
 	var udpServer *inbound.UDPServer
 	if cfg.UDPPort != "" {
@@ -314,7 +437,14 @@ func main() {
 		<-udpReady
 	}
 
+	if scriptEngine != nil {
+		mus.NewHooks(scriptEngine, gameLogger).ServerStarted(time.Now())
+	}
+
 	console := inbound.NewConsole(dbResult.Adapter, gameLogger, os.Stdin, cfg.DefaultUserLevel)
+	if scheduler != nil {
+		console.UseScheduler(scheduler)
+	}
 	go console.Run()
 
 	<-c
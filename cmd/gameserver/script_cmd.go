@@ -0,0 +1,151 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"fsos-server/external/migrations"
+	"fsos-server/internal/adapters/inbound"
+	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/config"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/factory"
+)
+
+const scriptUsage = `usage: gameserver script check [scripts-dir]
+       gameserver script test [-format tap|junit] [scripts-dir]`
+
+// runScriptCommand runs `gameserver script <subcommand>` and returns the
+// process exit code.
+func runScriptCommand(cfg *config.ServerConfig, logger ports.Logger, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, scriptUsage)
+		return 2
+	}
+	switch args[0] {
+	case "check":
+		return runScriptCheck(cfg, logger, args[1:])
+	case "test":
+		return runScriptTests(cfg, logger, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown script command %q\n%s\n", args[0], scriptUsage)
+		return 2
+	}
+}
+
+// runScriptCheck compiles every script and checks every @job header, as the
+// server does at startup, printing one line per problem. It exits 1 when there
+// is any.
+func runScriptCheck(cfg *config.ServerConfig, logger ports.Logger, args []string) int {
+	fs := flag.NewFlagSet("script check", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	scriptsDir := cfg.ScriptsPath
+	if fs.NArg() > 0 {
+		scriptsDir = fs.Arg(0)
+	}
+
+	engine := outbound.NewLuaScriptEngine(scriptsDir, logger, cfg.ScriptTimeout, nil, nil, nil, nil, nil, nil, nil)
+	problems, err := checkScripts(engine, scriptsDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "script check: %v\n", err)
+		return 1
+	}
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "script check: %d problem(s) in %s\n", len(problems), scriptsDir)
+		return 1
+	}
+	return 0
+}
+
+// checkScripts reports the scripts under scriptsDir that do not compile and
+// the job files whose @job header is invalid.
+func checkScripts(validator ports.ScriptValidator, scriptsDir string) ([]ports.ScriptProblem, error) {
+	problems, err := validator.ValidateScripts()
+	if err != nil {
+		return nil, err
+	}
+	return append(problems, inbound.ValidateJobHeaders(scriptsDir)...), nil
+}
+
+// runScriptTests runs the *_test.lua files under the scripts directory, each
+// case against its own in-memory session store, cache and queue and a
+// migrated SQLite database in a temp directory. It exits 1 when a case fails.
+func runScriptTests(cfg *config.ServerConfig, logger ports.Logger, args []string) int {
+	fs := flag.NewFlagSet("script test", flag.ContinueOnError)
+	format := fs.String("format", "tap", "report format: tap or junit")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	write := outbound.WriteScriptTestTAP
+	switch *format {
+	case "tap":
+	case "junit":
+		write = outbound.WriteScriptTestJUnit
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %q\n%s\n", *format, scriptUsage)
+		return 2
+	}
+	scriptsDir := cfg.ScriptsPath
+	if fs.NArg() > 0 {
+		scriptsDir = fs.Arg(0)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "script-test-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "script test: %v\n", err)
+		return 1
+	}
+	defer os.RemoveAll(tmpDir)
+
+	runs := 0
+	newDeps := func() (*outbound.ScriptTestDeps, error) {
+		runs++
+		dbResult, err := factory.NewDatabase("sqlite", filepath.Join(tmpDir, fmt.Sprintf("case-%d.db", runs)), migrations.All)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := dbResult.MigrationRunner.RunPending(); err != nil {
+			dbResult.Adapter.Close()
+			return nil, err
+		}
+		cache := outbound.NewMemoryCache()
+		queue := outbound.NewMemoryQueue()
+		return &outbound.ScriptTestDeps{
+			DB:           dbResult.Adapter,
+			QueryBuilder: dbResult.QueryBuilder,
+			SessionStore: outbound.NewMemorySessionStore(),
+			Cache:        cache,
+			Queue:        queue,
+			Close: func() {
+				queue.Close()
+				cache.Close()
+				dbResult.Adapter.Close()
+			},
+		}, nil
+	}
+
+	runner := outbound.NewScriptTestRunner(scriptsDir, logger, cfg.ScriptTimeout, newDeps)
+	files, err := runner.Discover()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "script test: %v\n", err)
+		return 1
+	}
+	results := runner.Run(files)
+	if err := write(os.Stdout, results); err != nil {
+		fmt.Fprintf(os.Stderr, "script test: %v\n", err)
+		return 1
+	}
+	for _, r := range results {
+		if !r.Passed() {
+			return 1
+		}
+	}
+	return 0
+}
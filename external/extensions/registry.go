@@ -0,0 +1,23 @@
+package extensions
+
+import "fsos-server/internal/adapters/inbound/mus"
+
+var (
+	All          []mus.Extension
+	Interceptors []mus.Interceptor
+)
+
+// Register adds a Go-native System command. Mirrors the queues and migrations
+// registries: game code registers entries from init(), and factory.NewHandler
+// wires them into the Dispatcher. Built-in System commands always win; a
+// subject that collides with one fails startup.
+func Register(e mus.Extension) {
+	All = append(All, e)
+}
+
+// RegisterInterceptor adds a Go interceptor to the Dispatcher chain. Go
+// interceptors run in registration order, ahead of the Lua ones under
+// scripts/interceptors/.
+func RegisterInterceptor(i mus.Interceptor) {
+	Interceptors = append(Interceptors, i)
+}
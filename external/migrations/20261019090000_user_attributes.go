@@ -0,0 +1,32 @@
+package migrations
+
+import "fsos-server/internal/domain/ports"
+
+func init() {
+	Register(&migration_20261019090000_user_attributes{})
+}
+
+// user_attributes backs the DBUser.* commands: per-account data shared by
+// every application, keyed by userID like player_attributes (no FK to users —
+// under AUTH_MODE=none a userID need not have an account row).
+type migration_20261019090000_user_attributes struct{}
+
+func (m *migration_20261019090000_user_attributes) Name() string {
+	return "20261019090000_user_attributes"
+}
+
+func (m *migration_20261019090000_user_attributes) Up(db ports.DBAdapter) error {
+	return db.CreateTable(ports.Table{
+		Name: "user_attributes",
+		Columns: []ports.Column{
+			ports.Col("user_id", ports.ColText).NotNull(),
+			ports.Col("attr_name", ports.ColText).NotNull(),
+			ports.Col("value_json", ports.ColText).NotNull(),
+		},
+		PrimaryKeys: []string{"user_id", "attr_name"},
+	})
+}
+
+func (m *migration_20261019090000_user_attributes) Down(db ports.DBAdapter) error {
+	return db.DropTable("user_attributes")
+}
@@ -0,0 +1,37 @@
+package migrations
+
+import "fsos-server/internal/domain/ports"
+
+func init() {
+	Register(&migration_20261019120000_script_timers{})
+}
+
+// script_timers holds the pending mus.timer.after runs, so they survive a
+// restart. A row is deleted when its timer fires or is cancelled.
+type migration_20261019120000_script_timers struct{}
+
+func (m *migration_20261019120000_script_timers) Name() string {
+	return "20261019120000_script_timers"
+}
+
+func (m *migration_20261019120000_script_timers) Up(db ports.DBAdapter) error {
+	if err := db.CreateTable(ports.Table{
+		Name: "script_timers",
+		Columns: []ports.Column{
+			ports.PrimaryKey("id"),
+			ports.UUID("uuid"),
+			ports.Col("subject", ports.ColText).NotNull(),
+			ports.Col("sender_id", ports.ColText).NotNull(),
+			ports.Col("content_json", ports.ColText).NotNull(),
+			ports.Col("fire_at", ports.ColDatetime).NotNull(),
+			ports.Col("created_at", ports.ColDatetime).NotNull().DefaultNow(),
+		},
+	}); err != nil {
+		return err
+	}
+	return db.CreateIndex(ports.Index{Name: "idx_script_timers_fire_at", Table: "script_timers", Columns: []string{"fire_at"}})
+}
+
+func (m *migration_20261019120000_script_timers) Down(db ports.DBAdapter) error {
+	return db.DropTable("script_timers")
+}
@@ -0,0 +1,13 @@
+-- echo_test.lua — unit tests for echo.lua, run with `make script-test`.
+
+test.case("answers with the content it was sent", function()
+  local res = test.run("echo", { sender = "alice", content = { score = 10 } })
+  test.equal(res.content, { score = 10 })
+  test.equal(res.errCode, 0)
+end)
+
+test.case("answers with what a stubbed mus.getContent returns", function()
+  mus.getContent = function() return "stubbed" end
+  local res = test.run("echo")
+  test.equal(res.content, "stubbed")
+end)
@@ -2,9 +2,11 @@ package inbound
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"fsos-server/internal/domain/ports"
 
@@ -16,6 +18,7 @@ type Console struct {
 	logger           ports.Logger
 	reader           io.Reader
 	defaultUserLevel int
+	scheduler        *Scheduler
 }
 
 func NewConsole(db ports.DBAdapter, logger ports.Logger, reader io.Reader, defaultUserLevel int) *Console {
@@ -27,6 +30,11 @@ func NewConsole(db ports.DBAdapter, logger ports.Logger, reader io.Reader, defau
 	}
 }
 
+// UseScheduler enables the job commands.
+func (c *Console) UseScheduler(s *Scheduler) {
+	c.scheduler = s
+}
+
 func (c *Console) Run() {
 	scanner := bufio.NewScanner(c.reader)
 	fmt.Print("> ")
@@ -62,6 +70,12 @@ func (c *Console) execute(line string) {
 		c.banUser(parts[2:])
 	case "revoke ban":
 		c.revokeBan(parts[2:])
+	case "list jobs":
+		c.listJobs()
+	case "run job":
+		c.runJob(parts[2:])
+	case "job history":
+		c.jobHistory(parts[2:])
 	case "help":
 		c.help()
 	case "quit", "exit":
@@ -158,11 +172,104 @@ func (c *Console) revokeBan(args []string) {
 	})
 }
 
+func (c *Console) listJobs() {
+	if c.scheduler == nil {
+		fmt.Println("Job scheduler is disabled.")
+		return
+	}
+	status := c.scheduler.Status()
+	if len(status) == 0 {
+		fmt.Println("No scheduled jobs.")
+		return
+	}
+	for _, st := range status {
+		next := "-"
+		if !st.Next.IsZero() {
+			next = st.Next.Format(time.RFC3339)
+		}
+		last := "never run"
+		if st.LastRun != nil {
+			last = "last run " + formatJobRun(*st.LastRun)
+		}
+		state := ""
+		if st.Running {
+			state = " [running]"
+		}
+		fmt.Printf("  %-20s %-30s next %s, %s%s\n", st.Job.Name, st.Job.Describe(), next, last, state)
+	}
+}
+
+func (c *Console) runJob(args []string) {
+	if len(args) < 1 {
+		fmt.Println("Usage: run job <name>")
+		return
+	}
+	if c.scheduler == nil {
+		fmt.Println("Job scheduler is disabled.")
+		return
+	}
+
+	name := args[0]
+	err := c.scheduler.Trigger(name)
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		fmt.Printf("Error: no scheduled job '%s'.\n", name)
+		return
+	case errors.Is(err, ErrJobRunning):
+		fmt.Printf("Job '%s' is already running; run skipped.\n", name)
+		return
+	case err != nil:
+		fmt.Printf("Error running job: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Job '%s' triggered. Use 'job history %s' to see the result.\n", name, name)
+	c.logger.Info("Job triggered via console", map[string]interface{}{
+		"job": name,
+	})
+}
+
+func (c *Console) jobHistory(args []string) {
+	if len(args) < 1 {
+		fmt.Println("Usage: job history <name>")
+		return
+	}
+	if c.scheduler == nil {
+		fmt.Println("Job scheduler is disabled.")
+		return
+	}
+
+	runs := c.scheduler.History(args[0])
+	if len(runs) == 0 {
+		fmt.Printf("No runs recorded for job '%s'.\n", args[0])
+		return
+	}
+	for _, run := range runs {
+		fmt.Printf("  %s\n", formatJobRun(run))
+	}
+}
+
+func formatJobRun(run JobRun) string {
+	s := fmt.Sprintf("%s (%s)", run.Started.Format(time.RFC3339), run.Duration.Round(time.Millisecond))
+	if run.Manual {
+		s += " manual"
+	}
+	if run.Err != "" {
+		s += " failed: " + run.Err
+	} else {
+		s += " ok"
+	}
+	return s
+}
+
 func (c *Console) help() {
 	fmt.Println("Available commands:")
 	fmt.Println("  create user <username> <password>  - Create a new user")
 	fmt.Println("  ban user <username> [reason]        - Ban a user")
 	fmt.Println("  revoke ban <username>               - Revoke active ban for a user")
+	fmt.Println("  list jobs                           - Show scheduled jobs and their last run")
+	fmt.Println("  run job <name>                      - Run a scheduled job now")
+	fmt.Println("  job history <name>                  - Show a job's recent runs")
 	fmt.Println("  help                                - Show this help")
 }
 
@@ -0,0 +1,78 @@
+package inbound
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"fsos-server/internal/adapters/inbound/mus"
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/scriptheader"
+)
+
+// interceptorHeaderTag names the optional phase header of an interceptor
+// script:
+//
+//	-- @interceptor phase=after
+//	-- @interceptor phase=both
+//
+// Without it an interceptor runs before routing only, which is all a filter
+// needs and spares a second VM per message.
+const interceptorHeaderTag = "interceptor"
+
+// InterceptorScript is one discovered <scriptsDir>/interceptors/<Name>.lua.
+type InterceptorScript struct {
+	Name   string
+	Before bool
+	After  bool
+}
+
+// DiscoverInterceptors scans <scriptsDir>/interceptors/*.lua and returns the
+// scripts sorted by name, which is the order they run in. A missing directory
+// simply means no Lua interceptors.
+func DiscoverInterceptors(scriptsDir string, logger ports.Logger) []InterceptorScript {
+	dir := filepath.Join(scriptsDir, mus.InterceptorScriptDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var scripts []InterceptorScript
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".lua" {
+			continue
+		}
+		s := InterceptorScript{Name: e.Name()[:len(e.Name())-len(".lua")], Before: true}
+		switch parseInterceptorPhase(filepath.Join(dir, e.Name())) {
+		case "after":
+			s.Before, s.After = false, true
+		case "both":
+			s.After = true
+		}
+		scripts = append(scripts, s)
+	}
+
+	sort.Slice(scripts, func(i, j int) bool { return scripts[i].Name < scripts[j].Name })
+	if logger != nil && len(scripts) > 0 {
+		names := make([]string, len(scripts))
+		for i, s := range scripts {
+			names[i] = s.Name
+		}
+		logger.Info("DiscoverInterceptors: Lua interceptors", map[string]interface{}{
+			"count":        len(scripts),
+			"interceptors": names,
+		})
+	}
+	return scripts
+}
+
+// parseInterceptorPhase returns the phase named in the script's header, or ""
+// when there is none or it names no phase.
+func parseInterceptorPhase(path string) string {
+	header, _ := scriptheader.FindFile(path, interceptorHeaderTag)
+	switch phase, _ := header.Get("phase"); phase {
+	case "before", "after", "both":
+		return phase
+	}
+	return ""
+}
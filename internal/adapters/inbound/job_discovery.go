@@ -1,36 +1,37 @@
 package inbound
 
 import (
-	"bufio"
+	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"sort"
 	"strconv"
 	"time"
 
 	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/scriptheader"
 )
 
-// jobHeaderRe matches a self-describing job header line in a job's Lua file:
+// jobHeaderTag names the self-describing job header line in a job's Lua file:
 //
 //	-- @job interval=300
 //	-- @job interval=2 enabled=false
+//	-- @job cron="0 3 * * *" tz=Europe/Madrid jitter=60
+//	-- @job cron=@hourly delay=30 overlap=queue
 //
 // This keeps the job catalog fully data-driven: a new scheduled job is just a
 // Lua file under external/scripts/jobs/ with this header — no Go registration.
-var (
-	jobHeaderRe   = regexp.MustCompile(`@job\b`)
-	jobIntervalRe = regexp.MustCompile(`interval\s*=\s*(\d+)`)
-	jobEnabledRe  = regexp.MustCompile(`enabled\s*=\s*(true|false)`)
-)
+// Values containing spaces are quoted.
+const jobHeaderTag = "job"
 
 // DiscoverJobs scans <scriptsDir>/jobs/*.lua, reads each file's "-- @job …"
 // header, and returns the runnable ScheduledJob list. A job's Name is the file
-// basename (subject "jobs/<name>"); its interval comes from interval=<seconds>;
-// enabled defaults to true and enabled=false skips it. Files with no @job
-// header or a non-positive interval are skipped (logged). Results are sorted by
-// name for a deterministic start order.
+// basename (subject "jobs/<name>"). It runs every interval=<seconds>, or on
+// cron=<expression> in tz=<IANA zone> (server local time by default); jitter=
+// and delay= are seconds, overlap= is skip (default) or queue. enabled
+// defaults to true and enabled=false skips it. Files with no @job header are
+// skipped silently, headers that do not describe a valid schedule are skipped
+// with a warning. Results are sorted by name for a deterministic start order.
 func DiscoverJobs(scriptsDir string, logger ports.Logger) []ScheduledJob {
 	dir := filepath.Join(scriptsDir, "jobs")
 	entries, err := os.ReadDir(dir)
@@ -50,23 +51,28 @@ func DiscoverJobs(scriptsDir string, logger ports.Logger) []ScheduledJob {
 			continue
 		}
 		name := e.Name()[:len(e.Name())-len(".lua")]
-		interval, enabled, ok := parseJobHeader(filepath.Join(dir, e.Name()))
-		if !ok {
+		job, enabled, line, err := parseJobHeader(filepath.Join(dir, e.Name()))
+		if line == 0 {
 			// No @job header — a helper/include, not a scheduled job.
 			continue
 		}
 		if !enabled {
 			continue
 		}
-		if interval <= 0 {
+		job.Name = name
+		if err == nil {
+			_, err = job.schedule()
+		}
+		if err != nil {
 			if logger != nil {
-				logger.Warn("DiscoverJobs: skipping job with non-positive interval", map[string]interface{}{
-					"job": name,
+				logger.Warn("DiscoverJobs: skipping job with an invalid header", map[string]interface{}{
+					"job":   name,
+					"error": err.Error(),
 				})
 			}
 			continue
 		}
-		jobs = append(jobs, ScheduledJob{Name: name, Interval: time.Duration(interval) * time.Second})
+		jobs = append(jobs, job)
 	}
 
 	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
@@ -83,31 +89,79 @@ func DiscoverJobs(scriptsDir string, logger ports.Logger) []ScheduledJob {
 	return jobs
 }
 
-// parseJobHeader reads a job file's leading comment lines for the @job header.
-// Returns (intervalSeconds, enabled, found). Only the first ~20 lines are read
-// (the header is at the top).
-func parseJobHeader(path string) (int, bool, bool) {
-	f, err := os.Open(path)
+// ValidateJobHeaders reports the <scriptsDir>/jobs/*.lua files whose @job
+// header DiscoverJobs would skip as invalid, disabled jobs included. A missing
+// jobs directory is not a problem.
+func ValidateJobHeaders(scriptsDir string) []ports.ScriptProblem {
+	dir := filepath.Join(scriptsDir, "jobs")
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return 0, false, false
+		return nil
 	}
-	defer f.Close()
-
-	scanner := bufio.NewScanner(f)
-	for i := 0; i < 20 && scanner.Scan(); i++ {
-		line := scanner.Text()
-		if !jobHeaderRe.MatchString(line) {
+	var problems []ports.ScriptProblem
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".lua" {
 			continue
 		}
-		interval := 0
-		if m := jobIntervalRe.FindStringSubmatch(line); m != nil {
-			interval, _ = strconv.Atoi(m[1])
+		path := filepath.Join(dir, e.Name())
+		job, _, line, err := parseJobHeader(path)
+		if line == 0 {
+			continue
+		}
+		if err == nil {
+			_, err = job.schedule()
+		}
+		if err != nil {
+			problems = append(problems, ports.ScriptProblem{Path: path, Line: line, Message: "@job header: " + err.Error()})
+		}
+	}
+	return problems
+}
+
+// parseJobHeader reads a job file's @job header. Returns (job without Name,
+// enabled, header line, error); the line is 0 when there is no header.
+func parseJobHeader(path string) (ScheduledJob, bool, int, error) {
+	header, ok := scriptheader.FindFile(path, jobHeaderTag)
+	if !ok {
+		return ScheduledJob{}, false, 0, nil
+	}
+	job, enabled, err := parseJobOptions(header.Options)
+	return job, enabled, header.Line, err
+}
+
+func parseJobOptions(options []scriptheader.Option) (ScheduledJob, bool, error) {
+	var job ScheduledJob
+	enabled := true
+	seconds := func(key, v string) (time.Duration, error) {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("%s=%s: want a number of seconds", key, v)
+		}
+		return time.Duration(n) * time.Second, nil
+	}
+
+	for _, option := range options {
+		key, value := option.Key, option.Value
+		var err error
+		switch key {
+		case "interval":
+			job.Interval, err = seconds(key, value)
+		case "cron":
+			job.Cron = value
+		case "tz":
+			job.TimeZone = value
+		case "jitter":
+			job.Jitter, err = seconds(key, value)
+		case "delay":
+			job.Delay, err = seconds(key, value)
+		case "overlap":
+			job.Overlap = value
+		case "enabled":
+			enabled = value == "true"
 		}
-		enabled := true
-		if m := jobEnabledRe.FindStringSubmatch(line); m != nil {
-			enabled = m[1] == "true"
+		if err != nil {
+			return job, enabled, err
 		}
-		return interval, enabled, true
 	}
-	return 0, false, false
+	return job, enabled, nil
 }
@@ -18,14 +18,22 @@ type MetricsServer struct {
 	sessionStore ports.SessionStore
 	logger       ports.Logger
 	// mu guards startedAt/server, which are written in the Start() goroutine and
-	// read from Shutdown() and the HTTP handlers on other goroutines.
+	// read from Shutdown() and the HTTP handlers on other goroutines, and the
+	// scheduler, attached after the server started.
 	mu          sync.Mutex
 	startedAt   time.Time
 	server      *http.Server
+	scheduler   *Scheduler
 	msgCount    atomic.Int64
 	msgErrors   atomic.Int64
 	rateLimited atomic.Int64
 	bannedConns atomic.Int64
+
+	scriptQueueDepth atomic.Int64
+	scriptsRun       atomic.Int64
+	scriptWaitNs     atomic.Int64
+	scriptExecNs     atomic.Int64
+	scriptsRejected  atomic.Int64
 }
 
 func NewMetricsServer(port string, bindAddr string, sessionStore ports.SessionStore, logger ports.Logger) *MetricsServer {
@@ -45,10 +53,36 @@ func (m *MetricsServer) IncrementErrors()      { m.msgErrors.Add(1) }
 func (m *MetricsServer) IncrementRateLimited() { m.rateLimited.Add(1) }
 func (m *MetricsServer) IncrementBannedConns() { m.bannedConns.Add(1) }
 
+func (m *MetricsServer) SetScriptQueueDepth(depth int)     { m.scriptQueueDepth.Store(int64(depth)) }
+func (m *MetricsServer) ObserveScriptWait(d time.Duration) { m.scriptWaitNs.Add(int64(d)) }
+func (m *MetricsServer) IncrementScriptsRejected()         { m.scriptsRejected.Add(1) }
+func (m *MetricsServer) ObserveScriptExecution(d time.Duration) {
+	m.scriptExecNs.Add(int64(d))
+	m.scriptsRun.Add(1)
+}
+
+// averageMs divides a nanosecond total by count, in milliseconds.
+func averageMs(totalNs, count int64) float64 {
+	if count == 0 {
+		return 0
+	}
+	return float64(totalNs) / float64(count) / float64(time.Millisecond)
+}
+
+// UseScheduler enables GET /jobs, which lists jobs with their recent runs.
+// The metrics server stays read-only: jobs are run by hand from the console
+// ("run job <name>").
+func (m *MetricsServer) UseScheduler(s *Scheduler) {
+	m.mu.Lock()
+	m.scheduler = s
+	m.mu.Unlock()
+}
+
 func (m *MetricsServer) Start() error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", m.handleHealth)
 	mux.HandleFunc("/metrics", m.handleMetrics)
+	mux.HandleFunc("/jobs", m.handleJobs)
 
 	addr := m.bindAddr + ":" + m.port
 	srv := &http.Server{
@@ -106,13 +140,85 @@ func (m *MetricsServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
 		activeConnections = len(conns)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	scriptsRun := m.scriptsRun.Load()
+
+	body := map[string]interface{}{
 		"uptime_seconds":     m.uptime().Seconds(),
 		"active_connections": activeConnections,
 		"messages_processed": m.msgCount.Load(),
 		"message_errors":     m.msgErrors.Load(),
 		"rate_limited":       m.rateLimited.Load(),
 		"banned_connections": m.bannedConns.Load(),
-	})
+		"script_queue_depth": m.scriptQueueDepth.Load(),
+		"scripts_executed":   scriptsRun,
+		"scripts_rejected":   m.scriptsRejected.Load(),
+		"script_wait_avg_ms": averageMs(m.scriptWaitNs.Load(), scriptsRun),
+		"script_exec_avg_ms": averageMs(m.scriptExecNs.Load(), scriptsRun),
+	}
+	m.mu.Lock()
+	scheduler := m.scheduler
+	m.mu.Unlock()
+	if scheduler != nil {
+		jobs := map[string]interface{}{}
+		for _, st := range scheduler.Status() {
+			job := map[string]interface{}{
+				"running":     st.Running,
+				"lease_owner": st.LeaseOwner,
+			}
+			if st.LastRun != nil {
+				job["last_run"] = st.LastRun.Started.Format(time.RFC3339)
+				job["last_duration_ms"] = float64(st.LastRun.Duration) / float64(time.Millisecond)
+				job["last_error"] = st.LastRun.Err
+			}
+			jobs[st.Job.Name] = job
+		}
+		body["jobs"] = jobs
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+func (m *MetricsServer) jobScheduler(w http.ResponseWriter) *Scheduler {
+	m.mu.Lock()
+	s := m.scheduler
+	m.mu.Unlock()
+	if s == nil {
+		http.Error(w, "job scheduler disabled", http.StatusServiceUnavailable)
+	}
+	return s
+}
+
+func (m *MetricsServer) handleJobs(w http.ResponseWriter, r *http.Request) {
+	s := m.jobScheduler(w)
+	if s == nil {
+		return
+	}
+
+	jobs := []map[string]interface{}{}
+	for _, st := range s.Status() {
+		runs := []map[string]interface{}{}
+		for _, run := range s.History(st.Job.Name) {
+			runs = append(runs, map[string]interface{}{
+				"started":     run.Started.Format(time.RFC3339Nano),
+				"duration_ms": float64(run.Duration) / float64(time.Millisecond),
+				"error":       run.Err,
+				"manual":      run.Manual,
+			})
+		}
+		job := map[string]interface{}{
+			"name":        st.Job.Name,
+			"schedule":    st.Job.Describe(),
+			"running":     st.Running,
+			"lease_owner": st.LeaseOwner,
+			"runs":        runs,
+		}
+		if !st.Next.IsZero() {
+			job["next"] = st.Next.Format(time.RFC3339)
+		}
+		jobs = append(jobs, job)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"jobs": jobs})
 }
@@ -1,18 +1,35 @@
 package mus
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
 	"fsos-server/internal/domain/types/smus"
 )
 
+// systemScriptSender is the wire sender of script replies.
+const systemScriptSender = "system.script"
+
+// errServerBusy answers a script the executor had no room for. MUS has no
+// dedicated "busy" code; "no connections available" is the closest a client
+// already knows how to retry on.
+const errServerBusy = smus.ErrNoConnectionsAvailable
+
 type Dispatcher struct {
 	logger        ports.Logger
 	scriptEngine  ports.ScriptEngine
 	systemService *SystemService
 	sender        *Sender
 	queue         ports.QueuePublisher
+	extensions    *ExtensionRegistry
+	interceptors  []Interceptor
+
+	// Script @script headers, when the engine reads them.
+	policies    ports.ScriptPolicySource
+	scriptRates *scriptRateLimiter
 }
 
 func NewDispatcher(
@@ -22,27 +39,68 @@ func NewDispatcher(
 	sender *Sender,
 	queue ports.QueuePublisher,
 ) *Dispatcher {
+	policies, _ := scriptEngine.(ports.ScriptPolicySource)
 	return &Dispatcher{
 		logger:        logger,
 		scriptEngine:  scriptEngine,
 		systemService: systemService,
 		sender:        sender,
 		queue:         queue,
+		policies:      policies,
+		scriptRates:   newScriptRateLimiter(),
 	}
 }
 
+// UseExtensions routes System subjects without a built-in handler to the
+// registry's Go-native extensions.
+func (d *Dispatcher) UseExtensions(r *ExtensionRegistry) {
+	d.extensions = r
+}
+
+// Use appends an interceptor to the chain. Register interceptors before
+// serving; the chain is not synchronized.
+func (d *Dispatcher) Use(i Interceptor) {
+	d.interceptors = append(d.interceptors, i)
+}
+
 func (d *Dispatcher) Dispatch(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
 	if msg.RecptID.Count == 0 {
 		return nil, fmt.Errorf("message has no recipients")
 	}
 
+	for _, i := range d.interceptors {
+		decision := i.Before(senderID, msg)
+		switch decision.Action {
+		case InterceptDrop:
+			d.logger.Debug("Message dropped by interceptor", map[string]interface{}{
+				"senderID": senderID,
+				"subject":  msg.Subject.Value,
+			})
+			return nil, nil
+		case InterceptReject:
+			return NewResponse(msg.Subject.Value, "System", []string{senderID}, decision.ErrCode, lingo.NewLVoid()), nil
+		}
+	}
+
+	resp, err := d.route(senderID, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	for n := len(d.interceptors) - 1; n >= 0; n-- {
+		resp = d.interceptors[n].After(senderID, msg, resp)
+	}
+	return resp, nil
+}
+
+func (d *Dispatcher) route(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
 	// MUS protocol routes by the first recipient only. The recipient list
 	// is message metadata; routing decisions use the primary recipient.
 	recipient := msg.RecptID.Strings[0].Value
 
 	switch recipient {
 	case "System":
-		return d.systemService.Handle(senderID, msg)
+		return d.routeSystem(senderID, msg)
 
 	case "system.script":
 		return d.handleScript(senderID, msg)
@@ -61,13 +119,45 @@ func (d *Dispatcher) Dispatch(senderID string, msg *smus.MUSMessage) (*smus.MUSM
 	}
 }
 
+// routeSystem hands a System message to its built-in handler, or to the
+// extension registered for the subject when there is none.
+func (d *Dispatcher) routeSystem(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
+	if d.extensions != nil && !d.systemService.HasHandler(msg.Subject.Value) {
+		if ext, ok := d.extensions.Lookup(msg.Subject.Value); ok {
+			return d.extensions.handle(ext, senderID, msg)
+		}
+	}
+	return d.systemService.Handle(senderID, msg)
+}
+
+// RunSystemCommand runs a System command for senderID as if the user had sent
+// it, bypassing interceptors, and returns the answer instead of sending it
+// (ports.SystemCommandRunner). Logon is refused: it binds a connection, which
+// a script does not have.
+func (d *Dispatcher) RunSystemCommand(senderID, subject string, content lingo.LValue) (int32, lingo.LValue, error) {
+	if subject == "Logon" {
+		return 0, nil, fmt.Errorf("%s cannot be run from a script", subject)
+	}
+	if content == nil {
+		content = lingo.NewLVoid()
+	}
+	resp, err := d.routeSystem(senderID, NewResponse(subject, senderID, []string{"System"}, 0, content))
+	if err != nil {
+		return 0, nil, err
+	}
+	if resp == nil {
+		return 0, lingo.NewLVoid(), nil
+	}
+	return resp.ErrCode, resp.MsgContent, nil
+}
+
 func (d *Dispatcher) handleScript(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
 	if d.scriptEngine == nil {
 		return nil, nil
 	}
 
 	scriptName := msg.Subject.Value
-	if !d.scriptEngine.HasScript(scriptName) {
+	if isInternalScript(scriptName) || !d.scriptEngine.HasScript(scriptName) {
 		d.logger.Warn("Script not found", map[string]interface{}{
 			"senderID": senderID,
 			"script":   scriptName,
@@ -75,6 +165,10 @@ func (d *Dispatcher) handleScript(senderID string, msg *smus.MUSMessage) (*smus.
 		return nil, nil
 	}
 
+	if errCode := d.checkScriptPolicy(senderID, scriptName); errCode != smus.ErrNoError {
+		return NewResponse(scriptName, systemScriptSender, []string{senderID}, errCode, lingo.NewLVoid()), nil
+	}
+
 	scriptMsg := &ports.ScriptMessage{
 		Subject:  scriptName,
 		SenderID: senderID,
@@ -82,6 +176,13 @@ func (d *Dispatcher) handleScript(senderID string, msg *smus.MUSMessage) (*smus.
 	}
 
 	result, err := d.scriptEngine.Execute(scriptMsg)
+	if errors.Is(err, ports.ErrScriptQueueFull) {
+		d.logger.Warn("Script queue full", map[string]interface{}{
+			"senderID": senderID,
+			"script":   scriptName,
+		})
+		return NewResponse(scriptName, systemScriptSender, []string{senderID}, errServerBusy, lingo.NewLVoid()), nil
+	}
 	if err != nil {
 		d.logger.Error("Script execution failed", map[string]interface{}{
 			"senderID": senderID,
@@ -97,9 +198,104 @@ func (d *Dispatcher) handleScript(senderID string, msg *smus.MUSMessage) (*smus.
 		"result":   fmt.Sprintf("%v", result.Content),
 	})
 
-	if result.Content != nil {
-		return NewResponse(scriptName, "system.script", []string{senderID}, smus.ErrNoError, result.Content), nil
+	// Queued replies go out first; the main reply is the Dispatch response.
+	for _, r := range result.Replies {
+		recipient := r.Recipient
+		if recipient == "" {
+			recipient = senderID
+		}
+		if err := d.sender.SendReply(systemScriptSender, senderID, recipient, r.Subject, r.ErrCode, r.Content); err != nil {
+			d.logger.Error("Script reply delivery failed", map[string]interface{}{
+				"senderID":  senderID,
+				"script":    scriptName,
+				"recipient": recipient,
+				"subject":   r.Subject,
+				"error":     err.Error(),
+			})
+		}
+	}
+
+	if result.NoReply || result.Content == nil {
+		return nil, nil
+	}
+	subject := scriptName
+	if result.Subject != "" {
+		subject = result.Subject
+	}
+	return NewResponse(subject, systemScriptSender, []string{senderID}, result.ErrCode, result.Content), nil
+}
+
+// checkScriptPolicy enforces the script's @script header before it runs: the
+// sender's session level must reach level=, and rate= caps how often each
+// sender may invoke it. It returns the error code to answer with, or
+// ErrNoError. A script whose header cannot be read is refused.
+func (d *Dispatcher) checkScriptPolicy(senderID, scriptName string) int32 {
+	if d.policies == nil {
+		return smus.ErrNoError
 	}
+	policy, err := d.policies.ScriptPolicy(scriptName)
+	if err != nil {
+		d.logger.Error("Script policy unavailable", map[string]interface{}{
+			"senderID": senderID,
+			"script":   scriptName,
+			"error":    err.Error(),
+		})
+		return smus.ErrServerInternalError
+	}
+	if policy.Level > 0 && d.userLevel(senderID) < policy.Level {
+		d.logger.Warn("Script refused: user level too low", map[string]interface{}{
+			"senderID": senderID,
+			"script":   scriptName,
+			"level":    policy.Level,
+		})
+		return smus.ErrNotPermittedWithUserLevel
+	}
+	// Count the script, not the subject: prefix-routed subjects
+	// ("saveLayer1-42", "saveLayer1-43") all run the same one.
+	rateKey := policy.Script
+	if rateKey == "" {
+		rateKey = scriptName
+	}
+	if policy.Rate > 0 && !d.scriptRates.allow(rateKey, senderID, policy.Rate, policy.RateWindow) {
+		d.logger.Warn("Script refused: rate limit", map[string]interface{}{
+			"senderID": senderID,
+			"script":   scriptName,
+			"rate":     policy.Rate,
+			"window":   policy.RateWindow.String(),
+		})
+		return smus.ErrOperationNotAllowed
+	}
+	return smus.ErrNoError
+}
+
+// CheckScriptCall applies to a script's mus.call what handleScript applies to
+// a system.script message (ports.ScriptCallGuard).
+func (d *Dispatcher) CheckScriptCall(senderID, subject string) error {
+	if isInternalScript(subject) {
+		return fmt.Errorf("%s scripts are run by the server only", strings.SplitN(subject, "/", 2)[0])
+	}
+	switch d.checkScriptPolicy(senderID, subject) {
+	case smus.ErrNoError:
+		return nil
+	case smus.ErrNotPermittedWithUserLevel:
+		return fmt.Errorf("user level too low")
+	case smus.ErrOperationNotAllowed:
+		return fmt.Errorf("rate limit exceeded")
+	default:
+		return fmt.Errorf("script policy unavailable")
+	}
+}
+
+// userLevel is the sender's session level, 0 without an Authorizer.
+func (d *Dispatcher) userLevel(senderID string) int {
+	if d.systemService == nil || d.systemService.authz == nil {
+		return 0
+	}
+	return d.systemService.authz.UserLevel(senderID)
+}
 
-	return nil, nil
+// isInternalScript reports whether subject names a script the server runs on
+// its own (interceptors, lifecycle hooks), which clients may not invoke.
+func isInternalScript(subject string) bool {
+	return strings.HasPrefix(subject, InterceptorScriptDir+"/") || strings.HasPrefix(subject, HookScriptDir+"/")
 }
@@ -0,0 +1,110 @@
+package mus
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/services"
+	"fsos-server/internal/domain/types/lingo"
+	"fsos-server/internal/domain/types/smus"
+)
+
+// ExtensionContext is what a Go-native extension handler may use: the same
+// session store, DB, Sender, Authorizer and logger the built-in System
+// commands run against.
+type ExtensionContext struct {
+	Sessions ports.SessionStore
+	DB       ports.DBAdapter
+	Sender   *Sender
+	Authz    *services.Authorizer
+	Logger   ports.Logger
+}
+
+// ExtensionHandler answers a System command; a nil response sends nothing.
+type ExtensionHandler func(ctx *ExtensionContext, senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error)
+
+// Extension is a custom System command registered by game code through
+// external/extensions. Subject is matched exactly, or as a prefix when it ends
+// in "*" ("game.shop.*"). Level is the declared command level, the
+// authorization key for the whole pattern; a commandLevels entry for the
+// pattern takes precedence, and the factory applies a USERLEVEL_ override
+// (USERLEVEL_GAME_SHOP_ALL for "game.shop.*") before registering.
+type Extension struct {
+	Subject string
+	Level   int
+	Handler ExtensionHandler
+}
+
+func (e Extension) isPrefix() bool {
+	return strings.HasSuffix(e.Subject, "*")
+}
+
+// ExtensionRegistry resolves System subjects the built-in handlers do not
+// cover to registered extensions: exact subjects first, then the longest
+// matching prefix.
+type ExtensionRegistry struct {
+	ctx      *ExtensionContext
+	exact    map[string]Extension
+	prefixes []Extension // sorted longest first
+}
+
+func NewExtensionRegistry(ctx *ExtensionContext) *ExtensionRegistry {
+	return &ExtensionRegistry{ctx: ctx, exact: make(map[string]Extension)}
+}
+
+// Register validates ext and declares its command level. Built-in subjects
+// cannot be replaced, and a subject or prefix can be registered only once.
+// Not safe for concurrent use; register everything before serving.
+func (r *ExtensionRegistry) Register(ext Extension, builtin func(subject string) bool) error {
+	if ext.Subject == "" || ext.Subject == "*" {
+		return fmt.Errorf("extension subject %q is empty", ext.Subject)
+	}
+	if ext.Handler == nil {
+		return fmt.Errorf("extension %q has no handler", ext.Subject)
+	}
+	if ext.isPrefix() {
+		for _, p := range r.prefixes {
+			if p.Subject == ext.Subject {
+				return fmt.Errorf("extension %q registered twice", ext.Subject)
+			}
+		}
+		r.prefixes = append(r.prefixes, ext)
+		sort.SliceStable(r.prefixes, func(i, j int) bool {
+			return len(r.prefixes[i].Subject) > len(r.prefixes[j].Subject)
+		})
+	} else {
+		if builtin != nil && builtin(ext.Subject) {
+			return fmt.Errorf("extension %q shadows a built-in System command", ext.Subject)
+		}
+		if _, dup := r.exact[ext.Subject]; dup {
+			return fmt.Errorf("extension %q registered twice", ext.Subject)
+		}
+		r.exact[ext.Subject] = ext
+	}
+	r.ctx.Authz.DeclareCommandLevel(ext.Subject, ext.Level)
+	return nil
+}
+
+// Lookup returns the extension serving subject, if any.
+func (r *ExtensionRegistry) Lookup(subject string) (Extension, bool) {
+	if ext, ok := r.exact[subject]; ok {
+		return ext, true
+	}
+	for _, ext := range r.prefixes {
+		if strings.HasPrefix(subject, strings.TrimSuffix(ext.Subject, "*")) {
+			return ext, true
+		}
+	}
+	return Extension{}, false
+}
+
+// handle runs ext for senderID after the level check, which is keyed by the
+// registered pattern rather than the concrete subject.
+func (r *ExtensionRegistry) handle(ext Extension, senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
+	if !r.ctx.Authz.CanRun(senderID, ext.Subject) {
+		return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrInvalidServerCommand, lingo.NewLVoid()), nil
+	}
+	return ext.Handler(r.ctx, senderID, msg)
+}
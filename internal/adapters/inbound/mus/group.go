@@ -6,25 +6,75 @@ import (
 	"fsos-server/internal/domain/types/lingo"
 	"strings"
 	"sync"
+	"time"
 )
 
+// GroupAttributeChangedSubject is the subject of the message pushed to group
+// members when a group attribute is set or deleted.
+const GroupAttributeChangedSubject = "groupAttributeChanged"
+
+// GroupFanOut delivers one message to the members of a group that pass
+// include. Sender implements it; groups use it for attribute notifications.
+type GroupFanOut interface {
+	SendToGroup(wireFrom, movieID, groupName, subject string, content lingo.LValue, include func(memberID string) bool) error
+}
+
 type Group struct {
 	Name       string
 	movieID    string
 	persistent bool // if true, don't delete when empty (e.g., @AllUsers)
 	mu         sync.RWMutex
 	attributes map[string]lingo.LValue
+
+	// Attribute change notifications: pushed to every member when notifyAll
+	// is set (movie-level setting), otherwise only to subscribed members.
+	// A nil fanOut disables them.
+	fanOut      GroupFanOut
+	notifyAll   bool
+	subscribers map[string]struct{}
+	hooks       *Hooks
 }
 
 func NewGroup(name, movieID string, persistent bool) *Group {
 	return &Group{
-		Name:       name,
-		movieID:    movieID,
-		persistent: persistent,
-		attributes: make(map[string]lingo.LValue),
+		Name:        name,
+		movieID:     movieID,
+		persistent:  persistent,
+		attributes:  make(map[string]lingo.LValue),
+		subscribers: make(map[string]struct{}),
 	}
 }
 
+// attachNotifier wires the group to its movie's notification settings and
+// hooks; called by Movie.AddGroup.
+func (g *Group) attachNotifier(fanOut GroupFanOut, notifyAll bool, hooks *Hooks) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.fanOut = fanOut
+	g.notifyAll = notifyAll
+	g.hooks = hooks
+}
+
+// Subscribe opts userID into attribute change notifications for this group.
+func (g *Group) Subscribe(userID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.subscribers[userID] = struct{}{}
+}
+
+func (g *Group) Unsubscribe(userID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.subscribers, userID)
+}
+
+func (g *Group) IsSubscribed(userID string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	_, ok := g.subscribers[userID]
+	return ok
+}
+
 func (g *Group) GetAttribute(name string) lingo.LValue {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
@@ -34,16 +84,48 @@ func (g *Group) GetAttribute(name string) lingo.LValue {
 	return lingo.NewLVoid()
 }
 
-func (g *Group) SetAttribute(name string, value lingo.LValue) {
+// SetAttribute stores value and notifies members on behalf of author.
+func (g *Group) SetAttribute(name string, value lingo.LValue, author string) {
 	g.mu.Lock()
-	defer g.mu.Unlock()
 	g.attributes[name] = value
+	g.mu.Unlock()
+	g.notifyAttribute(name, value, author)
 }
 
-func (g *Group) DeleteAttribute(name string) {
+// DeleteAttribute removes the attribute; the notification carries VOID.
+func (g *Group) DeleteAttribute(name string, author string) {
 	g.mu.Lock()
-	defer g.mu.Unlock()
 	delete(g.attributes, name)
+	g.mu.Unlock()
+	g.notifyAttribute(name, lingo.NewLVoid(), author)
+}
+
+// notifyAttribute fires the groupAttributeChanged hook and pushes [#group,
+// #attribute, #value, #author, #updatedAt] through the group fan-out, outside
+// the attribute lock.
+func (g *Group) notifyAttribute(name string, value lingo.LValue, author string) {
+	g.mu.RLock()
+	fanOut, notifyAll, hasSubscribers, hooks := g.fanOut, g.notifyAll, len(g.subscribers) > 0, g.hooks
+	g.mu.RUnlock()
+	hooks.After(HookGroupAttributeChanged, author, hookPayload("movieID", g.movieID, "group", g.Name, "attribute", name, "value", value, "author", author))
+	if fanOut == nil || (!notifyAll && !hasSubscribers) {
+		return
+	}
+
+	content := lingo.NewLPropList()
+	content.AddElement(lingo.NewLSymbol("group"), lingo.NewLString(g.Name))
+	content.AddElement(lingo.NewLSymbol("attribute"), lingo.NewLString(name))
+	content.AddElement(lingo.NewLSymbol("value"), value)
+	content.AddElement(lingo.NewLSymbol("author"), lingo.NewLString(author))
+	content.AddElement(lingo.NewLSymbol("updatedAt"), lingo.NewLInteger(int32(time.Now().Unix())))
+
+	include := func(string) bool { return true }
+	if !notifyAll {
+		include = g.IsSubscribed
+	}
+	// Best-effort: the attribute is already stored, and per-member delivery
+	// failures are logged by the fan-out.
+	_ = fanOut.SendToGroup("System", g.movieID, g.Name, GroupAttributeChangedSubject, content, include)
 }
 
 func (g *Group) GetAttributeNames() []string {
@@ -63,6 +145,8 @@ func groupRoomName(movieID, groupName string) string {
 type GroupManager struct {
 	sessionStore ports.SessionStore
 	logger       ports.Logger
+	hooks        *Hooks
+	movies       *MovieManager
 }
 
 func NewGroupManager(sessionStore ports.SessionStore, logger ports.Logger) *GroupManager {
@@ -72,6 +156,17 @@ func NewGroupManager(sessionStore ports.SessionStore, logger ports.Logger) *Grou
 	}
 }
 
+// UseHooks fires group join/leave hooks; beforeJoinGroup can veto a join.
+func (gm *GroupManager) UseHooks(hooks *Hooks) {
+	gm.hooks = hooks
+}
+
+// UseMovies lets LeaveAllGroups drop the user's attribute subscriptions
+// along with the memberships, so they don't outlive the session.
+func (gm *GroupManager) UseMovies(movies *MovieManager) {
+	gm.movies = movies
+}
+
 func (gm *GroupManager) JoinGroup(movieID, groupName, userID string) error {
 	// Verify user is in the movie
 	members, err := gm.sessionStore.GetRoomMembers(movieRoomName(movieID))
@@ -89,6 +184,9 @@ func (gm *GroupManager) JoinGroup(movieID, groupName, userID string) error {
 	if !inMovie {
 		return fmt.Errorf("user %q is not in movie %q", userID, movieID)
 	}
+	if err := gm.hooks.Before(HookBeforeJoinGroup, userID, groupHookPayload(movieID, groupName, userID)); err != nil {
+		return err
+	}
 
 	roomName := groupRoomName(movieID, groupName)
 	if err := gm.sessionStore.JoinRoom(roomName, userID); err != nil {
@@ -100,6 +198,7 @@ func (gm *GroupManager) JoinGroup(movieID, groupName, userID string) error {
 		"groupName": groupName,
 		"userID":    userID,
 	})
+	gm.hooks.After(HookAfterJoinGroup, userID, groupHookPayload(movieID, groupName, userID))
 
 	return nil
 }
@@ -115,6 +214,7 @@ func (gm *GroupManager) LeaveGroup(movieID, groupName, userID string) error {
 		"groupName": groupName,
 		"userID":    userID,
 	})
+	gm.hooks.After(HookAfterLeaveGroup, userID, groupHookPayload(movieID, groupName, userID))
 
 	return nil
 }
@@ -131,20 +231,53 @@ func (gm *GroupManager) GetGroupMemberCount(movieID, groupName string) (int, err
 	return len(members), nil
 }
 
+// UserGroups lists the groups of movieID that userID is in.
+func (gm *GroupManager) UserGroups(movieID, userID string) ([]string, error) {
+	rooms, err := gm.sessionStore.GetClientRooms(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := movieID + ":"
+	movieRoom := movieRoomName(movieID)
+	var groups []string
+	for _, room := range rooms {
+		if room != movieRoom && strings.HasPrefix(room, prefix) {
+			groups = append(groups, strings.TrimPrefix(room, prefix))
+		}
+	}
+	return groups, nil
+}
+
 func (gm *GroupManager) LeaveAllGroups(movieID, userID string) error {
 	rooms, err := gm.sessionStore.GetClientRooms(userID)
 	if err != nil {
 		return fmt.Errorf("failed to get client rooms: %w", err)
 	}
 
+	var movie *Movie
+	if gm.movies != nil {
+		movie, _ = gm.movies.GetMovie(movieID)
+	}
 	prefix := movieID + ":"
 	for _, room := range rooms {
 		if strings.HasPrefix(room, prefix) {
+			groupName := strings.TrimPrefix(room, prefix)
 			if err := gm.sessionStore.LeaveRoom(room, userID); err != nil {
 				return fmt.Errorf("failed to leave group room %q: %w", room, err)
 			}
+			if movie != nil {
+				if group, ok := movie.GetGroup(groupName); ok {
+					group.Unsubscribe(userID)
+				}
+			}
+			gm.hooks.After(HookAfterLeaveGroup, userID, groupHookPayload(movieID, groupName, userID))
 		}
 	}
 
 	return nil
 }
+
+func groupHookPayload(movieID, groupName, userID string) *lingo.LPropList {
+	return hookPayload("movieID", movieID, "group", groupName, "userID", userID)
+}
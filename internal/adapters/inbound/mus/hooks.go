@@ -0,0 +1,178 @@
+package mus
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/services"
+	"fsos-server/internal/domain/types/lingo"
+)
+
+// HookScriptDir is the scripts subdirectory holding lifecycle hooks. An event
+// is hooked by creating hooks/<event>.lua; like interceptors, these scripts
+// never run as system.script subjects.
+const HookScriptDir = "hooks"
+
+// Lifecycle events. Before-hooks may veto the action by answering with a
+// non-zero error code (mus.response(reason, mus.errors.operationNotAllowed));
+// the client gets that code and the reason as content. After-hooks only
+// observe. mus.getSender() is the user concerned ("System" for serverStart).
+// Every hook receives a prop list from mus.getContent():
+//
+//	beforeLogon, afterLogon   [#userID, #movieID, #userLevel, #ip]
+//	logonFailed               [#userID, #movieID, #reason]  (invalidUser, invalidPassword, refused, vetoed, ...)
+//	afterJoinMovie            [#movieID, #userID]
+//	afterLeaveMovie           [#movieID, #userID]
+//	beforeJoinGroup           [#movieID, #group, #userID]
+//	afterJoinGroup            [#movieID, #group, #userID]
+//	afterLeaveGroup           [#movieID, #group, #userID]
+//	groupAttributeChanged     [#movieID, #group, #attribute, #value, #author]  (#value is VOID when deleted)
+//	serverStart               [#startedAt]  (Unix seconds)
+//
+// Movies are joined as part of Logon, so movie admission rules belong in
+// beforeLogon. Disconnects keep their own DISCONNECT_HOOK script.
+const (
+	HookBeforeLogon           = "beforeLogon"
+	HookAfterLogon            = "afterLogon"
+	HookLogonFailed           = "logonFailed"
+	HookAfterJoinMovie        = "afterJoinMovie"
+	HookAfterLeaveMovie       = "afterLeaveMovie"
+	HookBeforeJoinGroup       = "beforeJoinGroup"
+	HookAfterJoinGroup        = "afterJoinGroup"
+	HookAfterLeaveGroup       = "afterLeaveGroup"
+	HookGroupAttributeChanged = "groupAttributeChanged"
+	HookServerStart           = "serverStart"
+)
+
+// HookVetoError is returned by an action a before-hook vetoed.
+type HookVetoError struct {
+	Event   string
+	ErrCode int32
+	Reason  lingo.LValue
+}
+
+func (e *HookVetoError) Error() string {
+	return fmt.Sprintf("%s hook vetoed the action (error code %d)", e.Event, e.ErrCode)
+}
+
+// Hooks runs lifecycle hook scripts through the ScriptEngine. A hook that is
+// missing does nothing; one that fails is logged and, for before-hooks, the
+// action allowed, as with interceptors. A nil *Hooks is valid and inert.
+type Hooks struct {
+	engine ports.ScriptEngine
+	logger ports.Logger
+}
+
+func NewHooks(engine ports.ScriptEngine, logger ports.Logger) *Hooks {
+	return &Hooks{engine: engine, logger: logger}
+}
+
+// Before runs a before-hook and returns a *HookVetoError when it vetoed. The
+// caller waits for it, so the run is Nested: inside the script executor's
+// Inline it does not queue behind the script that fired it.
+func (h *Hooks) Before(event, senderID string, payload *lingo.LPropList) error {
+	if !h.has(event) {
+		return nil
+	}
+	msg := hookMessage(event, senderID, payload)
+	msg.Nested = true
+	result := h.run(msg)
+	if result == nil || result.ErrCode == 0 {
+		return nil
+	}
+	reason := result.Content
+	if reason == nil {
+		reason = lingo.NewLVoid()
+	}
+	return &HookVetoError{Event: event, ErrCode: result.ErrCode, Reason: reason}
+}
+
+// After runs an after-hook without waiting for it, so a hook fired from
+// inside a script (which may hold the sender's turn in the script executor)
+// never waits on itself. Through a queueing engine the hook is submitted from
+// the caller, so one user's after-hooks run in the order their events
+// happened (afterJoinGroup before afterLeaveGroup).
+func (h *Hooks) After(event, senderID string, payload *lingo.LPropList) {
+	if !h.has(event) {
+		return
+	}
+	msg := hookMessage(event, senderID, payload)
+	queue, ok := h.engine.(ports.ScriptSubmitter)
+	if !ok {
+		go h.run(msg)
+		return
+	}
+	err := queue.Submit(msg, func(_ *ports.ScriptResult, err error) {
+		if err != nil {
+			h.failed(event, senderID, err)
+		}
+	})
+	if err != nil {
+		h.failed(event, senderID, err)
+	}
+}
+
+// GuardLogon adapts the beforeLogon hook to services.LogonGuard.
+func (h *Hooks) GuardLogon(a services.LogonAttempt) error {
+	return h.Before(HookBeforeLogon, a.UserID, logonHookPayload(a.UserID, a.MovieID, a.UserLevel, a.IP))
+}
+
+// ServerStarted fires serverStart once the server accepts connections.
+func (h *Hooks) ServerStarted(at time.Time) {
+	h.After(HookServerStart, "System", hookPayload("startedAt", int(at.Unix())))
+}
+
+func (h *Hooks) has(event string) bool {
+	return h != nil && h.engine != nil && h.engine.HasScript(HookScriptDir+"/"+event)
+}
+
+func (h *Hooks) run(msg *ports.ScriptMessage) *ports.ScriptResult {
+	result, err := h.engine.Execute(msg)
+	if err != nil {
+		h.failed(strings.TrimPrefix(msg.Subject, HookScriptDir+"/"), msg.SenderID, err)
+		return nil
+	}
+	return result
+}
+
+func (h *Hooks) failed(event, senderID string, err error) {
+	h.logger.Error("Lifecycle hook failed", map[string]interface{}{
+		"event":    event,
+		"senderID": senderID,
+		"error":    err.Error(),
+	})
+}
+
+func hookMessage(event, senderID string, payload *lingo.LPropList) *ports.ScriptMessage {
+	return &ports.ScriptMessage{
+		Subject:  HookScriptDir + "/" + event,
+		SenderID: senderID,
+		Content:  payload,
+		System:   event == HookServerStart,
+	}
+}
+
+// hookPayload builds a prop list from alternating symbol names and values.
+func hookPayload(pairs ...interface{}) *lingo.LPropList {
+	p := lingo.NewLPropList()
+	for i := 0; i+1 < len(pairs); i += 2 {
+		var v lingo.LValue
+		switch x := pairs[i+1].(type) {
+		case string:
+			v = lingo.NewLString(x)
+		case int:
+			v = lingo.NewLInteger(int32(x))
+		case lingo.LValue:
+			v = x
+		}
+		p.AddElement(lingo.NewLSymbol(pairs[i].(string)), v)
+	}
+	return p
+}
+
+// logonHookPayload is the content of beforeLogon and afterLogon.
+func logonHookPayload(userID, movieID string, userLevel int, ip string) *lingo.LPropList {
+	return hookPayload("userID", userID, "movieID", movieID, "userLevel", userLevel, "ip", ip)
+}
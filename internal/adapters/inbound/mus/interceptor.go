@@ -0,0 +1,143 @@
+package mus
+
+import (
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+	"fsos-server/internal/domain/types/smus"
+)
+
+// InterceptorScriptDir is the scripts subdirectory holding Lua interceptors.
+// Its scripts run only from the interceptor chain, never as system.script
+// subjects.
+const InterceptorScriptDir = "interceptors"
+
+type InterceptAction int
+
+const (
+	InterceptAllow  InterceptAction = iota // continue, possibly with rewritten content
+	InterceptDrop                          // stop silently: no routing, no response
+	InterceptReject                        // stop and answer the sender with ErrCode
+)
+
+type InterceptDecision struct {
+	Action  InterceptAction
+	ErrCode int32
+}
+
+// Interceptor inspects messages around routing. The Dispatcher runs Before
+// in registration order ahead of routing and After in reverse order once
+// routing produced its response.
+type Interceptor interface {
+	// Before may rewrite msg.MsgContent in place before allowing it.
+	Before(senderID string, msg *smus.MUSMessage) InterceptDecision
+	// After receives the response (nil when routing produced none) and
+	// returns the one to send; returning nil suppresses it.
+	After(senderID string, msg *smus.MUSMessage, resp *smus.MUSMessage) *smus.MUSMessage
+}
+
+// InterceptorFuncs adapts plain functions to Interceptor; a nil BeforeFunc
+// allows every message and a nil AfterFunc passes the response through.
+type InterceptorFuncs struct {
+	BeforeFunc func(senderID string, msg *smus.MUSMessage) InterceptDecision
+	AfterFunc  func(senderID string, msg *smus.MUSMessage, resp *smus.MUSMessage) *smus.MUSMessage
+}
+
+func (f InterceptorFuncs) Before(senderID string, msg *smus.MUSMessage) InterceptDecision {
+	if f.BeforeFunc == nil {
+		return InterceptDecision{Action: InterceptAllow}
+	}
+	return f.BeforeFunc(senderID, msg)
+}
+
+func (f InterceptorFuncs) After(senderID string, msg *smus.MUSMessage, resp *smus.MUSMessage) *smus.MUSMessage {
+	if f.AfterFunc == nil {
+		return resp
+	}
+	return f.AfterFunc(senderID, msg, resp)
+}
+
+// ScriptInterceptor runs an interceptors/<name>.lua script through the
+// ScriptEngine, so it gets the same sandbox as any other script plus the
+// mus.intercept module. A failing script is logged and the message allowed:
+// a broken filter must not take the server down with it.
+type ScriptInterceptor struct {
+	engine ports.ScriptEngine
+	logger ports.Logger
+	script string
+	before bool
+	after  bool
+}
+
+func NewScriptInterceptor(engine ports.ScriptEngine, logger ports.Logger, name string, before, after bool) *ScriptInterceptor {
+	return &ScriptInterceptor{
+		engine: engine,
+		logger: logger,
+		script: InterceptorScriptDir + "/" + name,
+		before: before,
+		after:  after,
+	}
+}
+
+func (si *ScriptInterceptor) Before(senderID string, msg *smus.MUSMessage) InterceptDecision {
+	if !si.before {
+		return InterceptDecision{Action: InterceptAllow}
+	}
+	verdict := si.run("before", senderID, msg, msg.MsgContent)
+	if verdict == nil {
+		return InterceptDecision{Action: InterceptAllow}
+	}
+	switch {
+	case verdict.Drop:
+		return InterceptDecision{Action: InterceptDrop}
+	case verdict.ErrCode != 0:
+		return InterceptDecision{Action: InterceptReject, ErrCode: verdict.ErrCode}
+	}
+	if verdict.Content != nil {
+		msg.MsgContent = verdict.Content
+	}
+	return InterceptDecision{Action: InterceptAllow}
+}
+
+func (si *ScriptInterceptor) After(senderID string, msg *smus.MUSMessage, resp *smus.MUSMessage) *smus.MUSMessage {
+	if !si.after || resp == nil {
+		return resp
+	}
+	verdict := si.run("after", senderID, msg, resp.MsgContent)
+	if verdict == nil {
+		return resp
+	}
+	switch {
+	case verdict.Drop:
+		return nil
+	case verdict.ErrCode != 0:
+		return NewResponse(msg.Subject.Value, "System", []string{senderID}, verdict.ErrCode, lingo.NewLVoid())
+	}
+	if verdict.Content != nil {
+		resp.MsgContent = verdict.Content
+	}
+	return resp
+}
+
+func (si *ScriptInterceptor) run(phase, senderID string, msg *smus.MUSMessage, content lingo.LValue) *ports.InterceptVerdict {
+	result, err := si.engine.Execute(&ports.ScriptMessage{
+		Subject:  si.script,
+		SenderID: senderID,
+		Content:  content,
+		Interception: &ports.ScriptInterception{
+			Phase:     phase,
+			Subject:   msg.Subject.Value,
+			Recipient: msg.RecptID.Strings[0].Value,
+		},
+	})
+	if err != nil {
+		si.logger.Error("Interceptor script failed", map[string]interface{}{
+			"script":   si.script,
+			"phase":    phase,
+			"senderID": senderID,
+			"subject":  msg.Subject.Value,
+			"error":    err.Error(),
+		})
+		return nil
+	}
+	return result.Verdict
+}
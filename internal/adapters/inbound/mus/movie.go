@@ -10,16 +10,25 @@ type Movie struct {
 	Name   string
 	groups map[string]*Group
 	mu     sync.RWMutex
+
+	// Group attribute notification settings handed to every group added.
+	fanOut          GroupFanOut
+	notifyAllGroups bool
+	hooks           *Hooks
 }
 
-func newMovie(name string) *Movie {
+func newMovie(name string, fanOut GroupFanOut, notifyAllGroups bool, hooks *Hooks) *Movie {
 	return &Movie{
-		Name:   name,
-		groups: make(map[string]*Group),
+		Name:            name,
+		groups:          make(map[string]*Group),
+		fanOut:          fanOut,
+		notifyAllGroups: notifyAllGroups,
+		hooks:           hooks,
 	}
 }
 
 func (m *Movie) AddGroup(name string, group *Group) {
+	group.attachNotifier(m.fanOut, m.notifyAllGroups, m.hooks)
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.groups[name] = group
@@ -59,6 +68,12 @@ type MovieManager struct {
 	logger       ports.Logger
 	mu           sync.RWMutex
 	movies       map[string]*Movie
+
+	// Group attribute notifications (see EnableGroupAttributeNotifications).
+	fanOut       GroupFanOut
+	notifyMovies map[string]bool
+
+	hooks *Hooks
 }
 
 func NewMovieManager(sessionStore ports.SessionStore, logger ports.Logger) *MovieManager {
@@ -69,6 +84,28 @@ func NewMovieManager(sessionStore ports.SessionStore, logger ports.Logger) *Movi
 	}
 }
 
+// EnableGroupAttributeNotifications lets groups push attribute changes through
+// fanOut. Groups of the listed movies ("*" for every movie) notify all members;
+// elsewhere only members that subscribed to a group are notified. It applies
+// to movies created afterwards, so call it before accepting connections.
+func (mm *MovieManager) EnableGroupAttributeNotifications(fanOut GroupFanOut, notifyMovies []string) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.fanOut = fanOut
+	mm.notifyMovies = make(map[string]bool, len(notifyMovies))
+	for _, id := range notifyMovies {
+		mm.notifyMovies[id] = true
+	}
+}
+
+// UseHooks fires movie and group attribute lifecycle hooks through hooks. Like
+// EnableGroupAttributeNotifications it applies to movies created afterwards.
+func (mm *MovieManager) UseHooks(hooks *Hooks) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.hooks = hooks
+}
+
 func movieRoomName(movieID string) string {
 	return fmt.Sprintf("movie:%s", movieID)
 }
@@ -77,7 +114,7 @@ func (mm *MovieManager) JoinMovie(movieID, userID string) error {
 	mm.mu.Lock()
 	movie, exists := mm.movies[movieID]
 	if !exists {
-		movie = newMovie(movieID)
+		movie = newMovie(movieID, mm.fanOut, mm.notifyMovies["*"] || mm.notifyMovies[movieID], mm.hooks)
 		mm.movies[movieID] = movie
 		mm.logger.Info("Movie created", map[string]interface{}{
 			"movieID": movieID,
@@ -108,6 +145,7 @@ func (mm *MovieManager) JoinMovie(movieID, userID string) error {
 		"movieID": movieID,
 		"userID":  userID,
 	})
+	mm.hooks.After(HookAfterJoinMovie, userID, hookPayload("movieID", movieID, "userID", userID))
 
 	return nil
 }
@@ -120,10 +158,13 @@ func (mm *MovieManager) LeaveMovie(movieID, userID string) error {
 		return fmt.Errorf("movie %q not found", movieID)
 	}
 
-	// Leave all groups in this movie
+	// Leave all groups in this movie, dropping attribute subscriptions too
 	for _, groupName := range movie.GetGroupNames() {
 		roomName := groupRoomName(movieID, groupName)
 		mm.sessionStore.LeaveRoom(roomName, userID)
+		if group, ok := movie.GetGroup(groupName); ok {
+			group.Unsubscribe(userID)
+		}
 	}
 
 	// Clear cached movieID attribute
@@ -156,6 +197,7 @@ func (mm *MovieManager) LeaveMovie(movieID, userID string) error {
 		"movieID": movieID,
 		"userID":  userID,
 	})
+	mm.hooks.After(HookAfterLeaveMovie, userID, hookPayload("movieID", movieID, "userID", userID))
 
 	return nil
 }
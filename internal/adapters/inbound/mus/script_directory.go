@@ -0,0 +1,176 @@
+package mus
+
+import (
+	"fmt"
+	"strings"
+
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/services"
+	"fsos-server/internal/domain/types/lingo"
+)
+
+// serverScriptSenders are the sender ids the server runs its own scripts as:
+// server hooks ("System") and scheduled jobs, which run as "system.jobs/"
+// plus the job name. A script running as one of them acts with admin rights,
+// unless a logged-on user happens to carry the name.
+var serverScriptSenders = map[string]bool{
+	"System":      true,
+	"system.jobs": true,
+}
+
+func isServerScriptSender(id string) bool {
+	return serverScriptSenders[id] || strings.HasPrefix(id, "system.jobs/")
+}
+
+// ScriptDirectory implements ports.MovieDirectory over the movie and group
+// managers, so mus.movie and mus.group behave like the system.movie.* and
+// system.group.* handlers.
+type ScriptDirectory struct {
+	movieManager *MovieManager
+	groupManager *GroupManager
+	sessionStore ports.SessionStore
+	authorizer   *services.Authorizer
+}
+
+func NewScriptDirectory(movieManager *MovieManager, groupManager *GroupManager, sessionStore ports.SessionStore, authorizer *services.Authorizer) *ScriptDirectory {
+	return &ScriptDirectory{
+		movieManager: movieManager,
+		groupManager: groupManager,
+		sessionStore: sessionStore,
+		authorizer:   authorizer,
+	}
+}
+
+func (d *ScriptDirectory) UserMovie(userID string) (string, error) {
+	val, err := d.sessionStore.GetUserAttribute(userID, "#movieID")
+	if err != nil {
+		return "", fmt.Errorf("user %q is not in any movie", userID)
+	}
+	if str, ok := val.(*lingo.LString); ok && str.Value != "" {
+		return str.Value, nil
+	}
+	return "", fmt.Errorf("user %q is not in any movie", userID)
+}
+
+func (d *ScriptDirectory) UserGroups(userID string) ([]string, error) {
+	movieID, err := d.UserMovie(userID)
+	if err != nil {
+		return nil, err
+	}
+	return d.groupManager.UserGroups(movieID, userID)
+}
+
+func (d *ScriptDirectory) UserLevel(userID string) int {
+	if d.authorizer == nil {
+		return 0
+	}
+	return d.authorizer.UserLevel(userID)
+}
+
+func (d *ScriptDirectory) MovieUsers(movieID string) ([]string, error) {
+	return d.movieManager.GetMovieUsers(movieID)
+}
+
+func (d *ScriptDirectory) MovieGroups(movieID string) ([]string, error) {
+	movie, err := d.movie(movieID)
+	if err != nil {
+		return nil, err
+	}
+	return movie.GetGroupNames(), nil
+}
+
+// JoinGroup creates the group on first join, as handleGroupJoin does.
+func (d *ScriptDirectory) JoinGroup(movieID, groupName, userID string) error {
+	movie, err := d.movie(movieID)
+	if err != nil {
+		return err
+	}
+	if _, exists := movie.GetGroup(groupName); !exists {
+		movie.AddGroup(groupName, NewGroup(groupName, movieID, false))
+	}
+	return d.groupManager.JoinGroup(movieID, groupName, userID)
+}
+
+func (d *ScriptDirectory) LeaveGroup(movieID, groupName, userID string) error {
+	if err := d.groupManager.LeaveGroup(movieID, groupName, userID); err != nil {
+		return err
+	}
+	if movie, ok := d.movieManager.GetMovie(movieID); ok {
+		if group, ok := movie.GetGroup(groupName); ok {
+			group.Unsubscribe(userID)
+		}
+	}
+	return nil
+}
+
+func (d *ScriptDirectory) GroupMembers(movieID, groupName string) ([]string, error) {
+	return d.groupManager.GetGroupMembers(movieID, groupName)
+}
+
+func (d *ScriptDirectory) GroupAttribute(movieID, groupName, attrName string) (lingo.LValue, error) {
+	group, err := d.group(movieID, groupName)
+	if err != nil {
+		return nil, err
+	}
+	return group.GetAttribute(attrName), nil
+}
+
+func (d *ScriptDirectory) SetGroupAttribute(movieID, groupName, attrName string, value lingo.LValue, author string) error {
+	group, err := d.group(movieID, groupName)
+	if err != nil {
+		return err
+	}
+	group.SetAttribute(attrName, value, author)
+	return nil
+}
+
+func (d *ScriptDirectory) DeleteGroupAttribute(movieID, groupName, attrName, author string) error {
+	group, err := d.group(movieID, groupName)
+	if err != nil {
+		return err
+	}
+	group.DeleteAttribute(attrName, author)
+	return nil
+}
+
+func (d *ScriptDirectory) GroupAttributeNames(movieID, groupName string) ([]string, error) {
+	group, err := d.group(movieID, groupName)
+	if err != nil {
+		return nil, err
+	}
+	return group.GetAttributeNames(), nil
+}
+
+// CanActOn lets a script touch its own sender, and other users only when the
+// sender holds an admin-level session or the script is a server script.
+func (d *ScriptDirectory) CanActOn(actorID, targetUserID string) bool {
+	if actorID == targetUserID {
+		return true
+	}
+	if isServerScriptSender(actorID) {
+		if connected, err := d.sessionStore.IsConnected(actorID); err == nil && !connected {
+			return true
+		}
+	}
+	return d.authorizer != nil && d.authorizer.OwnerOrAdmin(actorID, targetUserID)
+}
+
+func (d *ScriptDirectory) movie(movieID string) (*Movie, error) {
+	movie, ok := d.movieManager.GetMovie(movieID)
+	if !ok {
+		return nil, fmt.Errorf("movie %q not found", movieID)
+	}
+	return movie, nil
+}
+
+func (d *ScriptDirectory) group(movieID, groupName string) (*Group, error) {
+	movie, err := d.movie(movieID)
+	if err != nil {
+		return nil, err
+	}
+	group, ok := movie.GetGroup(groupName)
+	if !ok {
+		return nil, fmt.Errorf("group %q not found in movie %q", groupName, movieID)
+	}
+	return group, nil
+}
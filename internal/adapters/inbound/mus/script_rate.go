@@ -0,0 +1,53 @@
+package mus
+
+import (
+	"sync"
+	"time"
+)
+
+// scriptRateLimiter counts script invocations per user and script in fixed
+// windows, each script bringing its own limit from its @script header.
+type scriptRateLimiter struct {
+	mu        sync.Mutex
+	windows   map[string]*rateWindow
+	lastSweep time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	span  time.Duration
+	count int
+}
+
+func newScriptRateLimiter() *scriptRateLimiter {
+	return &scriptRateLimiter{windows: make(map[string]*rateWindow), lastSweep: time.Now()}
+}
+
+// allow records one invocation of script by userID and reports whether it
+// stays within limit per window.
+func (r *scriptRateLimiter) allow(script, userID string, limit int, window time.Duration) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(r.lastSweep) > time.Minute {
+		for key, w := range r.windows {
+			if now.Sub(w.start) >= w.span {
+				delete(r.windows, key)
+			}
+		}
+		r.lastSweep = now
+	}
+
+	key := script + "\x00" + userID
+	w, ok := r.windows[key]
+	if !ok || now.Sub(w.start) >= w.span || w.span != window {
+		w = &rateWindow{start: now, span: window}
+		r.windows[key] = w
+	}
+	if w.count >= limit {
+		return false
+	}
+	w.count++
+	return true
+}
@@ -40,11 +40,17 @@ func (s *Sender) SendMessage(senderID, recipientID, subject string, content ling
 // the protocol sender; routingSender resolves the movie for @group fan-out
 // (see ports.MessageSender).
 func (s *Sender) SendMessageFrom(wireFrom, routingSender, recipientID, subject string, content lingo.LValue) error {
+	return s.SendReply(wireFrom, routingSender, recipientID, subject, smus.ErrNoError, content)
+}
+
+// SendReply is SendMessageFrom with a MUS error code on the message, for
+// replies that report a failure (script results queued with mus.reply).
+func (s *Sender) SendReply(wireFrom, routingSender, recipientID, subject string, errCode int32, content lingo.LValue) error {
 	if strings.HasPrefix(recipientID, "@") {
-		return s.deliverToGroup(wireFrom, routingSender, recipientID, subject, content)
+		return s.deliverToGroup(wireFrom, routingSender, recipientID, subject, errCode, content)
 	}
 
-	msg := NewResponse(subject, wireFrom, []string{recipientID}, smus.ErrNoError, content)
+	msg := NewResponse(subject, wireFrom, []string{recipientID}, errCode, content)
 	msgBytes := msg.GetBytes()
 	if s.allEncrypted && s.cipher != nil {
 		msgBytes = s.cipher.Encrypt(msgBytes)
@@ -52,7 +58,7 @@ func (s *Sender) SendMessageFrom(wireFrom, routingSender, recipientID, subject s
 	return s.connWriter.WriteToClient(recipientID, msgBytes)
 }
 
-func (s *Sender) deliverToGroup(wireFrom, routingSender, groupRef, subject string, content lingo.LValue) error {
+func (s *Sender) deliverToGroup(wireFrom, routingSender, groupRef, subject string, errCode int32, content lingo.LValue) error {
 	// Find the movie the routing sender is in; senders that live in no movie
 	// (system.script, jobs) fall back to the configured default movie.
 	rooms, err := s.sessionStore.GetClientRooms(routingSender)
@@ -74,6 +80,17 @@ func (s *Sender) deliverToGroup(wireFrom, routingSender, groupRef, subject strin
 		return fmt.Errorf("sender %q is not in any movie", routingSender)
 	}
 
+	return s.fanOut(wireFrom, movieID, groupRef, subject, errCode, content, nil)
+}
+
+// SendToGroup delivers one message from wireFrom to the members of groupName
+// in movieID that pass include (nil includes everyone). It implements
+// GroupFanOut.
+func (s *Sender) SendToGroup(wireFrom, movieID, groupName, subject string, content lingo.LValue, include func(memberID string) bool) error {
+	return s.fanOut(wireFrom, movieID, groupName, subject, smus.ErrNoError, content, include)
+}
+
+func (s *Sender) fanOut(wireFrom, movieID, groupRef, subject string, errCode int32, content lingo.LValue, include func(memberID string) bool) error {
 	// Look up group members via session store room
 	roomName := groupRoomName(movieID, groupRef)
 	members, err := s.sessionStore.GetRoomMembers(roomName)
@@ -82,13 +99,16 @@ func (s *Sender) deliverToGroup(wireFrom, routingSender, groupRef, subject strin
 	}
 
 	// Serialize once with the group reference as recipient, then deliver to all members
-	msg := NewResponse(subject, wireFrom, []string{groupRef}, smus.ErrNoError, content)
+	msg := NewResponse(subject, wireFrom, []string{groupRef}, errCode, content)
 	msgBytes := msg.GetBytes()
 	if s.allEncrypted && s.cipher != nil {
 		msgBytes = s.cipher.Encrypt(msgBytes)
 	}
 
 	for _, memberID := range members {
+		if include != nil && !include(memberID) {
+			continue
+		}
 		if err := s.connWriter.WriteToClient(memberID, msgBytes); err != nil {
 			s.logger.Warn("Failed to deliver group message", map[string]interface{}{
 				"group":    groupRef,
@@ -23,6 +23,7 @@ type SystemService struct {
 	handlers     map[string]handlerFunc
 	emailSender  ports.EmailSender
 	timerManager ports.TimerManager
+	hooks        *Hooks
 }
 
 func NewSystemService(
@@ -51,33 +52,40 @@ func NewSystemService(
 	}
 
 	s.handlers = map[string]handlerFunc{
-		"Logon":                          s.handleLogon,
-		"system.server.getVersion":       s.handleServerGetVersion,
-		"system.server.getTime":          s.handleServerGetTime,
-		"system.server.getUserCount":     s.handleServerGetUserCount,
-		"system.server.getMovieCount":    s.handleServerGetMovieCount,
-		"system.server.getMovies":        s.handleServerGetMovies,
-		"system.movie.getUserCount":      s.handleMovieGetUserCount,
-		"system.movie.getGroups":         s.handleMovieGetGroups,
-		"system.movie.getGroupCount":     s.handleMovieGetGroupCount,
-		"system.group.join":              s.handleGroupJoin,
-		"JoinGroup":                      s.handleGroupJoin,
-		"system.group.leave":             s.handleGroupLeave,
-		"LeaveGroup":                     s.handleGroupLeave,
-		"system.group.getUsers":          s.handleGroupGetUsers,
-		"system.group.getUserCount":      s.handleGroupGetUserCount,
-		"system.group.setAttribute":      s.handleGroupSetAttribute,
-		"system.group.getAttribute":      s.handleGroupGetAttribute,
-		"system.group.deleteAttribute":   s.handleGroupDeleteAttribute,
-		"system.group.getAttributeNames": s.handleGroupGetAttributeNames,
-		"system.user.getAddress":         s.handleUserGetAddress,
-		"system.user.getGroups":          s.handleUserGetGroups,
-		"system.user.delete":             s.handleUserDelete,
+		"Logon":                              s.handleLogon,
+		"system.server.getVersion":           s.handleServerGetVersion,
+		"system.server.getTime":              s.handleServerGetTime,
+		"system.server.getUserCount":         s.handleServerGetUserCount,
+		"system.server.getMovieCount":        s.handleServerGetMovieCount,
+		"system.server.getMovies":            s.handleServerGetMovies,
+		"system.movie.getUserCount":          s.handleMovieGetUserCount,
+		"system.movie.getGroups":             s.handleMovieGetGroups,
+		"system.movie.getGroupCount":         s.handleMovieGetGroupCount,
+		"system.group.join":                  s.handleGroupJoin,
+		"JoinGroup":                          s.handleGroupJoin,
+		"system.group.leave":                 s.handleGroupLeave,
+		"LeaveGroup":                         s.handleGroupLeave,
+		"system.group.getUsers":              s.handleGroupGetUsers,
+		"system.group.getUserCount":          s.handleGroupGetUserCount,
+		"system.group.setAttribute":          s.handleGroupSetAttribute,
+		"system.group.getAttribute":          s.handleGroupGetAttribute,
+		"system.group.deleteAttribute":       s.handleGroupDeleteAttribute,
+		"system.group.getAttributeNames":     s.handleGroupGetAttributeNames,
+		"system.group.subscribeAttributes":   s.handleGroupSubscribeAttributes,
+		"system.group.unsubscribeAttributes": s.handleGroupUnsubscribeAttributes,
+		"system.user.getAddress":             s.handleUserGetAddress,
+		"system.user.getGroups":              s.handleUserGetGroups,
+		"system.user.delete":                 s.handleUserDelete,
 		// DBPlayer
 		"DBPlayer.getAttribute":      s.handleDBPlayerGetAttribute,
 		"DBPlayer.setAttribute":      s.handleDBPlayerSetAttribute,
 		"DBPlayer.deleteAttribute":   s.handleDBPlayerDeleteAttribute,
 		"DBPlayer.getAttributeNames": s.handleDBPlayerGetAttributeNames,
+		// DBUser
+		"DBUser.getAttribute":      s.handleDBUserGetAttribute,
+		"DBUser.setAttribute":      s.handleDBUserSetAttribute,
+		"DBUser.deleteAttribute":   s.handleDBUserDeleteAttribute,
+		"DBUser.getAttributeNames": s.handleDBUserGetAttributeNames,
 		// DBApplication
 		"DBApplication.getAttribute":      s.handleDBApplicationGetAttribute,
 		"DBApplication.setAttribute":      s.handleDBApplicationSetAttribute,
@@ -89,6 +97,11 @@ func NewSystemService(
 		"DBAdmin.createUser":        s.handleDBAdminCreateUser,
 		"DBAdmin.deleteUser":        s.handleDBAdminDeleteUser,
 		"DBAdmin.getUserCount":      s.handleDBAdminGetUserCount,
+		"DBAdmin.getUsers":          s.handleDBAdminGetUsers,
+		"DBAdmin.getApplications":   s.handleDBAdminGetApplications,
+		"DBAdmin.getBans":           s.handleDBAdminGetBans,
+		"DBAdmin.setUserLevel":      s.handleDBAdminSetUserLevel,
+		"DBAdmin.setPassword":       s.handleDBAdminSetPassword,
 		"DBAdmin.ban":               s.handleDBAdminBan,
 		"DBAdmin.revokeBan":         s.handleDBAdminRevokeBan,
 		// Email
@@ -103,6 +116,17 @@ func NewSystemService(
 	return s
 }
 
+// UseHooks fires the logon lifecycle hooks (see hooks.go) through hooks.
+func (s *SystemService) UseHooks(hooks *Hooks) {
+	s.hooks = hooks
+}
+
+// HasHandler reports whether subject is a built-in System command.
+func (s *SystemService) HasHandler(subject string) bool {
+	_, ok := s.handlers[subject]
+	return ok
+}
+
 func (s *SystemService) Handle(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
 	if handler, ok := s.handlers[msg.Subject.Value]; ok {
 		return handler(senderID, msg)
@@ -131,6 +155,15 @@ func (s *SystemService) handleLogon(connectionID string, msg *smus.MUSMessage) (
 
 	res := s.logon.Logon(req)
 	if res.Code != services.LogonOK {
+		movieID := res.MovieID
+		if req.Credentials != nil {
+			movieID = req.Credentials.MovieID
+		}
+		s.hooks.After(HookLogonFailed, res.UserID, hookPayload("userID", res.UserID, "movieID", movieID, "reason", logonFailureReason(res.Code)))
+		var veto *HookVetoError
+		if errors.As(res.Err, &veto) {
+			return NewResponse("Logon", "System", []string{res.UserID}, veto.ErrCode, veto.Reason), nil
+		}
 		return NewResponse("Logon", "System", []string{res.UserID}, logonErrCode(res.Code), lingo.NewLVoid()), nil
 	}
 
@@ -155,10 +188,35 @@ func (s *SystemService) handleLogon(connectionID string, msg *smus.MUSMessage) (
 		"movieID":    res.MovieID,
 		"user_level": res.UserLevel,
 	})
+	if s.hooks != nil {
+		ip := ""
+		if conn, _ := s.sessionStore.GetConnection(res.UserID); conn != nil {
+			ip = conn.IP
+		}
+		s.hooks.After(HookAfterLogon, res.UserID, logonHookPayload(res.UserID, res.MovieID, res.UserLevel, ip))
+	}
 
 	return NewResponse("Logon", "System", []string{res.UserID}, smus.ErrNoError, lingo.NewLVoid()), nil
 }
 
+// logonFailureReason names a failed logon outcome for the logonFailed hook.
+func logonFailureReason(code services.LogonCode) string {
+	switch code {
+	case services.LogonBadCredentialsFormat:
+		return "badCredentialsFormat"
+	case services.LogonInvalidUser:
+		return "invalidUser"
+	case services.LogonInvalidPassword:
+		return "invalidPassword"
+	case services.LogonRefused:
+		return "refused"
+	case services.LogonVetoed:
+		return "vetoed"
+	default:
+		return "internalError"
+	}
+}
+
 // logonErrCode maps domain logon outcomes to MUS protocol error codes.
 func logonErrCode(code services.LogonCode) int32 {
 	switch code {
@@ -168,7 +226,7 @@ func logonErrCode(code services.LogonCode) int32 {
 		return smus.ErrInvalidUserID
 	case services.LogonInvalidPassword:
 		return smus.ErrInvalidPassword
-	case services.LogonRefused:
+	case services.LogonRefused, services.LogonVetoed:
 		return smus.ErrConnectionRefused
 	default:
 		return smus.ErrServerInternalError
@@ -216,6 +274,11 @@ func (s *SystemService) getUserMovieID(userID string) (string, error) {
 // command-refused response. (backlog H2)
 var errCrossUserDenied = errors.New("cross-user access denied")
 
+// errInvalidDBParams is returned by a DB action whose optional parameters are
+// missing or contradictory (e.g. a ban naming neither user nor IP); it maps to
+// an invalid-format response like a missing required field.
+var errInvalidDBParams = errors.New("invalid DB command parameters")
+
 // handleDBCommand is a generic helper for DB command handlers that follow the pattern:
 // check permissions → parse proplist → extract required fields → execute action → return response.
 func (s *SystemService) handleDBCommand(senderID string, msg *smus.MUSMessage,
@@ -246,11 +309,28 @@ func (s *SystemService) handleDBCommand(senderID string, msg *smus.MUSMessage,
 	return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrNoError, result), nil
 }
 
+// optionalField returns the named property of a prop-list message content, or
+// nil when the content is not a prop-list or does not carry it. It covers the
+// optional parameters handleDBCommand's required-field list cannot express.
+func optionalField(content lingo.LValue, name string) lingo.LValue {
+	plist, ok := content.(*lingo.LPropList)
+	if !ok {
+		return nil
+	}
+	val, err := plist.GetElement(name)
+	if err != nil {
+		return nil
+	}
+	return val
+}
+
 // dbErrorCode maps domain errors to MUS protocol error codes.
 func dbErrorCode(err error) int32 {
 	switch {
 	case errors.Is(err, errCrossUserDenied):
 		return smus.ErrInvalidServerCommand
+	case errors.Is(err, errInvalidDBParams):
+		return smus.ErrInvalidMessageFormat
 	case errors.Is(err, ports.ErrUserNotFound):
 		return smus.ErrDatabaseUserIDNotFound
 	case errors.Is(err, ports.ErrBanNotFound):
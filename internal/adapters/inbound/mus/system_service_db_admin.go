@@ -1,6 +1,9 @@
 package mus
 
 import (
+	"time"
+
+	"fsos-server/internal/domain/services"
 	"fsos-server/internal/domain/types/lingo"
 	"fsos-server/internal/domain/types/smus"
 
@@ -43,25 +46,51 @@ func (s *SystemService) handleDBAdminDeleteUser(senderID string, msg *smus.MUSMe
 		})
 }
 
+// handleDBAdminGetUserCount counts registered accounts; live sessions are
+// system.server.getUserCount.
 func (s *SystemService) handleDBAdminGetUserCount(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
 	return s.handleDBCommand(senderID, msg, nil,
 		func(f map[string]lingo.LValue) (lingo.LValue, error) {
-			conns, err := s.sessionStore.GetAllConnections()
+			n, err := s.db.CountUsers()
 			if err != nil {
 				return nil, err
 			}
-			return lingo.NewLInteger(int32(len(conns))), nil
+			return lingo.NewLInteger(int32(n)), nil
 		})
 }
 
+// handleDBAdminBan bans by #userID, #ipAddress or both. #duration (seconds)
+// makes the ban expire; without it the ban is permanent.
 func (s *SystemService) handleDBAdminBan(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
-	return s.handleDBCommand(senderID, msg, []string{"userID", "reason"},
+	return s.handleDBCommand(senderID, msg, []string{"reason"},
 		func(f map[string]lingo.LValue) (lingo.LValue, error) {
-			user, err := s.db.GetUser(lingo.StringValue(f["userID"]))
-			if err != nil {
-				return nil, err
+			var userID *int64
+			if v := optionalField(msg.MsgContent, "userID"); v != nil {
+				user, err := s.db.GetUser(lingo.StringValue(v))
+				if err != nil {
+					return nil, err
+				}
+				userID = &user.ID
+			}
+			var ipAddress *string
+			if v := optionalField(msg.MsgContent, "ipAddress"); v != nil {
+				if ip := lingo.StringValue(v); ip != "" {
+					ipAddress = &ip
+				}
 			}
-			err = s.db.CreateBan(&user.ID, nil, lingo.StringValue(f["reason"]), nil)
+			if userID == nil && ipAddress == nil {
+				return nil, errInvalidDBParams
+			}
+			var expiresAt *time.Time
+			if v := optionalField(msg.MsgContent, "duration"); v != nil {
+				secs := v.ToInteger()
+				if secs <= 0 {
+					return nil, errInvalidDBParams
+				}
+				t := time.Now().Add(time.Duration(secs) * time.Second)
+				expiresAt = &t
+			}
+			err := s.db.CreateBan(userID, ipAddress, lingo.StringValue(f["reason"]), expiresAt)
 			return lingo.NewLVoid(), err
 		})
 }
@@ -81,3 +110,165 @@ func (s *SystemService) handleDBAdminRevokeBan(senderID string, msg *smus.MUSMes
 			return lingo.NewLVoid(), err
 		})
 }
+
+// Listing defaults: pages hold defaultDBPageSize rows unless #limit asks for
+// another size, capped at maxDBPageSize to keep one reply well under the
+// protocol's message size limit.
+const (
+	defaultDBPageSize = 50
+	maxDBPageSize     = 500
+)
+
+// dbPage reads the optional #offset and #limit of a listing command.
+func dbPage(content lingo.LValue) (offset, limit int) {
+	limit = defaultDBPageSize
+	if v := optionalField(content, "offset"); v != nil {
+		offset = int(v.ToInteger())
+	}
+	if v := optionalField(content, "limit"); v != nil {
+		limit = int(v.ToInteger())
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 || limit > maxDBPageSize {
+		limit = maxDBPageSize
+	}
+	return offset, limit
+}
+
+// unixOrVoid renders an optional timestamp as Unix seconds, VOID when unset.
+func unixOrVoid(t *time.Time) lingo.LValue {
+	if t == nil {
+		return lingo.NewLVoid()
+	}
+	return lingo.NewLInteger(int32(t.Unix()))
+}
+
+func stringOrVoid(s *string) lingo.LValue {
+	if s == nil {
+		return lingo.NewLVoid()
+	}
+	return lingo.NewLString(*s)
+}
+
+func (s *SystemService) handleDBAdminGetUsers(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
+	return s.handleDBCommand(senderID, msg, nil,
+		func(f map[string]lingo.LValue) (lingo.LValue, error) {
+			offset, limit := dbPage(msg.MsgContent)
+			users, err := s.db.ListUsers(offset, limit)
+			if err != nil {
+				return nil, err
+			}
+			list := lingo.NewLList()
+			for _, u := range users {
+				entry := lingo.NewLPropList()
+				entry.AddElement(lingo.NewLSymbol("userID"), lingo.NewLString(u.Username))
+				entry.AddElement(lingo.NewLSymbol("userLevel"), lingo.NewLInteger(int32(u.UserLevel)))
+				entry.AddElement(lingo.NewLSymbol("createdAt"), lingo.NewLInteger(int32(u.CreatedAt.Unix())))
+				list.Values = append(list.Values, entry)
+			}
+			return list, nil
+		})
+}
+
+func (s *SystemService) handleDBAdminGetApplications(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
+	return s.handleDBCommand(senderID, msg, nil,
+		func(f map[string]lingo.LValue) (lingo.LValue, error) {
+			offset, limit := dbPage(msg.MsgContent)
+			names, err := s.db.ListApplications(offset, limit)
+			if err != nil {
+				return nil, err
+			}
+			list := lingo.NewLList()
+			for _, name := range names {
+				list.Values = append(list.Values, lingo.NewLString(name))
+			}
+			return list, nil
+		})
+}
+
+// handleDBAdminGetBans lists active bans; #activeOnly: 0 includes revoked and
+// expired ones.
+func (s *SystemService) handleDBAdminGetBans(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
+	return s.handleDBCommand(senderID, msg, nil,
+		func(f map[string]lingo.LValue) (lingo.LValue, error) {
+			offset, limit := dbPage(msg.MsgContent)
+			activeOnly := true
+			if v := optionalField(msg.MsgContent, "activeOnly"); v != nil {
+				activeOnly = v.ToInteger() != 0
+			}
+			bans, err := s.db.ListBans(offset, limit, activeOnly)
+			if err != nil {
+				return nil, err
+			}
+			list := lingo.NewLList()
+			for _, b := range bans {
+				entry := lingo.NewLPropList()
+				entry.AddElement(lingo.NewLSymbol("banID"), lingo.NewLString(b.UUID))
+				entry.AddElement(lingo.NewLSymbol("userID"), stringOrVoid(b.Username))
+				entry.AddElement(lingo.NewLSymbol("ipAddress"), stringOrVoid(b.IPAddress))
+				entry.AddElement(lingo.NewLSymbol("reason"), lingo.NewLString(b.Reason))
+				entry.AddElement(lingo.NewLSymbol("expiresAt"), unixOrVoid(b.ExpiresAt))
+				entry.AddElement(lingo.NewLSymbol("revokedAt"), unixOrVoid(b.RevokedAt))
+				entry.AddElement(lingo.NewLSymbol("createdAt"), lingo.NewLInteger(int32(b.CreatedAt.Unix())))
+				list.Values = append(list.Values, entry)
+			}
+			return list, nil
+		})
+}
+
+// handleDBAdminSetUserLevel changes an account's level. An admin cannot grant
+// a level above their own nor touch an account that outranks them, and a live
+// session picks up the new level at once.
+func (s *SystemService) handleDBAdminSetUserLevel(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
+	return s.handleDBCommand(senderID, msg, []string{"userID", "userLevel"},
+		func(f map[string]lingo.LValue) (lingo.LValue, error) {
+			userID := lingo.StringValue(f["userID"])
+			level := int(f["userLevel"].ToInteger())
+			if level > s.authz.UserLevel(senderID) {
+				return nil, errCrossUserDenied
+			}
+			if err := s.checkNotOutranked(senderID, userID); err != nil {
+				return nil, err
+			}
+			if err := s.db.UpdateUserLevel(userID, level); err != nil {
+				return nil, err
+			}
+			if connected, err := s.sessionStore.IsConnected(userID); err == nil && connected {
+				s.sessionStore.SetUserAttribute(userID, services.UserLevelAttribute, lingo.NewLInteger(int32(level)))
+			}
+			return lingo.NewLVoid(), nil
+		})
+}
+
+// handleDBAdminSetPassword resets an account's password, unless the account
+// outranks the admin.
+func (s *SystemService) handleDBAdminSetPassword(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
+	return s.handleDBCommand(senderID, msg, []string{"userID", "password"},
+		func(f map[string]lingo.LValue) (lingo.LValue, error) {
+			if err := s.checkNotOutranked(senderID, lingo.StringValue(f["userID"])); err != nil {
+				return nil, err
+			}
+			hash, err := bcrypt.GenerateFromPassword([]byte(lingo.StringValue(f["password"])), bcrypt.DefaultCost)
+			if err != nil {
+				return nil, err
+			}
+			err = s.db.UpdateUserPassword(lingo.StringValue(f["userID"]), string(hash))
+			return lingo.NewLVoid(), err
+		})
+}
+
+// checkNotOutranked refuses an admin action on an account whose stored level
+// is above the sender's, so an admin cannot take over or demote their
+// superiors.
+func (s *SystemService) checkNotOutranked(senderID, userID string) error {
+	target, err := s.db.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	if target.UserLevel > s.authz.UserLevel(senderID) {
+		return errCrossUserDenied
+	}
+	return nil
+}
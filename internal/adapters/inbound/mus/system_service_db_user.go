@@ -0,0 +1,56 @@
+package mus
+
+import (
+	"fsos-server/internal/domain/types/lingo"
+	"fsos-server/internal/domain/types/smus"
+)
+
+func (s *SystemService) handleDBUserGetAttribute(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
+	return s.handleDBCommand(senderID, msg, []string{"userID", "attribute"},
+		func(f map[string]lingo.LValue) (lingo.LValue, error) {
+			if !s.authz.OwnerOrAdmin(senderID, lingo.StringValue(f["userID"])) {
+				return nil, errCrossUserDenied
+			}
+			return s.db.GetUserAttribute(lingo.StringValue(f["userID"]), lingo.StringValue(f["attribute"]))
+		})
+}
+
+func (s *SystemService) handleDBUserSetAttribute(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
+	return s.handleDBCommand(senderID, msg, []string{"userID", "attribute", "value"},
+		func(f map[string]lingo.LValue) (lingo.LValue, error) {
+			if !s.authz.OwnerOrAdmin(senderID, lingo.StringValue(f["userID"])) {
+				return nil, errCrossUserDenied
+			}
+			err := s.db.SetUserAttribute(lingo.StringValue(f["userID"]), lingo.StringValue(f["attribute"]), f["value"])
+			return lingo.NewLVoid(), err
+		})
+}
+
+func (s *SystemService) handleDBUserDeleteAttribute(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
+	return s.handleDBCommand(senderID, msg, []string{"userID", "attribute"},
+		func(f map[string]lingo.LValue) (lingo.LValue, error) {
+			if !s.authz.OwnerOrAdmin(senderID, lingo.StringValue(f["userID"])) {
+				return nil, errCrossUserDenied
+			}
+			err := s.db.DeleteUserAttribute(lingo.StringValue(f["userID"]), lingo.StringValue(f["attribute"]))
+			return lingo.NewLVoid(), err
+		})
+}
+
+func (s *SystemService) handleDBUserGetAttributeNames(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
+	return s.handleDBCommand(senderID, msg, []string{"userID"},
+		func(f map[string]lingo.LValue) (lingo.LValue, error) {
+			if !s.authz.OwnerOrAdmin(senderID, lingo.StringValue(f["userID"])) {
+				return nil, errCrossUserDenied
+			}
+			names, err := s.db.GetUserAttributeNames(lingo.StringValue(f["userID"]))
+			if err != nil {
+				return nil, err
+			}
+			list := lingo.NewLList()
+			for _, name := range names {
+				list.Values = append(list.Values, lingo.NewLString(name))
+			}
+			return list, nil
+		})
+}
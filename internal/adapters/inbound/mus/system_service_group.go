@@ -1,6 +1,9 @@
 package mus
 
 import (
+	"errors"
+	"slices"
+
 	"fsos-server/internal/domain/types/lingo"
 	"fsos-server/internal/domain/types/smus"
 )
@@ -25,6 +28,10 @@ func (s *SystemService) handleGroupJoin(senderID string, msg *smus.MUSMessage) (
 	}
 
 	if err := s.groupManager.JoinGroup(movieID, groupName, senderID); err != nil {
+		var veto *HookVetoError
+		if errors.As(err, &veto) {
+			return NewResponse(msg.Subject.Value, "System", []string{senderID}, veto.ErrCode, veto.Reason), nil
+		}
 		return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrServerInternalError, lingo.NewLVoid()), nil
 	}
 	return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrNoError, lingo.NewLVoid()), nil
@@ -42,6 +49,11 @@ func (s *SystemService) handleGroupLeave(senderID string, msg *smus.MUSMessage)
 	if err := s.groupManager.LeaveGroup(movieID, groupName, senderID); err != nil {
 		return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrServerInternalError, lingo.NewLVoid()), nil
 	}
+	if movie, ok := s.movieManager.GetMovie(movieID); ok {
+		if group, ok := movie.GetGroup(groupName); ok {
+			group.Unsubscribe(senderID)
+		}
+	}
 	return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrNoError, lingo.NewLVoid()), nil
 }
 
@@ -114,7 +126,7 @@ func (s *SystemService) handleGroupSetAttribute(senderID string, msg *smus.MUSMe
 	if !ok {
 		return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrServerInternalError, lingo.NewLVoid()), nil
 	}
-	group.SetAttribute(attrName, valueVal)
+	group.SetAttribute(attrName, valueVal, senderID)
 	return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrNoError, lingo.NewLVoid()), nil
 }
 
@@ -180,7 +192,7 @@ func (s *SystemService) handleGroupDeleteAttribute(senderID string, msg *smus.MU
 	if !ok {
 		return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrServerInternalError, lingo.NewLVoid()), nil
 	}
-	group.DeleteAttribute(attrName)
+	group.DeleteAttribute(attrName, senderID)
 	return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrNoError, lingo.NewLVoid()), nil
 }
 
@@ -208,3 +220,45 @@ func (s *SystemService) handleGroupGetAttributeNames(senderID string, msg *smus.
 	}
 	return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrNoError, list), nil
 }
+
+// handleGroupSubscribeAttributes opts the sender into groupAttributeChanged
+// messages for a group they belong to. Content: the group name.
+func (s *SystemService) handleGroupSubscribeAttributes(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
+	return s.handleGroupSubscription(senderID, msg, true)
+}
+
+func (s *SystemService) handleGroupUnsubscribeAttributes(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
+	return s.handleGroupSubscription(senderID, msg, false)
+}
+
+func (s *SystemService) handleGroupSubscription(senderID string, msg *smus.MUSMessage, subscribe bool) (*smus.MUSMessage, error) {
+	groupName, err := lingo.ExtractString(msg.MsgContent)
+	if err != nil {
+		return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrInvalidMessageFormat, lingo.NewLVoid()), nil
+	}
+	movieID, err := s.getUserMovieID(senderID)
+	if err != nil {
+		return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrServerInternalError, lingo.NewLVoid()), nil
+	}
+	movie, ok := s.movieManager.GetMovie(movieID)
+	if !ok {
+		return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrServerInternalError, lingo.NewLVoid()), nil
+	}
+	group, ok := movie.GetGroup(groupName)
+	if !ok {
+		return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrServerInternalError, lingo.NewLVoid()), nil
+	}
+	if subscribe {
+		members, err := s.groupManager.GetGroupMembers(movieID, groupName)
+		if err != nil {
+			return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrServerInternalError, lingo.NewLVoid()), nil
+		}
+		if !slices.Contains(members, senderID) {
+			return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrOperationNotAllowed, lingo.NewLVoid()), nil
+		}
+		group.Subscribe(senderID)
+	} else {
+		group.Unsubscribe(senderID)
+	}
+	return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrNoError, lingo.NewLVoid()), nil
+}
@@ -3,7 +3,6 @@ package mus
 import (
 	"fsos-server/internal/domain/types/lingo"
 	"fsos-server/internal/domain/types/smus"
-	"strings"
 )
 
 func (s *SystemService) handleUserGetAddress(senderID string, msg *smus.MUSMessage) (*smus.MUSMessage, error) {
@@ -20,22 +19,14 @@ func (s *SystemService) handleUserGetGroups(senderID string, msg *smus.MUSMessag
 		return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrServerInternalError, lingo.NewLVoid()), nil
 	}
 
-	rooms, err := s.sessionStore.GetClientRooms(senderID)
+	groups, err := s.groupManager.UserGroups(movieID, senderID)
 	if err != nil {
 		return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrServerInternalError, lingo.NewLVoid()), nil
 	}
 
-	prefix := movieID + ":"
-	movieRoom := "movie:" + movieID
 	list := lingo.NewLList()
-	for _, room := range rooms {
-		if room == movieRoom {
-			continue
-		}
-		if strings.HasPrefix(room, prefix) {
-			groupName := strings.TrimPrefix(room, prefix)
-			list.Values = append(list.Values, lingo.NewLString(groupName))
-		}
+	for _, groupName := range groups {
+		list.Values = append(list.Values, lingo.NewLString(groupName))
 	}
 	return NewResponse(msg.Subject.Value, "System", []string{senderID}, smus.ErrNoError, list), nil
 }
@@ -1,6 +1,9 @@
 package inbound
 
 import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
 	"sync"
 	"time"
 
@@ -8,46 +11,172 @@ import (
 	"fsos-server/internal/domain/types/lingo"
 )
 
-// jobSenderID is the synthetic sender used for scheduler-invoked scripts. Jobs
-// operate server-wide and derive any per-player target from their own DB
-// queries; getSender() in a job script returns this label.
-const jobSenderID = "system.jobs"
+// jobSenderPrefix starts the synthetic sender of scheduler-invoked scripts,
+// followed by the job name: "system.jobs/cleanup". Jobs operate server-wide
+// and derive any per-player target from their own DB queries; getSender() in
+// a job script returns this label. A sender per job keeps the ScriptExecutor
+// from serializing unrelated jobs behind each other.
+const jobSenderPrefix = "system.jobs/"
 
-// ScheduledJob is one recurring job: a Lua script under external/scripts/jobs/
-// run every Interval. Name is the script basename (subject "jobs/<Name>").
+// jobHistorySize is how many recent runs the scheduler remembers per job.
+const jobHistorySize = 20
+
+// Overlap policies: what happens when a job is due while its previous run is
+// still going. OverlapSkip drops the new run; OverlapQueue runs it as soon as
+// the current one ends, keeping at most one waiting run.
+const (
+	OverlapSkip  = "skip"
+	OverlapQueue = "queue"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobRunning  = errors.New("job already running")
+)
+
+// ScheduledJob is one recurring job: a Lua script under external/scripts/jobs/.
+// Name is the script basename (subject "jobs/<Name>"). It runs every Interval,
+// or on the Cron expression when one is set. The struct is comparable so
+// Reconcile can tell whether a job's definition changed.
 type ScheduledJob struct {
 	Name     string
 	Interval time.Duration
+	Cron     string        // cron expression or alias; replaces Interval
+	TimeZone string        // IANA zone for Cron; empty means server local time
+	Jitter   time.Duration // up to this much random delay added to every run
+	Delay    time.Duration // interval jobs: first run after Delay instead of one Interval; cron jobs: no run before Delay
+	Overlap  string        // OverlapSkip (default) or OverlapQueue
+}
+
+// JobRun records one run of a job. Err is empty when the script succeeded.
+type JobRun struct {
+	Job      string
+	Started  time.Time
+	Duration time.Duration
+	Err      string
+	Manual   bool
+}
+
+// JobStatus describes a scheduled job for the console and GET /jobs.
+// LastRun is this instance's; with leases, LeaseOwner names the instance that
+// took the latest scheduled run, which may be another one.
+type JobStatus struct {
+	Job        ScheduledJob
+	Next       time.Time // zero before Start or when the job is not scheduled
+	Running    bool
+	LastRun    *JobRun
+	LeaseOwner string
+}
+
+// Describe renders the job's schedule for humans.
+func (j ScheduledJob) Describe() string {
+	if j.Cron == "" {
+		return "every " + j.Interval.String()
+	}
+	if j.TimeZone == "" {
+		return "cron " + j.Cron
+	}
+	return "cron " + j.Cron + " " + j.TimeZone
 }
 
-// Scheduler runs registered jobs on their own tickers, invoking the shared
-// script engine once per interval. It is a standalone background service
+// jobSchedule yields the next firing time after a given one.
+type jobSchedule func(after time.Time) time.Time
+
+// schedule validates the job's timing options and returns its schedule.
+func (j ScheduledJob) schedule() (jobSchedule, error) {
+	if j.Overlap != "" && j.Overlap != OverlapSkip && j.Overlap != OverlapQueue {
+		return nil, fmt.Errorf("overlap=%s: want %s or %s", j.Overlap, OverlapSkip, OverlapQueue)
+	}
+	if j.Cron == "" {
+		if j.TimeZone != "" {
+			return nil, fmt.Errorf("tz=%s needs a cron schedule", j.TimeZone)
+		}
+		if j.Interval <= 0 {
+			return nil, fmt.Errorf("non-positive interval %s", j.Interval)
+		}
+		interval := j.Interval
+		return func(after time.Time) time.Time { return after.Add(interval) }, nil
+	}
+
+	cron, err := ParseCron(j.Cron)
+	if err != nil {
+		return nil, err
+	}
+	loc := time.Local
+	if j.TimeZone != "" {
+		if loc, err = time.LoadLocation(j.TimeZone); err != nil {
+			return nil, fmt.Errorf("tz=%s: %w", j.TimeZone, err)
+		}
+	}
+	if cron.Next(time.Now().In(loc)).IsZero() {
+		return nil, fmt.Errorf("cron %q never fires", j.Cron)
+	}
+	return func(after time.Time) time.Time { return cron.Next(after.In(loc)) }, nil
+}
+
+// Scheduler runs registered jobs on their own timers, invoking the shared
+// script engine whenever a job is due. It is a standalone background service
 // (separate from the message-queue/consumer system) modeled on IdleChecker:
-// one goroutine per job, a shared done channel, and a once-guarded Stop.
+// one goroutine per job and a once-guarded Stop. Runs happen off the timer
+// goroutine so the overlap policy, not the timer, decides what a slow job
+// does; run state and history are kept per job name and survive Reconcile,
+// which swaps the job set while running, for hot reload.
 type Scheduler struct {
 	engine   ports.ScriptEngine
 	logger   ports.Logger
+	mu       sync.Mutex
 	jobs     []ScheduledJob
-	done     chan struct{}
+	loops    map[string]*jobLoop  // running loops by job name, once started
+	states   map[string]*jobState // run state by job name
+	leases   ports.LeaseStore     // nil: every instance runs every job
+	nodeID   string
+	started  bool
+	stopped  bool
 	wg       sync.WaitGroup
 	stopOnce sync.Once
 }
 
-// NewScheduler builds a scheduler for the given jobs. Jobs with a non-positive
-// interval or whose script is missing are dropped at construction (logged), so
-// Start only ticks runnable jobs. A nil engine yields a no-op scheduler.
+type jobLoop struct {
+	job      ScheduledJob
+	schedule jobSchedule
+	next     time.Time // guarded by Scheduler.mu
+	stop     chan struct{}
+}
+
+type jobState struct {
+	running      bool
+	queued       bool
+	queuedManual bool
+	history      []JobRun // oldest first, at most jobHistorySize
+	leaseOwner   string
+}
+
+// NewScheduler builds a scheduler for the given jobs. Jobs with an invalid
+// schedule or whose script is missing are dropped at construction (logged),
+// so Start only schedules runnable jobs. A nil engine yields a no-op scheduler.
 func NewScheduler(engine ports.ScriptEngine, jobs []ScheduledJob, logger ports.Logger) *Scheduler {
+	s := &Scheduler{
+		engine: engine,
+		logger: logger,
+		loops:  make(map[string]*jobLoop),
+		states: make(map[string]*jobState),
+	}
+	s.jobs = s.runnable(jobs)
+	return s
+}
+
+func (s *Scheduler) runnable(jobs []ScheduledJob) []ScheduledJob {
 	var runnable []ScheduledJob
 	for _, j := range jobs {
-		if j.Interval <= 0 {
-			logger.Warn("Scheduler: skipping job with non-positive interval", map[string]interface{}{
-				"job":      j.Name,
-				"interval": j.Interval.String(),
+		if _, err := j.schedule(); err != nil {
+			s.logger.Warn("Scheduler: skipping job with an invalid schedule", map[string]interface{}{
+				"job":   j.Name,
+				"error": err.Error(),
 			})
 			continue
 		}
-		if engine == nil || !engine.HasScript("jobs/"+j.Name) {
-			logger.Warn("Scheduler: skipping job with no script", map[string]interface{}{
+		if s.engine == nil || !s.engine.HasScript("jobs/"+j.Name) {
+			s.logger.Warn("Scheduler: skipping job with no script", map[string]interface{}{
 				"job":    j.Name,
 				"script": "jobs/" + j.Name + ".lua",
 			})
@@ -55,18 +184,31 @@ func NewScheduler(engine ports.ScriptEngine, jobs []ScheduledJob, logger ports.L
 		}
 		runnable = append(runnable, j)
 	}
+	return runnable
+}
 
-	return &Scheduler{
-		engine: engine,
-		logger: logger,
-		jobs:   runnable,
-		done:   make(chan struct{}),
-	}
+// UseLeases makes the scheduler cluster-safe: every scheduled run first takes
+// a lease named after the job and its firing time, and only the instance that
+// gets it runs the job. Interval jobs then fire on multiples of their interval
+// so that all instances agree on firing times. A lease outlives its tick by
+// several periods (see jobLeaseTTL), so an instance whose timer fires late
+// still finds it taken. Manual triggers take no lease. Call before Start.
+func (s *Scheduler) UseLeases(leases ports.LeaseStore, nodeID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leases = leases
+	s.nodeID = nodeID
 }
 
-// Start launches one goroutine per job. Each ticks on its own interval and runs
-// the job until Stop is called. No-op when there are no runnable jobs.
+// Start launches one goroutine per job. Each waits for the job's next firing
+// time and runs it until Stop is called. No-op when there are no runnable jobs.
 func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started || s.stopped {
+		return
+	}
+	s.started = true
 	if len(s.jobs) == 0 {
 		s.logger.Info("Scheduler: no jobs to run")
 		return
@@ -75,8 +217,7 @@ func (s *Scheduler) Start() {
 	names := make([]string, len(s.jobs))
 	for i, j := range s.jobs {
 		names[i] = j.Name
-		s.wg.Add(1)
-		go s.runJobLoop(j)
+		s.startLoop(j)
 	}
 	s.logger.Info("Scheduler started", map[string]interface{}{
 		"count": len(s.jobs),
@@ -84,50 +225,326 @@ func (s *Scheduler) Start() {
 	})
 }
 
-func (s *Scheduler) runJobLoop(job ScheduledJob) {
+// Reconcile replaces the job set, filtered as in NewScheduler. Once started,
+// loops of removed jobs stop, new jobs start, and a job whose definition
+// changed restarts on the new schedule; unchanged jobs keep their timer. A run
+// in progress is not interrupted.
+func (s *Scheduler) Reconcile(jobs []ScheduledJob) {
+	runnable := s.runnable(jobs)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.jobs = runnable
+	if !s.started {
+		return
+	}
+
+	want := make(map[string]ScheduledJob, len(runnable))
+	for _, j := range runnable {
+		want[j.Name] = j
+	}
+	for name, loop := range s.loops {
+		if j, ok := want[name]; ok && j == loop.job {
+			continue
+		}
+		close(loop.stop)
+		delete(s.loops, name)
+		s.logger.Info("Scheduler: job stopped", map[string]interface{}{
+			"job": name,
+		})
+	}
+	for _, j := range runnable {
+		if _, running := s.loops[j.Name]; running {
+			continue
+		}
+		s.startLoop(j)
+		s.logger.Info("Scheduler: job scheduled", map[string]interface{}{
+			"job":      j.Name,
+			"schedule": j.Describe(),
+		})
+	}
+}
+
+// Jobs returns the current runnable job set.
+func (s *Scheduler) Jobs() []ScheduledJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ScheduledJob(nil), s.jobs...)
+}
+
+// Status reports every runnable job in name order.
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := make([]JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		st := JobStatus{Job: j}
+		if loop, ok := s.loops[j.Name]; ok {
+			st.Next = loop.next
+		}
+		if state, ok := s.states[j.Name]; ok {
+			st.Running = state.running
+			st.LeaseOwner = state.leaseOwner
+			if n := len(state.history); n > 0 {
+				last := state.history[n-1]
+				st.LastRun = &last
+			}
+		}
+		status = append(status, st)
+	}
+	return status
+}
+
+// History returns the job's recent runs, oldest first.
+func (s *Scheduler) History(name string) []JobRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state, ok := s.states[name]; ok {
+		return append([]JobRun(nil), state.history...)
+	}
+	return nil
+}
+
+// Trigger runs a job now, outside its schedule, under its overlap policy: it
+// returns ErrJobRunning when the job is busy and the policy is skip, and
+// queues the run otherwise. The run itself happens in the background.
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return fmt.Errorf("scheduler stopped")
+	}
+	for _, j := range s.jobs {
+		if j.Name == name {
+			if !s.fire(j, true) {
+				return ErrJobRunning
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrJobNotFound, name)
+}
+
+// startLoop must be called with mu held.
+func (s *Scheduler) startLoop(job ScheduledJob) {
+	schedule, _ := job.schedule() // validated by runnable
+	loop := &jobLoop{job: job, schedule: schedule, stop: make(chan struct{})}
+	now := time.Now()
+	switch {
+	case job.Cron == "" && s.leases != nil:
+		loop.next = now.Add(job.Delay).Truncate(job.Interval).Add(job.Interval)
+	case job.Cron == "" && job.Delay > 0:
+		loop.next = now.Add(job.Delay)
+	default:
+		loop.next = schedule(now.Add(job.Delay))
+	}
+	s.loops[job.Name] = loop
+	s.wg.Add(1)
+	go s.runJobLoop(loop)
+}
+
+func (s *Scheduler) runJobLoop(loop *jobLoop) {
 	defer s.wg.Done()
-	ticker := time.NewTicker(job.Interval)
-	defer ticker.Stop()
+	s.mu.Lock()
+	next := loop.next
+	s.mu.Unlock()
 
 	for {
+		wait := time.Until(next)
+		if loop.job.Jitter > 0 {
+			wait += rand.N(loop.job.Jitter)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-loop.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		select {
+		case <-loop.stop:
+			return // don't take a lease this instance won't use
+		default:
+		}
+		claimed := s.claim(loop, next)
+
+		s.mu.Lock()
 		select {
-		case <-s.done:
+		case <-loop.stop:
+			// Stopped or reconciled away while the timer fired.
+			s.mu.Unlock()
 			return
-		case <-ticker.C:
-			s.runJob(job)
+		default:
 		}
+		if claimed {
+			s.fire(loop.job, false)
+		}
+		// Firing times follow the schedule, not the (jittered) run; after a
+		// stall, pick up from now instead of replaying missed runs.
+		next = loop.schedule(next)
+		if now := time.Now(); next.Before(now) {
+			next = loop.schedule(now)
+		}
+		loop.next = next
+		s.mu.Unlock()
+	}
+}
+
+// A job lease lasts jobLeasePeriods of the job's period, and at least
+// minJobLeaseTTL: an instance held up past the next tick by a GC pause or
+// clock skew must still find the tick it missed taken, or it would run it a
+// second time.
+const (
+	jobLeasePeriods = 4
+	minJobLeaseTTL  = 5 * time.Minute
+)
+
+func jobLeaseTTL(period time.Duration) time.Duration {
+	return max(jobLeasePeriods*period, minJobLeaseTTL)
+}
+
+// claim takes the lease for the run of loop's job due at tick, recording who
+// holds it. Without leases every run is claimed. A lease store error skips
+// the run: missing one run is better than running it on every instance.
+func (s *Scheduler) claim(loop *jobLoop, tick time.Time) bool {
+	if s.leases == nil {
+		return true
+	}
+	name := loop.job.Name
+	key := fmt.Sprintf("jobs/%s/%d", name, tick.UnixMilli())
+	ttl := jobLeaseTTL(loop.schedule(tick).Sub(tick))
+
+	owner := s.nodeID
+	ok, err := s.leases.Acquire(key, s.nodeID, ttl)
+	if err == nil && !ok {
+		owner, err = s.leases.Holder(key)
+	}
+	if err != nil {
+		s.logger.Error("Scheduler: job lease unavailable; run skipped", map[string]interface{}{
+			"job":   name,
+			"error": err.Error(),
+		})
+		return false
+	}
+
+	s.mu.Lock()
+	state := s.state(name)
+	state.leaseOwner = owner
+	s.mu.Unlock()
+	if !ok {
+		s.logger.Debug("Scheduler: job run taken by another instance", map[string]interface{}{
+			"job":   name,
+			"owner": owner,
+		})
+	}
+	return ok
+}
+
+// state returns the run state of a job, creating it. Must be called with mu
+// held.
+func (s *Scheduler) state(name string) *jobState {
+	state, ok := s.states[name]
+	if !ok {
+		state = &jobState{}
+		s.states[name] = state
+	}
+	return state
+}
+
+// fire starts a run of job, or queues it per the overlap policy when the job
+// is already running. It reports whether the run was started or queued. Must
+// be called with mu held.
+func (s *Scheduler) fire(job ScheduledJob, manual bool) bool {
+	state := s.state(job.Name)
+	if state.running {
+		if job.Overlap == OverlapQueue && !state.queued {
+			state.queued = true
+			state.queuedManual = manual
+			return true
+		}
+		s.logger.Warn("Scheduler: job still running; run skipped", map[string]interface{}{
+			"job":    job.Name,
+			"manual": manual,
+		})
+		return false
+	}
+	state.running = true
+	s.wg.Add(1)
+	go s.execute(job, state, manual)
+	return true
+}
+
+// execute runs job, then any run queued behind it, recording each in the
+// job's history.
+func (s *Scheduler) execute(job ScheduledJob, state *jobState, manual bool) {
+	defer s.wg.Done()
+	for {
+		run := s.runJob(job)
+		run.Manual = manual
+
+		s.mu.Lock()
+		state.history = append(state.history, run)
+		if over := len(state.history) - jobHistorySize; over > 0 {
+			state.history = append([]JobRun(nil), state.history[over:]...)
+		}
+		if state.queued && !s.stopped {
+			state.queued = false
+			manual = state.queuedManual
+			s.mu.Unlock()
+			continue
+		}
+		state.running = false
+		state.queued = false
+		s.mu.Unlock()
+		return
 	}
 }
 
 // runJob executes a single job's script, recovering from panics so one bad job
 // can never take down the scheduler goroutine.
-func (s *Scheduler) runJob(job ScheduledJob) {
+func (s *Scheduler) runJob(job ScheduledJob) (run JobRun) {
+	run = JobRun{Job: job.Name, Started: time.Now()}
 	defer func() {
 		if r := recover(); r != nil {
+			run.Err = fmt.Sprintf("panic: %v", r)
 			s.logger.Error("Scheduler: job panicked", map[string]interface{}{
 				"job":   job.Name,
 				"panic": r,
 			})
 		}
+		run.Duration = time.Since(run.Started)
 	}()
 
 	_, err := s.engine.Execute(&ports.ScriptMessage{
 		Subject:  "jobs/" + job.Name,
-		SenderID: jobSenderID,
+		SenderID: jobSenderPrefix + job.Name,
 		Content:  lingo.NewLVoid(),
+		System:   true,
 	})
 	if err != nil {
+		run.Err = err.Error()
 		s.logger.Error("Scheduler: job failed", map[string]interface{}{
 			"job":   job.Name,
 			"error": err.Error(),
 		})
 	}
+	return run
 }
 
-// Stop signals every job goroutine to exit and waits for them to finish.
+// Stop signals every job goroutine to exit and waits for them and any runs in
+// progress to finish. Queued runs are dropped.
 func (s *Scheduler) Stop() {
 	s.stopOnce.Do(func() {
-		close(s.done)
+		s.mu.Lock()
+		s.stopped = true
+		for name, loop := range s.loops {
+			close(loop.stop)
+			delete(s.loops, name)
+		}
+		s.mu.Unlock()
 		s.wg.Wait()
 		s.logger.Info("Scheduler stopped")
 	})
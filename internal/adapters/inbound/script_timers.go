@@ -0,0 +1,207 @@
+package inbound
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+
+	"github.com/google/uuid"
+)
+
+// MaxScriptTimerDelay bounds how far ahead mus.timer.after can schedule.
+const MaxScriptTimerDelay = 30 * 24 * time.Hour
+
+// scriptTimerRetryDelay is how long a timer that found the script queue full
+// waits before firing again.
+const scriptTimerRetryDelay = time.Second
+
+// ScriptTimerService runs script subjects once after a delay
+// (ports.ScriptTimers). Every timer is stored in the DB before it is armed and
+// deleted when it fires, so timers pending at shutdown are re-armed by Start
+// on the next boot — overdue ones fire at once. Deleting the row claims the
+// run: a timer cancelled meanwhile, or already fired by another instance
+// sharing the DB, is skipped. A run the script queue has no room for is
+// stored and armed again scriptTimerRetryDelay later. Fired runs are
+// server-initiated (ScriptMessage.System) with the setter as sender; their
+// replies are dropped, as nobody is waiting for them.
+type ScriptTimerService struct {
+	engine   ports.ScriptEngine
+	db       ports.DBAdapter
+	logger   ports.Logger
+	mu       sync.Mutex
+	timers   map[string]*time.Timer // armed timers by ID
+	stopped  bool
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+}
+
+func NewScriptTimerService(engine ports.ScriptEngine, db ports.DBAdapter, logger ports.Logger) *ScriptTimerService {
+	return &ScriptTimerService{
+		engine: engine,
+		db:     db,
+		logger: logger,
+		timers: make(map[string]*time.Timer),
+	}
+}
+
+// Start arms the timers stored in the DB.
+func (s *ScriptTimerService) Start() error {
+	pending, err := s.db.ListScriptTimers()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range pending {
+		s.arm(t)
+	}
+	s.logger.Info("Script timers started", map[string]interface{}{
+		"pending": len(pending),
+	})
+	return nil
+}
+
+func (s *ScriptTimerService) After(delay time.Duration, subject, senderID string, content lingo.LValue) (string, error) {
+	if delay <= 0 || delay > MaxScriptTimerDelay {
+		return "", fmt.Errorf("delay %s out of range (0, %s]", delay, MaxScriptTimerDelay)
+	}
+	if !s.engine.HasScript(subject) {
+		return "", fmt.Errorf("no script for subject %q", subject)
+	}
+	if content == nil {
+		content = lingo.NewLVoid()
+	}
+	t := ports.ScriptTimer{
+		ID:       uuid.New().String(),
+		Subject:  subject,
+		SenderID: senderID,
+		Content:  content,
+		FireAt:   time.Now().Add(delay),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return "", errors.New("script timers stopped")
+	}
+	if err := s.db.CreateScriptTimer(t); err != nil {
+		return "", err
+	}
+	s.arm(t)
+	return t.ID, nil
+}
+
+func (s *ScriptTimerService) Cancel(id, senderID string) error {
+	// The row goes first: deleting it checks the sender, and cancels a timer
+	// another instance armed, which then finds nothing to claim.
+	if err := s.db.DeleteScriptTimer(id, senderID); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if timer, ok := s.timers[id]; ok {
+		timer.Stop()
+		delete(s.timers, id)
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// arm schedules t unless it already is. Callers hold s.mu.
+func (s *ScriptTimerService) arm(t ports.ScriptTimer) {
+	if _, ok := s.timers[t.ID]; ok {
+		return
+	}
+	s.timers[t.ID] = time.AfterFunc(time.Until(t.FireAt), func() { s.fire(t) })
+}
+
+func (s *ScriptTimerService) fire(t ports.ScriptTimer) {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.timers, t.ID)
+	s.wg.Add(1)
+	s.mu.Unlock()
+	defer s.wg.Done()
+
+	if err := s.db.DeleteScriptTimer(t.ID, ""); err != nil {
+		if !errors.Is(err, ports.ErrTimerNotFound) {
+			// Left in the DB, so the next Start retries it.
+			s.logger.Error("Script timer: failed to claim timer", map[string]interface{}{
+				"id":    t.ID,
+				"error": err.Error(),
+			})
+		}
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("Script timer panicked", map[string]interface{}{
+				"id":      t.ID,
+				"subject": t.Subject,
+				"panic":   r,
+			})
+		}
+	}()
+	_, err := s.engine.Execute(&ports.ScriptMessage{
+		Subject:  t.Subject,
+		SenderID: t.SenderID,
+		Content:  t.Content,
+		System:   true,
+	})
+	if errors.Is(err, ports.ErrScriptQueueFull) {
+		s.retry(t)
+		return
+	}
+	if err != nil {
+		s.logger.Error("Script timer failed", map[string]interface{}{
+			"id":      t.ID,
+			"subject": t.Subject,
+			"error":   err.Error(),
+		})
+	}
+}
+
+// retry stores t again to fire scriptTimerRetryDelay from now.
+func (s *ScriptTimerService) retry(t ports.ScriptTimer) {
+	t.FireAt = time.Now().Add(scriptTimerRetryDelay)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.db.CreateScriptTimer(t); err != nil {
+		s.logger.Error("Script timer: queue full and the timer could not be stored again; dropped", map[string]interface{}{
+			"id":      t.ID,
+			"subject": t.Subject,
+			"error":   err.Error(),
+		})
+		return
+	}
+	s.logger.Warn("Script timer: script queue full; retrying", map[string]interface{}{
+		"id":      t.ID,
+		"subject": t.Subject,
+	})
+	if !s.stopped {
+		s.arm(t)
+	}
+}
+
+// Stop disarms every timer and waits for runs in progress. Pending timers
+// stay in the DB for the next Start.
+func (s *ScriptTimerService) Stop() {
+	s.stopOnce.Do(func() {
+		s.mu.Lock()
+		s.stopped = true
+		for id, timer := range s.timers {
+			timer.Stop()
+			delete(s.timers, id)
+		}
+		s.mu.Unlock()
+		s.wg.Wait()
+		s.logger.Info("Script timers stopped")
+	})
+}
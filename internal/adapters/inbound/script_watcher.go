@@ -0,0 +1,189 @@
+package inbound
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fsos-server/internal/domain/ports"
+)
+
+// fileStamp is what the watcher compares between polls.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// ScriptWatcher polls SCRIPTS_PATH for changed .lua files so scripts and jobs
+// reload without a restart. Each changed file is syntax-checked through the
+// engine's ScriptReloader first; one that fails keeps its last good version,
+// and a broken job file keeps its previous schedule too. When anything under
+// jobs/ changed, jobs are rediscovered and the Scheduler reconciled. Modeled
+// on Scheduler: one goroutine, a done channel and a once-guarded Stop.
+type ScriptWatcher struct {
+	scriptsDir string
+	interval   time.Duration
+	reloader   ports.ScriptReloader
+	scheduler  *Scheduler // nil when jobs are disabled
+	logger     ports.Logger
+
+	files  map[string]fileStamp
+	broken map[string]bool
+	jobs   map[string]ScheduledJob // last applied job set
+
+	done     chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+}
+
+// NewScriptWatcher records the current state of scriptsDir as the baseline;
+// only later changes trigger reloads. jobs is the set the scheduler was
+// started with.
+func NewScriptWatcher(scriptsDir string, interval time.Duration, reloader ports.ScriptReloader, scheduler *Scheduler, jobs []ScheduledJob, logger ports.Logger) *ScriptWatcher {
+	w := &ScriptWatcher{
+		scriptsDir: scriptsDir,
+		interval:   interval,
+		reloader:   reloader,
+		scheduler:  scheduler,
+		logger:     logger,
+		files:      scanScripts(scriptsDir),
+		broken:     make(map[string]bool),
+		jobs:       make(map[string]ScheduledJob),
+		done:       make(chan struct{}),
+	}
+	for _, j := range jobs {
+		w.jobs[j.Name] = j
+	}
+	return w
+}
+
+func (w *ScriptWatcher) Start() {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-ticker.C:
+				w.Poll()
+			}
+		}
+	}()
+	w.logger.Info("Script watcher started", map[string]interface{}{
+		"dir":      w.scriptsDir,
+		"interval": w.interval.String(),
+		"scripts":  len(w.files),
+	})
+}
+
+func (w *ScriptWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.done)
+		w.wg.Wait()
+	})
+}
+
+// Poll compares scriptsDir with the previous poll and applies the changes.
+// Start calls it on every tick; it is not safe to call concurrently.
+func (w *ScriptWatcher) Poll() {
+	current := scanScripts(w.scriptsDir)
+	jobsChanged := false
+
+	for path := range w.files {
+		if _, ok := current[path]; ok {
+			continue
+		}
+		if w.reloader != nil {
+			w.reloader.Forget(path)
+		}
+		delete(w.broken, path)
+		jobsChanged = jobsChanged || w.isJobFile(path)
+		w.logger.Info("Script removed", map[string]interface{}{
+			"path": path,
+		})
+	}
+
+	for path, stamp := range current {
+		if prev, ok := w.files[path]; ok && prev.modTime.Equal(stamp.modTime) && prev.size == stamp.size {
+			continue
+		}
+		jobsChanged = jobsChanged || w.isJobFile(path)
+		if w.reloader == nil {
+			continue
+		}
+		if err := w.reloader.Reload(path); err != nil {
+			w.broken[path] = true
+			w.logger.Error("Script reload failed; keeping last good version", map[string]interface{}{
+				"path":  path,
+				"error": err.Error(),
+			})
+			continue
+		}
+		delete(w.broken, path)
+		w.logger.Info("Script reloaded", map[string]interface{}{
+			"path": path,
+		})
+	}
+
+	w.files = current
+	if jobsChanged && w.scheduler != nil {
+		w.reschedule()
+	}
+}
+
+// reschedule rediscovers jobs and hands them to the scheduler. A job whose
+// file does not compile keeps its previous definition, or stays off if it
+// never had one.
+func (w *ScriptWatcher) reschedule() {
+	discovered := make(map[string]ScheduledJob)
+	for _, j := range DiscoverJobs(w.scriptsDir, w.logger) {
+		discovered[j.Name] = j
+	}
+	for path := range w.broken {
+		if !w.isJobFile(path) {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".lua")
+		if prev, ok := w.jobs[name]; ok {
+			discovered[name] = prev
+		} else {
+			delete(discovered, name)
+		}
+	}
+
+	jobs := make([]ScheduledJob, 0, len(discovered))
+	for _, j := range discovered {
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	w.jobs = discovered
+	w.scheduler.Reconcile(jobs)
+}
+
+func (w *ScriptWatcher) isJobFile(path string) bool {
+	return filepath.Dir(path) == filepath.Join(w.scriptsDir, "jobs")
+}
+
+// scanScripts stamps every .lua file under dir, keyed by the same joined path
+// the script engine resolves subjects to.
+func scanScripts(dir string) map[string]fileStamp {
+	files := make(map[string]fileStamp)
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".lua" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return files
+}
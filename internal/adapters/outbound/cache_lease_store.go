@@ -0,0 +1,33 @@
+package outbound
+
+import (
+	"time"
+
+	"fsos-server/internal/domain/ports"
+)
+
+// leaseKeyPrefix keeps leases apart from script cache keys.
+const leaseKeyPrefix = "lease:"
+
+// CacheLeaseStore implements ports.LeaseStore on top of the shared cache. It is
+// cluster-wide only when the cache is (CACHE_TYPE=redis); with the memory
+// cache every instance holds its own leases.
+type CacheLeaseStore struct {
+	cache ports.Cache
+}
+
+func NewCacheLeaseStore(cache ports.Cache) *CacheLeaseStore {
+	return &CacheLeaseStore{cache: cache}
+}
+
+func (s *CacheLeaseStore) Acquire(key, owner string, ttl time.Duration) (bool, error) {
+	return s.cache.SetNX(leaseKeyPrefix+key, []byte(owner), ttl)
+}
+
+func (s *CacheLeaseStore) Holder(key string) (string, error) {
+	owner, err := s.cache.Get(leaseKeyPrefix + key)
+	if err != nil {
+		return "", err
+	}
+	return string(owner), nil
+}
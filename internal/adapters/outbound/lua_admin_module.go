@@ -0,0 +1,227 @@
+package outbound
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// userLevelAttribute is the session attribute LogonService stamps the user
+// level into (services.UserLevelAttribute), and movieIDAttribute the one the
+// logon handler stamps the movie into.
+const (
+	userLevelAttribute = "#userLevel"
+	movieIDAttribute   = "#movieID"
+)
+
+var errNoKillTimers = errors.New("kill timers are not available")
+
+// adminDeps is what mus.admin acts through. timers and db may be nil; the
+// actions needing them then fail.
+type adminDeps struct {
+	sessions ports.SessionStore
+	conns    ports.ConnectionWriter
+	timers   ports.TimerManager
+	db       ports.DBAdapter
+	logger   ports.Logger
+}
+
+// registerAdminModule builds mus.admin for moderation flows. Like mus.db it
+// trusts the script: deciding who may moderate (e.g. by reading #userLevel
+// through mus.session) is up to the script. Every action is audit-logged with
+// the invoking script and sender; call returns the running execution.
+func registerAdminModule(L *lua.LState, musMod *lua.LTable, deps *adminDeps, call func() *ports.ScriptMessage) {
+	adminMod := L.NewTable()
+
+	audit := func(action, target string, details map[string]interface{}, err error) {
+		if deps.logger == nil {
+			return
+		}
+		msg := call()
+		fields := map[string]interface{}{
+			"action": action,
+			"target": target,
+			"script": msg.Subject,
+			"sender": msg.SenderID,
+		}
+		for k, v := range details {
+			fields[k] = v
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+			deps.logger.Warn("Moderation action failed", fields)
+			return
+		}
+		deps.logger.Info("Moderation action", fields)
+	}
+
+	// mus.admin.kick(userID, [reason]) -> true | false, err
+	adminMod.RawSetString("kick", L.NewFunction(func(L *lua.LState) int {
+		userID := L.CheckString(1)
+		reason := L.OptString(2, "")
+		err := deps.kick(userID)
+		audit("kick", userID, map[string]interface{}{"reason": reason}, err)
+		return pushResult(L, err)
+	}))
+
+	// mus.admin.setKillTimer(userID, minutes) -> true | false, err. The user
+	// is disconnected when the timer fires; setting it again restarts it.
+	adminMod.RawSetString("setKillTimer", L.NewFunction(func(L *lua.LState) int {
+		userID := L.CheckString(1)
+		minutes := L.CheckInt(2)
+		if minutes <= 0 {
+			L.ArgError(2, "minutes must be positive")
+		}
+		var err error
+		if deps.timers == nil {
+			err = errNoKillTimers
+		} else {
+			deps.timers.SetUserKillTimer(userID, minutes)
+		}
+		audit("setKillTimer", userID, map[string]interface{}{"minutes": minutes}, err)
+		return pushResult(L, err)
+	}))
+
+	// mus.admin.cancelKillTimer(userID) -> true | false, err
+	adminMod.RawSetString("cancelKillTimer", L.NewFunction(func(L *lua.LState) int {
+		userID := L.CheckString(1)
+		var err error
+		if deps.timers == nil {
+			err = errNoKillTimers
+		} else {
+			deps.timers.CancelUserKillTimer(userID)
+		}
+		audit("cancelKillTimer", userID, nil, err)
+		return pushResult(L, err)
+	}))
+
+	// mus.admin.ban(userID, reason, [durationSeconds]) -> true | false, err.
+	// Without a duration the ban is permanent. A live session is kicked.
+	adminMod.RawSetString("ban", L.NewFunction(func(L *lua.LState) int {
+		userID := L.CheckString(1)
+		reason := L.CheckString(2)
+		expiresAt := banExpiry(L, 3)
+		err := deps.banUser(userID, reason, expiresAt)
+		audit("ban", userID, map[string]interface{}{"reason": reason, "expiresAt": expiryField(expiresAt)}, err)
+		return pushResult(L, err)
+	}))
+
+	// mus.admin.banIP(ip, reason, [durationSeconds]) -> true | false, err.
+	// Every session connected from ip is kicked.
+	adminMod.RawSetString("banIP", L.NewFunction(func(L *lua.LState) int {
+		ip := L.CheckString(1)
+		reason := L.CheckString(2)
+		expiresAt := banExpiry(L, 3)
+		kicked, err := deps.banIP(ip, reason, expiresAt)
+		audit("banIP", ip, map[string]interface{}{"reason": reason, "expiresAt": expiryField(expiresAt), "kicked": kicked}, err)
+		return pushResult(L, err)
+	}))
+
+	// mus.admin.setUserLevel(userID, level) -> true | false, err. A live
+	// session picks up the new level at once.
+	adminMod.RawSetString("setUserLevel", L.NewFunction(func(L *lua.LState) int {
+		userID := L.CheckString(1)
+		level := L.CheckInt(2)
+		err := deps.setUserLevel(userID, level)
+		audit("setUserLevel", userID, map[string]interface{}{"userLevel": level}, err)
+		return pushResult(L, err)
+	}))
+
+	musMod.RawSetString("admin", adminMod)
+}
+
+// kick drops userID's session state and closes the connection, as a fired
+// user kill timer does.
+func (d *adminDeps) kick(userID string) error {
+	online, err := d.sessions.IsConnected(userID)
+	if err != nil {
+		return err
+	}
+	if !online {
+		return fmt.Errorf("user %q is not online", userID)
+	}
+	d.sessions.LeaveAllRooms(userID)
+	d.sessions.UnregisterConnection(userID)
+	return d.conns.DisconnectClient(userID)
+}
+
+func (d *adminDeps) banUser(userID, reason string, expiresAt *time.Time) error {
+	if d.db == nil {
+		return errors.New("no database configured")
+	}
+	user, err := d.db.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	if err := d.db.CreateBan(&user.ID, nil, reason, expiresAt); err != nil {
+		return err
+	}
+	if online, err := d.sessions.IsConnected(userID); err == nil && online {
+		return d.kick(userID)
+	}
+	return nil
+}
+
+// banIP bans ip and returns the sessions it kicked.
+func (d *adminDeps) banIP(ip, reason string, expiresAt *time.Time) ([]string, error) {
+	if d.db == nil {
+		return nil, errors.New("no database configured")
+	}
+	if err := d.db.CreateBan(nil, &ip, reason, expiresAt); err != nil {
+		return nil, err
+	}
+	conns, err := d.sessions.GetAllConnections()
+	if err != nil {
+		return nil, err
+	}
+	var kicked []string
+	for _, c := range conns {
+		if c.IP != ip {
+			continue
+		}
+		if err := d.kick(c.ClientID); err != nil {
+			return kicked, err
+		}
+		kicked = append(kicked, c.ClientID)
+	}
+	return kicked, nil
+}
+
+func (d *adminDeps) setUserLevel(userID string, level int) error {
+	if d.db == nil {
+		return errors.New("no database configured")
+	}
+	if err := d.db.UpdateUserLevel(userID, level); err != nil {
+		return err
+	}
+	if online, err := d.sessions.IsConnected(userID); err == nil && online {
+		return d.sessions.SetUserAttribute(userID, userLevelAttribute, lingo.NewLInteger(int32(level)))
+	}
+	return nil
+}
+
+// banExpiry reads the optional ban duration in seconds at idx; nil means a
+// permanent ban.
+func banExpiry(L *lua.LState, idx int) *time.Time {
+	secs := L.OptInt(idx, 0)
+	if secs < 0 {
+		L.ArgError(idx, "duration must be positive")
+	}
+	if secs == 0 {
+		return nil
+	}
+	t := time.Now().Add(time.Duration(secs) * time.Second)
+	return &t
+}
+
+func expiryField(t *time.Time) interface{} {
+	if t == nil {
+		return "never"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
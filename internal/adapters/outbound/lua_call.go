@@ -0,0 +1,93 @@
+package outbound
+
+import (
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// maxScriptCallDepth bounds mus.call nesting, so scripts calling each other in
+// a loop fail at once instead of running out the clock.
+const maxScriptCallDepth = 8
+
+// inline runs fn through the bound inliner, so a script fn runs for senderID
+// does not wait behind the script calling it. Without one fn runs as is.
+func (b luaBindings) inline(senderID string, fn func()) {
+	if b.inliner == nil {
+		fn()
+		return
+	}
+	b.inliner.Inline(senderID, fn)
+}
+
+// registerCallFunctions adds mus.call, and mus.system once System commands are
+// bound. call returns the running execution.
+func registerCallFunctions(L *lua.LState, musMod *lua.LTable, e *LuaScriptEngine, bound luaBindings, call func() *luaCall) {
+	// mus.call(subject, [content]) -> content, errCode runs another script for
+	// the same sender, on its own VM but within the caller's deadline. Replies
+	// it queues with mus.reply are delivered with the caller's; a failure
+	// raises in the caller. Unless the server started the run, the call guard
+	// first checks the sender may run subject at all, so a script cannot lend
+	// its callers a higher level, a way around a rate or a hook.
+	musMod.RawSetString("call", L.NewFunction(func(L *lua.LState) int {
+		subject := L.CheckString(1)
+		content := lingo.LuaToLValue(L.Get(2))
+		current := call()
+		if current.depth >= maxScriptCallDepth {
+			L.RaiseError("mus.call(%q): nested more than %d deep", subject, maxScriptCallDepth)
+			return 0
+		}
+		if bound.callGuard != nil && !current.msg.System {
+			if err := bound.callGuard.CheckScriptCall(current.msg.SenderID, subject); err != nil {
+				L.RaiseError("mus.call(%q): %s", subject, err.Error())
+				return 0
+			}
+		}
+		result, err := e.run(&ports.ScriptMessage{
+			Subject:  subject,
+			SenderID: current.msg.SenderID,
+			Content:  content,
+			System:   current.msg.System,
+		}, scriptContext(L), current.depth+1, current.meter)
+		if err != nil {
+			L.RaiseError("mus.call(%q): %s", subject, luaErrorMessage(err))
+			return 0
+		}
+		if len(result.Replies) > 0 {
+			own := current.ensureResult()
+			own.Replies = append(own.Replies, result.Replies...)
+		}
+		L.Push(lingo.LValueToLua(L, result.Content))
+		L.Push(lua.LNumber(result.ErrCode))
+		return 2
+	}))
+
+	if bound.system == nil {
+		return
+	}
+
+	// mus.system(subject, [content]) -> errCode, content runs a System command
+	// (system.group.join, DBPlayer.getAttribute, ...) as the sender, with the
+	// sender's permissions; the answer comes back instead of going out.
+	musMod.RawSetString("system", L.NewFunction(func(L *lua.LState) int {
+		subject := L.CheckString(1)
+		content := lingo.LuaToLValue(L.Get(2))
+		senderID := call().msg.SenderID
+		var (
+			errCode int32
+			answer  lingo.LValue
+			err     error
+		)
+		bound.inline(senderID, func() {
+			errCode, answer, err = bound.system.RunSystemCommand(senderID, subject, content)
+		})
+		if err != nil {
+			L.RaiseError("mus.system(%q): %s", subject, err.Error())
+			return 0
+		}
+		L.Push(lua.LNumber(errCode))
+		L.Push(lingo.LValueToLua(L, answer))
+		return 2
+	}))
+}
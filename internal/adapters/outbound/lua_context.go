@@ -0,0 +1,61 @@
+package outbound
+
+import (
+	"net"
+
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Transports reported by mus.getContext().
+const (
+	transportTCP = "tcp"
+	transportUDP = "udp"
+)
+
+// callerContext builds the mus.getContext() table for the sender of msg:
+//
+//	{userID, system, userLevel, movieID, ip, connectedAt, groups, transport}
+//
+// A system run (msg.System) only has userID and system = true. Otherwise the
+// level and groups come from movies when one is bound, and from the session
+// attributes LogonService stamps when not. A sender with a live connection
+// came in over TCP; one without, named by its address, sent a UDP datagram.
+func (e *LuaScriptEngine) callerContext(L *lua.LState, msg *ports.ScriptMessage, movies ports.MovieDirectory) *lua.LTable {
+	ctx := L.NewTable()
+	ctx.RawSetString("userID", lua.LString(msg.SenderID))
+	ctx.RawSetString("system", lua.LBool(msg.System))
+	if msg.System || e.sessionStore == nil {
+		return ctx
+	}
+	userID := msg.SenderID
+
+	var level int
+	var groups []string
+	if movies != nil {
+		level = movies.UserLevel(userID)
+		groups, _ = movies.UserGroups(userID)
+	} else if val, err := e.sessionStore.GetUserAttribute(userID, userLevelAttribute); err == nil && val != nil {
+		level = int(val.ToInteger())
+	}
+	ctx.RawSetString("userLevel", lua.LNumber(level))
+	ctx.RawSetString("groups", stringList(L, groups))
+
+	if val, err := e.sessionStore.GetUserAttribute(userID, movieIDAttribute); err == nil {
+		if movieID, ok := val.(*lingo.LString); ok && movieID.Value != "" {
+			ctx.RawSetString("movieID", lua.LString(movieID.Value))
+		}
+	}
+
+	if conn, err := e.sessionStore.GetConnection(userID); err == nil && conn != nil {
+		ctx.RawSetString("ip", lua.LString(conn.IP))
+		ctx.RawSetString("connectedAt", lua.LNumber(conn.ConnectedAt.Unix()))
+		ctx.RawSetString("transport", lua.LString(transportTCP))
+	} else if host, _, err := net.SplitHostPort(userID); err == nil {
+		ctx.RawSetString("ip", lua.LString(host))
+		ctx.RawSetString("transport", lua.LString(transportUDP))
+	}
+	return ctx
+}
@@ -0,0 +1,84 @@
+package outbound
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// maxRandomBytes bounds mus.crypto.randomBytes and token.
+const maxRandomBytes = 1024
+
+var hmacHashes = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// registerCryptoModule builds mus.crypto:
+//
+//	sha256(data)                       -> hex digest
+//	hmac(key, data, [alg])             -> hex HMAC, alg "sha256" (default) or "sha512"
+//	randomBytes(n)                     -> n bytes from crypto/rand, as a Lua string
+//	token([n])                         -> n random bytes (default 32), URL-safe base64
+//	equal(a, b)                        -> constant-time comparison, for signatures
+func registerCryptoModule(L *lua.LState, musMod *lua.LTable) {
+	cryptoMod := L.NewTable()
+
+	cryptoMod.RawSetString("sha256", L.NewFunction(func(L *lua.LState) int {
+		sum := sha256.Sum256([]byte(L.CheckString(1)))
+		L.Push(lua.LString(hex.EncodeToString(sum[:])))
+		return 1
+	}))
+
+	cryptoMod.RawSetString("hmac", L.NewFunction(func(L *lua.LState) int {
+		key := L.CheckString(1)
+		data := L.CheckString(2)
+		newHash, ok := hmacHashes[L.OptString(3, "sha256")]
+		if !ok {
+			L.ArgError(3, "want sha256 or sha512")
+			return 0
+		}
+		mac := hmac.New(newHash, []byte(key))
+		mac.Write([]byte(data))
+		L.Push(lua.LString(hex.EncodeToString(mac.Sum(nil))))
+		return 1
+	}))
+
+	random := func(L *lua.LState, n int) []byte {
+		if n < 1 || n > maxRandomBytes {
+			L.ArgError(1, "byte count must be 1-1024")
+			return nil
+		}
+		b := make([]byte, n)
+		if _, err := rand.Read(b); err != nil {
+			L.RaiseError("crypto: %s", err.Error())
+			return nil
+		}
+		return b
+	}
+
+	cryptoMod.RawSetString("randomBytes", L.NewFunction(func(L *lua.LState) int {
+		L.Push(lua.LString(string(random(L, L.CheckInt(1)))))
+		return 1
+	}))
+
+	cryptoMod.RawSetString("token", L.NewFunction(func(L *lua.LState) int {
+		L.Push(lua.LString(base64.RawURLEncoding.EncodeToString(random(L, L.OptInt(1, 32)))))
+		return 1
+	}))
+
+	cryptoMod.RawSetString("equal", L.NewFunction(func(L *lua.LState) int {
+		a, b := L.CheckString(1), L.CheckString(2)
+		L.Push(lua.LBool(subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1))
+		return 1
+	}))
+
+	musMod.RawSetString("crypto", cryptoMod)
+}
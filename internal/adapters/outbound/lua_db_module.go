@@ -1,6 +1,9 @@
 package outbound
 
 import (
+	"errors"
+	"sync"
+
 	"fsos-server/internal/domain/ports"
 	"fsos-server/internal/domain/types/lingo"
 
@@ -10,6 +13,13 @@ import (
 
 const luaQueryTypeName = "query"
 
+// unknownUserHash is what mus.db.checkPassword compares against for a user id
+// that does not exist, at the cost of a real one. No password matches it.
+var unknownUserHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("no such user"), bcrypt.DefaultCost)
+	return hash
+})
+
 func registerDBModule(L *lua.LState, musMod *lua.LTable, db ports.DBAdapter, qb ports.QueryBuilder, logger ports.Logger) {
 	dbMod := L.NewTable()
 
@@ -89,6 +99,7 @@ func registerDBModule(L *lua.LState, musMod *lua.LTable, db ports.DBAdapter, qb
 
 	if db != nil {
 		registerDBPlayerOps(L, dbMod, db)
+		registerDBUserOps(L, dbMod, db)
 		registerDBApplicationOps(L, dbMod, db)
 		registerDBAdminOps(L, dbMod, db, logger)
 	}
@@ -337,6 +348,53 @@ func registerDBPlayerOps(L *lua.LState, dbMod *lua.LTable, db ports.DBAdapter) {
 	}))
 }
 
+// --- DBUser standard ops ---
+
+func registerDBUserOps(L *lua.LState, dbMod *lua.LTable, db ports.DBAdapter) {
+	dbMod.RawSetString("getUserAttribute", L.NewFunction(func(L *lua.LState) int {
+		userID := L.CheckString(1)
+		attr := L.CheckString(2)
+		val, err := db.GetUserAttribute(userID, attr)
+		if err != nil {
+			L.Push(lua.LNil)
+			return 1
+		}
+		L.Push(lingo.LValueToLua(L, val))
+		return 1
+	}))
+	dbMod.RawSetString("setUserAttribute", L.NewFunction(func(L *lua.LState) int {
+		userID := L.CheckString(1)
+		attr := L.CheckString(2)
+		value := lingo.LuaToLValue(L.Get(3))
+		if err := db.SetUserAttribute(userID, attr, value); err != nil {
+			L.RaiseError("setUserAttribute failed: %s", err.Error())
+		}
+		return 0
+	}))
+	dbMod.RawSetString("deleteUserAttribute", L.NewFunction(func(L *lua.LState) int {
+		userID := L.CheckString(1)
+		attr := L.CheckString(2)
+		if err := db.DeleteUserAttribute(userID, attr); err != nil {
+			L.RaiseError("deleteUserAttribute failed: %s", err.Error())
+		}
+		return 0
+	}))
+	dbMod.RawSetString("getUserAttributeNames", L.NewFunction(func(L *lua.LState) int {
+		userID := L.CheckString(1)
+		names, err := db.GetUserAttributeNames(userID)
+		if err != nil {
+			L.Push(L.NewTable())
+			return 1
+		}
+		tbl := L.NewTable()
+		for _, n := range names {
+			tbl.Append(lua.LString(n))
+		}
+		L.Push(tbl)
+		return 1
+	}))
+}
+
 // --- DBApplication standard ops ---
 
 func registerDBApplicationOps(L *lua.LState, dbMod *lua.LTable, db ports.DBAdapter) {
@@ -428,6 +486,25 @@ func registerDBAdminOps(L *lua.LState, dbMod *lua.LTable, db ports.DBAdapter, lo
 		}
 		return 0
 	}))
+	// checkPassword(userID, password) -> bool compares like
+	// LogonService.validateCredentials; an unknown user is false, after the
+	// same bcrypt work, so timing does not tell which user ids exist.
+	dbMod.RawSetString("checkPassword", L.NewFunction(func(L *lua.LState) int {
+		userID := L.CheckString(1)
+		password := L.CheckString(2)
+		user, err := db.GetUser(userID)
+		if err != nil {
+			if errors.Is(err, ports.ErrUserNotFound) {
+				bcrypt.CompareHashAndPassword(unknownUserHash(), []byte(password))
+				L.Push(lua.LFalse)
+				return 1
+			}
+			L.RaiseError("checkPassword failed: %s", err.Error())
+			return 0
+		}
+		L.Push(lua.LBool(bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) == nil))
+		return 1
+	}))
 	dbMod.RawSetString("deleteUser", L.NewFunction(func(L *lua.LState) int {
 		userID := L.CheckString(1)
 		if err := db.DeleteUser(userID); err != nil {
@@ -0,0 +1,203 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// maxHTTPRedirects bounds the redirects mus.http.request follows; each one
+// must stay on an allowed host.
+const maxHTTPRedirects = 5
+
+// ScriptHTTPPolicy governs mus.http: the hosts scripts may call, the largest
+// response body they may read and how many requests one script may have in
+// flight across its concurrent runs.
+type ScriptHTTPPolicy struct {
+	AllowedHosts     []string // host names; ".example.com" also allows subdomains
+	MaxResponseBytes int64
+	MaxConcurrent    int
+}
+
+// scriptHTTP is the client behind mus.http, shared by every VM.
+type scriptHTTP struct {
+	policy   ScriptHTTPPolicy
+	hosts    []string // lower-cased AllowedHosts
+	client   *http.Client
+	mu       sync.Mutex
+	inFlight map[string]int // requests in flight by script path
+}
+
+func newScriptHTTP(policy ScriptHTTPPolicy) *scriptHTTP {
+	h := &scriptHTTP{policy: policy, inFlight: make(map[string]int)}
+	for _, host := range policy.AllowedHosts {
+		h.hosts = append(h.hosts, strings.ToLower(host))
+	}
+	h.client = &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxHTTPRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxHTTPRedirects)
+			}
+			if !h.allowed(req.URL) {
+				return fmt.Errorf("redirect to %s: host not allowed", req.URL.Hostname())
+			}
+			return nil
+		},
+	}
+	return h
+}
+
+// allowed reports whether u is an http(s) URL on an allowed host.
+func (h *scriptHTTP) allowed(u *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return false
+	}
+	for _, allowed := range h.hosts {
+		if host == allowed || strings.HasPrefix(allowed, ".") && (host == allowed[1:] || strings.HasSuffix(host, allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// acquire takes one of script's request slots, if it has one free.
+func (h *scriptHTTP) acquire(script string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.inFlight[script] >= h.policy.MaxConcurrent {
+		return false
+	}
+	h.inFlight[script]++
+	return true
+}
+
+func (h *scriptHTTP) release(script string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.inFlight[script]--; h.inFlight[script] <= 0 {
+		delete(h.inFlight, script)
+	}
+}
+
+// do sends req and reads at most MaxResponseBytes of the answer.
+func (h *scriptHTTP) do(req *http.Request) (*http.Response, []byte, error) {
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, h.policy.MaxResponseBytes+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if int64(len(body)) > h.policy.MaxResponseBytes {
+		return nil, nil, fmt.Errorf("response larger than %d bytes", h.policy.MaxResponseBytes)
+	}
+	return resp, body, nil
+}
+
+// registerHTTPModule builds mus.http:
+//
+//	mus.http.request{method, url, headers, body, timeout} -> response | nil, err
+//
+// method defaults to GET, headers is a table of names to values and timeout
+// is in seconds; the request never outlives the script's own deadline. The
+// response is {status, headers, body}, its header names lower-cased.
+// Disallowed hosts, network failures, timeouts and oversized responses
+// return nil and an error; an error status is still a response.
+func registerHTTPModule(L *lua.LState, musMod *lua.LTable, h *scriptHTTP, call func() *luaCall) {
+	httpMod := L.NewTable()
+
+	httpMod.RawSetString("request", L.NewFunction(func(L *lua.LState) int {
+		opts := L.CheckTable(1)
+		method := strings.ToUpper(luaOptString(L, opts, "method", "GET"))
+		rawURL := luaOptString(L, opts, "url", "")
+		if rawURL == "" {
+			L.ArgError(1, "url is required")
+			return 0
+		}
+		var body io.Reader
+		if s := luaOptString(L, opts, "body", ""); s != "" {
+			body = strings.NewReader(s)
+		}
+
+		ctx := scriptContext(L)
+		if timeout, ok := opts.RawGetString("timeout").(lua.LNumber); ok && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(float64(timeout)*float64(time.Second)))
+			defer cancel()
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+		if err != nil {
+			L.ArgError(1, err.Error())
+			return 0
+		}
+		if headers, ok := opts.RawGetString("headers").(*lua.LTable); ok {
+			headers.ForEach(func(k, v lua.LValue) {
+				req.Header.Set(k.String(), v.String())
+			})
+		}
+		if !h.allowed(req.URL) {
+			return pushHTTPError(L, fmt.Errorf("host not allowed: %s", req.URL.Hostname()))
+		}
+
+		script := call().script
+		if !h.acquire(script) {
+			return pushHTTPError(L, fmt.Errorf("too many requests in flight (max %d)", h.policy.MaxConcurrent))
+		}
+		resp, data, err := h.do(req)
+		h.release(script)
+		if err != nil {
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			return pushHTTPError(L, err)
+		}
+
+		respHeaders := L.NewTable()
+		for name, values := range resp.Header {
+			respHeaders.RawSetString(strings.ToLower(name), lua.LString(strings.Join(values, ", ")))
+		}
+		res := L.NewTable()
+		res.RawSetString("status", lua.LNumber(resp.StatusCode))
+		res.RawSetString("headers", respHeaders)
+		res.RawSetString("body", lua.LString(data))
+		L.Push(res)
+		return 1
+	}))
+
+	musMod.RawSetString("http", httpMod)
+}
+
+func pushHTTPError(L *lua.LState, err error) int {
+	L.Push(lua.LNil)
+	L.Push(lua.LString(err.Error()))
+	return 2
+}
+
+// luaOptString reads an optional string field of opts.
+func luaOptString(L *lua.LState, opts *lua.LTable, field, fallback string) string {
+	switch v := opts.RawGetString(field).(type) {
+	case *lua.LNilType:
+		return fallback
+	case lua.LString:
+		return string(v)
+	default:
+		L.ArgError(1, fmt.Sprintf("%s must be a string, got %s", field, v.Type()))
+		return fallback
+	}
+}
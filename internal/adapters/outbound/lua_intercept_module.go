@@ -0,0 +1,52 @@
+package outbound
+
+import (
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// registerInterceptModule exposes mus.intercept to interceptor scripts. The
+// script inspects the message with mus.getContent() and the getters below,
+// and decides by calling drop, reject or rewrite; doing nothing allows it.
+func registerInterceptModule(L *lua.LState, musMod *lua.LTable, info *ports.ScriptInterception, verdict *ports.InterceptVerdict) {
+	mod := L.NewTable()
+
+	mod.RawSetString("getPhase", L.NewFunction(func(L *lua.LState) int {
+		L.Push(lua.LString(info.Phase))
+		return 1
+	}))
+
+	mod.RawSetString("getSubject", L.NewFunction(func(L *lua.LState) int {
+		L.Push(lua.LString(info.Subject))
+		return 1
+	}))
+
+	mod.RawSetString("getRecipient", L.NewFunction(func(L *lua.LState) int {
+		L.Push(lua.LString(info.Recipient))
+		return 1
+	}))
+
+	mod.RawSetString("drop", L.NewFunction(func(L *lua.LState) int {
+		verdict.Drop = true
+		return 0
+	}))
+
+	mod.RawSetString("reject", L.NewFunction(func(L *lua.LState) int {
+		code := L.CheckInt(1)
+		if code == 0 {
+			L.ArgError(1, "error code must be non-zero")
+			return 0
+		}
+		verdict.ErrCode = int32(code)
+		return 0
+	}))
+
+	mod.RawSetString("rewrite", L.NewFunction(func(L *lua.LState) int {
+		verdict.Content = lingo.LuaToLValue(L.Get(1))
+		return 0
+	}))
+
+	musMod.RawSetString("intercept", mod)
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"math"
 
+	"fsos-server/internal/domain/types/lingo"
+
 	lua "github.com/yuin/gopher-lua"
 )
 
@@ -62,6 +64,9 @@ func luaValueToJsonable(L *lua.LState, lv lua.LValue) interface{} {
 	case lua.LString:
 		return string(v)
 	case *lua.LTable:
+		if name, ok := lingo.LuaSymbolName(v); ok {
+			return name
+		}
 		if isSequentialTable(v) {
 			arr := make([]interface{}, 0, v.MaxN())
 			for i := 1; i <= v.MaxN(); i++ {
@@ -0,0 +1,358 @@
+package outbound
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unsafe"
+
+	"fsos-server/internal/domain/types/lingo"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// ScriptLimits caps what a script may use beyond its timeout. A zero field
+// leaves that cap off, or at gopher-lua's default for the two VM sizes.
+type ScriptLimits struct {
+	CallStackSize   int   // nested Lua calls
+	RegistrySize    int   // value stack slots, grown on demand up to this
+	MaxMemory       int64 // bytes the script's values may hold, approximately
+	MaxInstructions int64 // VM instructions per execution
+	MaxMessageBytes int   // encoded content of a response, reply or sent message
+	MaxSends        int   // mus.sendMessage and mus.server.broadcast calls per execution
+}
+
+// memorySampleInterval is the fewest instructions between two memory
+// samples. Larger heaps are sampled less often, so walking them costs a
+// bounded share of the run.
+const memorySampleInterval = 10000
+
+// Rough sizes for the memory estimate.
+const (
+	luaValueBytes    = 16 // a slot holding any value
+	luaTableBytes    = 56
+	luaEntryBytes    = 40 // per table entry, key and value slots included
+	luaFunctionBytes = 64
+	luaStringShare   = 64 // strings at least this long are counted once
+)
+
+// scriptMeter accounts for one execution, mus.call runs included: they share
+// their caller's meter. Only the goroutine running the script touches it.
+type scriptMeter struct {
+	limits       ScriptLimits
+	instructions int64
+	nextSample   int64
+	used         int64   // bytes counted by the last memory sample
+	fresh        int64   // bytes of large strings the frames gained since
+	strings      []*byte // large strings in the running frame, by data
+	lastStrings  []*byte // the same, one instruction earlier
+	sends        int
+	err          error // the limit the run broke, once it has
+}
+
+func newScriptMeter(limits ScriptLimits) *scriptMeter {
+	return &scriptMeter{limits: limits, nextSample: memorySampleInterval}
+}
+
+// metered reports whether the meter has to see every instruction.
+func (m *scriptMeter) metered() bool {
+	return m.limits.MaxInstructions > 0 || m.limits.MaxMemory > 0
+}
+
+// step counts one instruction of L and samples its memory when due: on
+// schedule, or sooner when new large strings could have taken the run past
+// the limit.
+func (m *scriptMeter) step(L *lua.LState) {
+	m.instructions++
+	if budget := m.limits.MaxInstructions; budget > 0 && m.instructions > budget {
+		m.err = fmt.Errorf("instruction budget of %d exceeded", budget)
+		return
+	}
+	if m.limits.MaxMemory <= 0 {
+		return
+	}
+	if !m.watchStrings(L) && m.instructions < m.nextSample {
+		return
+	}
+	used, visited := luaMemory(L, m.limits.MaxMemory)
+	if used > m.limits.MaxMemory {
+		m.err = fmt.Errorf("memory limit of %d bytes exceeded", m.limits.MaxMemory)
+		return
+	}
+	m.used, m.fresh = used, 0
+	m.nextSample = m.instructions + max(memorySampleInterval, 16*int64(visited))
+}
+
+// watchStrings looks at the large strings in the running frame's registers,
+// where each concatenation and library call leaves its result: a string
+// doubled a few dozen times outgrows any limit in fewer instructions than
+// one sample interval. It fails the run on a string over the limit by
+// itself, and reports whether the ones the frame gained since the last
+// sample call for an early one.
+func (m *scriptMeter) watchStrings(L *lua.LState) bool {
+	m.strings, m.lastStrings = m.lastStrings[:0], m.strings
+	for i, top := 1, L.GetTop(); i <= top; i++ {
+		s, ok := L.Get(i).(lua.LString)
+		if !ok || len(s) < luaStringShare {
+			continue
+		}
+		if int64(len(s)) > m.limits.MaxMemory {
+			m.err = fmt.Errorf("memory limit of %d bytes exceeded", m.limits.MaxMemory)
+			return false
+		}
+		data := unsafe.StringData(string(s))
+		if !slices.Contains(m.lastStrings, data) {
+			m.fresh += int64(len(s))
+		}
+		m.strings = append(m.strings, data)
+	}
+	return m.used+m.fresh > m.limits.MaxMemory
+}
+
+// checkMessage raises a script error when content encodes to more than
+// MaxMessageBytes, which clients would refuse.
+func (m *scriptMeter) checkMessage(L *lua.LState, fn string, content lingo.LValue) {
+	if m == nil || m.limits.MaxMessageBytes <= 0 {
+		return
+	}
+	if n := len(content.GetBytes()); n > m.limits.MaxMessageBytes {
+		L.RaiseError("%s: content is %d bytes, over the %d-byte message limit", fn, n, m.limits.MaxMessageBytes)
+	}
+}
+
+// countSend raises a script error on the send past MaxSends.
+func (m *scriptMeter) countSend(L *lua.LState, fn string) {
+	if m == nil {
+		return
+	}
+	m.sends++
+	if limit := m.limits.MaxSends; limit > 0 && m.sends > limit {
+		L.RaiseError("%s: more than %d messages sent in one run", fn, limit)
+	}
+}
+
+// meteredContext is the context a metered script runs under. gopher-lua
+// (v1.1.1) checks Done before every instruction, so that is where the meter
+// counts; TestLimits_InstructionBudget fails should an upgrade stop doing
+// so. Once a limit is broken Done stays closed and Err says which, and the
+// VM raises it as the script's error.
+type meteredContext struct {
+	context.Context
+	L     *lua.LState
+	meter *scriptMeter
+}
+
+var closedDone = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+func (c *meteredContext) Done() <-chan struct{} {
+	if c.meter.err == nil {
+		c.meter.step(c.L)
+	}
+	if c.meter.err != nil {
+		return closedDone
+	}
+	return c.Context.Done()
+}
+
+func (c *meteredContext) Err() error {
+	if c.meter.err != nil {
+		return c.meter.err
+	}
+	return c.Context.Err()
+}
+
+// scriptContext is the deadline of the script L runs, for work it hands to
+// other goroutines or VMs; the meter stays with L.
+func scriptContext(L *lua.LState) context.Context {
+	switch ctx := L.Context().(type) {
+	case nil:
+		return context.Background()
+	case *meteredContext:
+		return ctx.Context
+	default:
+		return ctx
+	}
+}
+
+// luaMemory estimates the bytes held by the values L can reach from its
+// globals and the locals of its running frames. It stops counting past
+// limit; visited is how many values it walked.
+func luaMemory(L *lua.LState, limit int64) (used int64, visited int) {
+	seen := make(map[interface{}]struct{})
+	var walk func(v lua.LValue)
+	walk = func(v lua.LValue) {
+		if used > limit {
+			return
+		}
+		visited++
+		used += luaValueBytes
+		switch v := v.(type) {
+		case lua.LString:
+			if len(v) >= luaStringShare {
+				data := unsafe.StringData(string(v))
+				if _, ok := seen[data]; ok {
+					return
+				}
+				seen[data] = struct{}{}
+			}
+			used += int64(len(v))
+		case *lua.LTable:
+			if _, ok := seen[v]; ok {
+				return
+			}
+			seen[v] = struct{}{}
+			used += luaTableBytes
+			v.ForEach(func(key, value lua.LValue) {
+				used += luaEntryBytes
+				walk(key)
+				walk(value)
+			})
+			walk(v.Metatable)
+		case *lua.LFunction:
+			if _, ok := seen[v]; ok {
+				return
+			}
+			seen[v] = struct{}{}
+			used += luaFunctionBytes
+			for _, uv := range v.Upvalues {
+				walk(uv.Value())
+			}
+		}
+	}
+
+	walk(L.G.Global)
+	walk(L.G.Registry)
+	for level := 0; ; level++ {
+		frame, ok := L.GetStack(level)
+		if !ok {
+			break
+		}
+		for n := 1; ; n++ {
+			name, value := L.GetLocal(frame, n)
+			if name == "" {
+				break
+			}
+			walk(value)
+		}
+	}
+	return used, visited
+}
+
+// limitStringBuilders replaces string.rep, string.format and table.concat
+// with versions that refuse results over maxBytes before building them: one
+// call could otherwise allocate far past the memory limit before the meter
+// sees its result.
+func limitStringBuilders(L *lua.LState, maxBytes int64) {
+	if strMod, ok := L.GetGlobal(lua.StringLibName).(*lua.LTable); ok {
+		limitStringRep(L, strMod, maxBytes)
+		limitStringFormat(L, strMod, maxBytes)
+	}
+	if tblMod, ok := L.GetGlobal(lua.TabLibName).(*lua.LTable); ok {
+		limitTableConcat(L, tblMod, maxBytes)
+	}
+}
+
+func limitStringRep(L *lua.LState, strMod *lua.LTable, maxBytes int64) {
+	strMod.RawSetString("rep", L.NewFunction(func(L *lua.LState) int {
+		s := L.CheckString(1)
+		n := L.CheckInt(2)
+		if n <= 0 || s == "" {
+			L.Push(lua.LString(""))
+			return 1
+		}
+		if int64(n) > maxBytes/int64(len(s)) {
+			L.RaiseError("string.rep: result over the memory limit of %d bytes", maxBytes)
+			return 0
+		}
+		L.Push(lua.LString(strings.Repeat(s, n)))
+		return 1
+	}))
+}
+
+// luaNumberBytes bounds the length of a number or other non-string value
+// once formatted.
+const luaNumberBytes = 32
+
+// limitStringFormat bounds string.format's result by its format, the widths
+// and precisions in it and its arguments; Go's formatting, which gopher-lua
+// uses, takes widths of any size.
+func limitStringFormat(L *lua.LState, strMod *lua.LTable, maxBytes int64) {
+	format, ok := strMod.RawGetString("format").(*lua.LFunction)
+	if !ok {
+		return
+	}
+	strMod.RawSetString("format", L.NewFunction(func(L *lua.LState) int {
+		size := formatWidths(L.CheckString(1))
+		for i, top := 2, L.GetTop(); i <= top; i++ {
+			if s, ok := L.Get(i).(lua.LString); ok {
+				size += int64(len(s))
+			} else {
+				size += luaNumberBytes
+			}
+		}
+		if size > maxBytes {
+			L.RaiseError("string.format: result over the memory limit of %d bytes", maxBytes)
+			return 0
+		}
+		return format.GFunction(L)
+	}))
+}
+
+// formatWidths is the length of format plus every width and precision in its
+// verbs, the most padding they can add.
+func formatWidths(format string) int64 {
+	size := int64(len(format))
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		for i++; i < len(format) && strings.IndexByte("-+ #0", format[i]) >= 0; i++ {
+		}
+		for i < len(format) && (format[i] == '.' || format[i] >= '0' && format[i] <= '9') {
+			n := int64(0)
+			for ; i < len(format) && format[i] >= '0' && format[i] <= '9'; i++ {
+				n = min(n*10+int64(format[i]-'0'), 1<<40)
+			}
+			size += n
+			if i < len(format) && format[i] == '.' {
+				i++
+			}
+		}
+	}
+	return size
+}
+
+// limitTableConcat sizes table.concat's result from the items it joins.
+func limitTableConcat(L *lua.LState, tblMod *lua.LTable, maxBytes int64) {
+	concat, ok := tblMod.RawGetString("concat").(*lua.LFunction)
+	if !ok {
+		return
+	}
+	tblMod.RawSetString("concat", L.NewFunction(func(L *lua.LState) int {
+		tbl := L.CheckTable(1)
+		sep := int64(len(L.OptString(2, "")))
+		first := max(L.OptInt(3, 1), 1)
+		last := min(L.OptInt(4, tbl.Len()), tbl.Len())
+		size := int64(0)
+		for i := first; i <= last && size <= maxBytes; i++ {
+			if s, ok := tbl.RawGetInt(i).(lua.LString); ok {
+				size += int64(len(s))
+			} else {
+				size += luaNumberBytes
+			}
+			if i < last {
+				size += sep
+			}
+		}
+		if size > maxBytes {
+			L.RaiseError("table.concat: result over the memory limit of %d bytes", maxBytes)
+			return 0
+		}
+		return concat.GFunction(L)
+	}))
+}
@@ -0,0 +1,128 @@
+package outbound
+
+import (
+	"fsos-server/internal/domain/types/lingo"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// lingoTypeNames are what mus.lingo.typeOf reports, after Lingo's ilk().
+var lingoTypeNames = map[int16]string{
+	lingo.VtVoid:        "void",
+	lingo.VtInteger:     "integer",
+	lingo.VtSymbol:      "symbol",
+	lingo.VtString:      "string",
+	lingo.VtPicture:     "picture",
+	lingo.VtFloat:       "float",
+	lingo.VtList:        "list",
+	lingo.VtPoint:       "point",
+	lingo.VtRect:        "rect",
+	lingo.VtPropList:    "propList",
+	lingo.VtColor:       "color",
+	lingo.VtDate:        "date",
+	lingo.VtMedia:       "media",
+	lingo.Vt3dVector:    "vector",
+	lingo.Vt3dTransform: "transform",
+}
+
+// registerLingoModule builds mus.lingo, constructors for the Lingo values a
+// Lua value does not map to on its own:
+//
+//	symbol(name)  point(h, v)  rect(l, t, r, b)  rgb(r, g, b)  date(bytes)
+//	vector(x, y, z)  transform([16 numbers])  typeOf(v)
+//
+// The values are tagged tables (see lingo.LValueToLua) that keep their type
+// through mus.response, mus.reply, mus.sendMessage, mus.publish and the
+// mus.db attribute calls, and that come back in the same form when received.
+func registerLingoModule(L *lua.LState, musMod *lua.LTable) {
+	lingoMod := L.NewTable()
+
+	push := func(L *lua.LState, v lingo.LValue) int {
+		L.Push(lingo.LValueToLua(L, v))
+		return 1
+	}
+	coord := func(L *lua.LState, n int) lingo.LValue {
+		return lingo.LuaToLValue(L.CheckNumber(n))
+	}
+
+	lingoMod.RawSetString("symbol", L.NewFunction(func(L *lua.LState) int {
+		L.Push(lingo.SymbolToLua(L, L.CheckString(1)))
+		return 1
+	}))
+
+	lingoMod.RawSetString("point", L.NewFunction(func(L *lua.LState) int {
+		return push(L, lingo.NewLPoint(coord(L, 1), coord(L, 2)))
+	}))
+
+	lingoMod.RawSetString("rect", L.NewFunction(func(L *lua.LState) int {
+		return push(L, lingo.NewLRect(coord(L, 1), coord(L, 2), coord(L, 3), coord(L, 4)))
+	}))
+
+	lingoMod.RawSetString("rgb", L.NewFunction(func(L *lua.LState) int {
+		var rgb [3]uint8
+		for i := range rgb {
+			c := L.CheckInt(i + 1)
+			if c < 0 || c > 255 {
+				L.ArgError(i+1, "color component must be 0-255")
+				return 0
+			}
+			rgb[i] = uint8(c)
+		}
+		return push(L, lingo.NewLColor(rgb[0], rgb[1], rgb[2]))
+	}))
+
+	// date takes the 8 raw bytes of a Director date, e.g. kept from one a
+	// client sent; the layout is not documented.
+	lingoMod.RawSetString("date", L.NewFunction(func(L *lua.LState) int {
+		data := L.CheckString(1)
+		if len(data) != 8 {
+			L.ArgError(1, "a date is 8 bytes")
+			return 0
+		}
+		var raw [8]byte
+		copy(raw[:], data)
+		return push(L, lingo.NewLDate(raw))
+	}))
+
+	lingoMod.RawSetString("vector", L.NewFunction(func(L *lua.LState) int {
+		return push(L, lingo.NewL3dVector(float32(L.CheckNumber(1)), float32(L.CheckNumber(2)), float32(L.CheckNumber(3))))
+	}))
+
+	lingoMod.RawSetString("transform", L.NewFunction(func(L *lua.LState) int {
+		var matrix [16]float32
+		if L.GetTop() == 0 {
+			// identity
+			for i := 0; i < 16; i += 5 {
+				matrix[i] = 1
+			}
+			return push(L, lingo.NewL3dTransform(matrix))
+		}
+		values := L.CheckTable(1)
+		if values.Len() != 16 {
+			L.ArgError(1, "a transform is 16 numbers")
+			return 0
+		}
+		for i := range matrix {
+			n, ok := values.RawGetInt(i + 1).(lua.LNumber)
+			if !ok {
+				L.ArgError(1, "a transform is 16 numbers")
+				return 0
+			}
+			matrix[i] = float32(n)
+		}
+		return push(L, lingo.NewL3dTransform(matrix))
+	}))
+
+	// typeOf(v) names the Lingo type v converts to: "integer", "symbol",
+	// "propList", "point"... Booleans are integers and nil is "void".
+	lingoMod.RawSetString("typeOf", L.NewFunction(func(L *lua.LState) int {
+		name, ok := lingoTypeNames[lingo.LuaToLValue(L.Get(1)).GetType()]
+		if !ok {
+			name = "void"
+		}
+		L.Push(lua.LString(name))
+		return 1
+	}))
+
+	musMod.RawSetString("lingo", lingoMod)
+}
@@ -0,0 +1,151 @@
+package outbound
+
+import (
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// registerMovieModules builds mus.movie and mus.group over movies, with the
+// semantics of the system.movie.* and system.group.* commands. Calls default
+// to the sender's movie; naming another movie, or joining or removing another
+// user, needs the sender to pass movies.CanActOn. Joins and leaves run through
+// inline for the user joining or leaving: the group hooks they fire run as
+// that user, who need not be the sender.
+func registerMovieModules(L *lua.LState, musMod *lua.LTable, movies ports.MovieDirectory, sender func() string, inline func(senderID string, fn func())) {
+	// movieArg resolves the optional movieID argument at idx. It returns ""
+	// when the sender is in no movie and none was given.
+	movieArg := func(L *lua.LState, fn string, idx int) string {
+		own, _ := movies.UserMovie(sender())
+		movieID := L.OptString(idx, own)
+		if movieID != own && !movies.CanActOn(sender(), "") {
+			L.RaiseError("%s: %s may not access movie %s", fn, sender(), movieID)
+		}
+		return movieID
+	}
+	userArg := func(L *lua.LState, fn string) string {
+		userID := L.CheckString(1)
+		if !movies.CanActOn(sender(), userID) {
+			L.RaiseError("%s: %s may not act on %s", fn, sender(), userID)
+		}
+		return userID
+	}
+
+	movieMod := L.NewTable()
+
+	// mus.movie.getMovie(userID) -> movieID|nil
+	movieMod.RawSetString("getMovie", L.NewFunction(func(L *lua.LState) int {
+		movieID, err := movies.UserMovie(L.CheckString(1))
+		if err != nil {
+			L.Push(lua.LNil)
+			return 1
+		}
+		L.Push(lua.LString(movieID))
+		return 1
+	}))
+
+	// mus.movie.getUsers([movieID]) -> {userID, ...}
+	movieMod.RawSetString("getUsers", L.NewFunction(func(L *lua.LState) int {
+		users, _ := movies.MovieUsers(movieArg(L, "mus.movie.getUsers", 1))
+		L.Push(stringList(L, users))
+		return 1
+	}))
+
+	movieMod.RawSetString("getUserCount", L.NewFunction(func(L *lua.LState) int {
+		users, _ := movies.MovieUsers(movieArg(L, "mus.movie.getUserCount", 1))
+		L.Push(lua.LNumber(len(users)))
+		return 1
+	}))
+
+	// mus.movie.getGroups([movieID]) -> {group, ...}
+	movieMod.RawSetString("getGroups", L.NewFunction(func(L *lua.LState) int {
+		groups, _ := movies.MovieGroups(movieArg(L, "mus.movie.getGroups", 1))
+		L.Push(stringList(L, groups))
+		return 1
+	}))
+
+	musMod.RawSetString("movie", movieMod)
+
+	groupMod := L.NewTable()
+
+	// mus.group.join(userID, group) -> true | false, err. The group is in the
+	// user's movie and is created on first join; a beforeJoinGroup hook can
+	// veto, in which case err is its reason.
+	groupMod.RawSetString("join", L.NewFunction(func(L *lua.LState) int {
+		userID := userArg(L, "mus.group.join")
+		group := L.CheckString(2)
+		movieID, err := movies.UserMovie(userID)
+		if err == nil {
+			inline(userID, func() { err = movies.JoinGroup(movieID, group, userID) })
+		}
+		return pushResult(L, err)
+	}))
+
+	// mus.group.leave(userID, group) -> true | false, err
+	groupMod.RawSetString("leave", L.NewFunction(func(L *lua.LState) int {
+		userID := userArg(L, "mus.group.leave")
+		group := L.CheckString(2)
+		movieID, err := movies.UserMovie(userID)
+		if err == nil {
+			inline(userID, func() { err = movies.LeaveGroup(movieID, group, userID) })
+		}
+		return pushResult(L, err)
+	}))
+
+	// mus.group.getUsers(group, [movieID]) -> {userID, ...}
+	groupMod.RawSetString("getUsers", L.NewFunction(func(L *lua.LState) int {
+		group := L.CheckString(1)
+		members, _ := movies.GroupMembers(movieArg(L, "mus.group.getUsers", 2), group)
+		L.Push(stringList(L, members))
+		return 1
+	}))
+
+	groupMod.RawSetString("getUserCount", L.NewFunction(func(L *lua.LState) int {
+		group := L.CheckString(1)
+		members, _ := movies.GroupMembers(movieArg(L, "mus.group.getUserCount", 2), group)
+		L.Push(lua.LNumber(len(members)))
+		return 1
+	}))
+
+	// mus.group.getAttribute(group, attr, [movieID]) -> value|nil
+	groupMod.RawSetString("getAttribute", L.NewFunction(func(L *lua.LState) int {
+		group := L.CheckString(1)
+		attr := L.CheckString(2)
+		val, err := movies.GroupAttribute(movieArg(L, "mus.group.getAttribute", 3), group, attr)
+		if err != nil || val == nil {
+			L.Push(lua.LNil)
+			return 1
+		}
+		L.Push(lingo.LValueToLua(L, val))
+		return 1
+	}))
+
+	// mus.group.setAttribute(group, attr, value, [movieID]) -> true | false, err.
+	// Members are notified with the sender as author.
+	groupMod.RawSetString("setAttribute", L.NewFunction(func(L *lua.LState) int {
+		group := L.CheckString(1)
+		attr := L.CheckString(2)
+		value := lingo.LuaToLValue(L.Get(3))
+		movieID := movieArg(L, "mus.group.setAttribute", 4)
+		return pushResult(L, movies.SetGroupAttribute(movieID, group, attr, value, sender()))
+	}))
+
+	// mus.group.deleteAttribute(group, attr, [movieID]) -> true | false, err
+	groupMod.RawSetString("deleteAttribute", L.NewFunction(func(L *lua.LState) int {
+		group := L.CheckString(1)
+		attr := L.CheckString(2)
+		movieID := movieArg(L, "mus.group.deleteAttribute", 3)
+		return pushResult(L, movies.DeleteGroupAttribute(movieID, group, attr, sender()))
+	}))
+
+	// mus.group.getAttributeNames(group, [movieID]) -> {attr, ...}
+	groupMod.RawSetString("getAttributeNames", L.NewFunction(func(L *lua.LState) int {
+		group := L.CheckString(1)
+		names, _ := movies.GroupAttributeNames(movieArg(L, "mus.group.getAttributeNames", 2), group)
+		L.Push(stringList(L, names))
+		return 1
+	}))
+
+	musMod.RawSetString("group", groupMod)
+}
@@ -6,10 +6,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"fsos-server/internal/domain/ports"
 	"fsos-server/internal/domain/types/lingo"
+	"fsos-server/internal/domain/types/smus"
 
 	"github.com/google/uuid"
 	lua "github.com/yuin/gopher-lua"
@@ -26,6 +29,15 @@ type LuaScriptEngine struct {
 	sessionStore  ports.SessionStore
 	cache         ports.Cache
 	emailSender   ports.EmailSender
+
+	bindMu sync.RWMutex
+	bound  luaBindings
+
+	disableBroken atomic.Bool // see DisableBrokenScripts
+
+	vms     sync.Pool // *luaVM, see lua_vm_pool.go
+	protoMu sync.RWMutex
+	protos  map[string]*compiledScript
 }
 
 func NewLuaScriptEngine(scriptsDir string, logger ports.Logger, scriptTimeoutSeconds int, publisher ports.QueuePublisher, sender ports.MessageSender, db ports.DBAdapter, queryBuilder ports.QueryBuilder, sessionStore ports.SessionStore, cache ports.Cache, emailSender ports.EmailSender) *LuaScriptEngine {
@@ -40,14 +52,93 @@ func NewLuaScriptEngine(scriptsDir string, logger ports.Logger, scriptTimeoutSec
 		sessionStore:  sessionStore,
 		cache:         cache,
 		emailSender:   emailSender,
+		protos:        make(map[string]*compiledScript),
 	}
 }
 
+// luaBindings are dependencies bound after construction, because the objects
+// behind them are built later in startup. gen stamps the VMs built with them:
+// pooled states built before a Use* call are replaced on their next use.
+type luaBindings struct {
+	movies       ports.MovieDirectory
+	conns        ports.ConnectionWriter
+	timers       ports.TimerManager
+	system       ports.SystemCommandRunner
+	callGuard    ports.ScriptCallGuard
+	inliner      ports.ScriptInliner
+	scriptTimers ports.ScriptTimers
+	http         *scriptHTTP
+	limits       ScriptLimits
+	gen          int
+}
+
+// UseMovieDirectory exposes movies to scripts as mus.movie and mus.group, and
+// lets mus.session reach other users' sessions when movies.CanActOn allows.
+func (e *LuaScriptEngine) UseMovieDirectory(movies ports.MovieDirectory) {
+	e.bind(func(b *luaBindings) { b.movies = movies })
+}
+
+// UseModeration enables mus.admin: kicks through conns, and kill timers
+// through timers when it is non-nil.
+func (e *LuaScriptEngine) UseModeration(conns ports.ConnectionWriter, timers ports.TimerManager) {
+	e.bind(func(b *luaBindings) { b.conns, b.timers = conns, timers })
+}
+
+// UseSystemCommands enables mus.system, which runs System commands through
+// runner.
+func (e *LuaScriptEngine) UseSystemCommands(runner ports.SystemCommandRunner) {
+	e.bind(func(b *luaBindings) { b.system = runner })
+}
+
+// UseScriptCallGuard checks each client-driven mus.call against guard before
+// running the callee.
+func (e *LuaScriptEngine) UseScriptCallGuard(guard ports.ScriptCallGuard) {
+	e.bind(func(b *luaBindings) { b.callGuard = guard })
+}
+
+// UseInliner makes the mus calls that may run a hook script and wait for it
+// go through inliner: mus.system for the sender, mus.group.join/leave for the
+// user joining or leaving.
+func (e *LuaScriptEngine) UseInliner(inliner ports.ScriptInliner) {
+	e.bind(func(b *luaBindings) { b.inliner = inliner })
+}
+
+// UseScriptTimers enables mus.timer, which runs scripts later through
+// timers.
+func (e *LuaScriptEngine) UseScriptTimers(timers ports.ScriptTimers) {
+	e.bind(func(b *luaBindings) { b.scriptTimers = timers })
+}
+
+// UseHTTP enables mus.http, which may call the hosts policy allows.
+func (e *LuaScriptEngine) UseHTTP(policy ScriptHTTPPolicy) {
+	h := newScriptHTTP(policy)
+	e.bind(func(b *luaBindings) { b.http = h })
+}
+
+// UseLimits caps the resources of every execution from now on.
+func (e *LuaScriptEngine) UseLimits(limits ScriptLimits) {
+	e.bind(func(b *luaBindings) { b.limits = limits })
+}
+
+func (e *LuaScriptEngine) bind(set func(*luaBindings)) {
+	e.bindMu.Lock()
+	defer e.bindMu.Unlock()
+	set(&e.bound)
+	e.bound.gen++
+}
+
+func (e *LuaScriptEngine) bindings() luaBindings {
+	e.bindMu.RLock()
+	defer e.bindMu.RUnlock()
+	return e.bound
+}
+
 // resolveScriptPath maps a network-supplied subject to an on-disk .lua path,
 // rejecting absolute subjects, ".." traversal, and any path that escapes
 // scriptsDir. Same containment policy already enforced for require/dofile.
+// Test files (*_test.lua) only run under ScriptTestRunner.
 func (e *LuaScriptEngine) resolveScriptPath(subject string) (string, bool) {
-	if subject == "" || filepath.IsAbs(subject) || containsDotDot(subject) {
+	if subject == "" || filepath.IsAbs(subject) || containsDotDot(subject) || strings.HasSuffix(subject, TestScriptSuffix) {
 		return "", false
 	}
 	path := filepath.Join(e.scriptsDir, subject+".lua")
@@ -85,27 +176,114 @@ func (e *LuaScriptEngine) resolveScript(subject string) (string, bool) {
 }
 
 func (e *LuaScriptEngine) HasScript(subject string) bool {
-	_, ok := e.resolveScript(subject)
+	path, ok := e.resolveScript(subject)
+	if ok && e.disableBroken.Load() {
+		_, err := e.compiled(path)
+		return err == nil
+	}
 	return ok
 }
 
+// ScriptPolicy returns the policy of the script subject runs, from its @script
+// header (ports.ScriptPolicySource).
+func (e *LuaScriptEngine) ScriptPolicy(subject string) (ports.ScriptPolicy, error) {
+	path, ok := e.resolveScript(subject)
+	if !ok {
+		return ports.ScriptPolicy{}, fmt.Errorf("invalid script subject: %q", subject)
+	}
+	script, err := e.compiled(path)
+	if err != nil {
+		return ports.ScriptPolicy{}, err
+	}
+	policy := script.policy
+	policy.Script = path
+	return policy, nil
+}
+
 func (e *LuaScriptEngine) Execute(msg *ports.ScriptMessage) (*ports.ScriptResult, error) {
+	return e.run(msg, context.Background(), 0, nil)
+}
+
+// run executes msg's script within parent's deadline; depth counts the
+// mus.call frames above it, whose meter it shares (nil for a fresh one).
+func (e *LuaScriptEngine) run(msg *ports.ScriptMessage, parent context.Context, depth int, meter *scriptMeter) (*ports.ScriptResult, error) {
 	path, ok := e.resolveScript(msg.Subject)
 	if !ok {
 		return nil, fmt.Errorf("invalid script subject: %q", msg.Subject)
 	}
+	script, err := e.compiled(path)
+	if err != nil {
+		return nil, fmt.Errorf("script %q execution failed: %w", msg.Subject, err)
+	}
+
+	// VMs are pooled: one runs a single script at a time, and reset() puts
+	// its globals and modules back to their post-setup state afterwards so
+	// nothing leaks from one execution into the next.
+	vm, err := e.acquireVM()
+	if err != nil {
+		return nil, err
+	}
+	L := vm.L
+	if meter == nil {
+		meter = newScriptMeter(e.bindings().limits)
+	}
+	call := &luaCall{msg: msg, script: path, loaded: L.NewTable(), depth: depth, meter: meter}
+	vm.call = call
 
-	// Fresh VM per execution — intentionally not pooled.
-	// This ensures thread safety (no shared state between concurrent calls)
-	// at the cost of VM creation overhead. If throughput becomes an issue,
-	// consider a sync.Pool of pre-configured LStates.
-	L := lua.NewState(lua.Options{SkipOpenLibs: true})
-	defer L.Close()
+	// Register mus.intercept — interceptor scripts only
+	if msg.Interception != nil {
+		call.verdict = &ports.InterceptVerdict{}
+		registerInterceptModule(L, vm.mus, msg.Interception, call.verdict)
+	}
 
-	// Execution timeout to prevent runaway scripts (e.g. infinite loops)
-	ctx, cancel := context.WithTimeout(context.Background(), e.scriptTimeout)
+	// Execution timeout to prevent runaway scripts (e.g. infinite loops); a
+	// @script timeout= header overrides the default
+	timeout := e.scriptTimeout
+	if script.policy.Timeout > 0 {
+		timeout = script.policy.Timeout
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
 	defer cancel()
-	L.SetContext(ctx)
+	if meter.metered() {
+		L.SetContext(&meteredContext{Context: ctx, L: L, meter: meter})
+	} else {
+		L.SetContext(ctx)
+	}
+
+	L.Push(L.NewFunctionFromProto(script.proto))
+	err = L.PCall(0, lua.MultRet, nil)
+	L.RemoveContext()
+	vm.call = nil
+	if err != nil {
+		// A failed or timed-out VM may hold half-finished state; drop it.
+		L.Close()
+		return nil, fmt.Errorf("script %q execution failed: %w", msg.Subject, err)
+	}
+	e.releaseVM(vm)
+
+	// Scripts must use mus.response() to produce a result.
+	// If they don't, the result is LVoid (no response).
+	result := call.ensureResult()
+	if result.Content == nil {
+		result.Content = lingo.NewLVoid()
+	}
+	result.Verdict = call.verdict
+
+	return result, nil
+}
+
+// newVM builds a state with the safe libs, the mus module and the sandboxed
+// require/dofile. The mus closures read the running execution from vm.call.
+func (e *LuaScriptEngine) newVM() (*luaVM, error) {
+	bound := e.bindings()
+	limits := bound.limits
+	opts := lua.Options{SkipOpenLibs: true, CallStackSize: limits.CallStackSize}
+	if limits.RegistrySize > 0 {
+		opts.RegistrySize = min(limits.RegistrySize, lua.RegistrySize)
+		opts.RegistryMaxSize = limits.RegistrySize
+	}
+	L := lua.NewState(opts)
+	vm := &luaVM{L: L, bindGen: bound.gen}
 
 	// Open only safe libs — no os, io, debug, or package (which exposes loadlib/filesystem)
 	for _, pair := range []struct {
@@ -122,40 +300,80 @@ func (e *LuaScriptEngine) Execute(msg *ports.ScriptMessage) (*ports.ScriptResult
 			NRet:    0,
 			Protect: true,
 		}, lua.LString(pair.name)); err != nil {
+			L.Close()
 			return nil, fmt.Errorf("failed to open lib %s: %w", pair.name, err)
 		}
 	}
+	if limits.MaxMemory > 0 {
+		limitStringBuilders(L, limits.MaxMemory)
+	}
 
 	// Build the mus module
-	var result *ports.ScriptResult
-
 	musMod := L.NewTable()
+	vm.mus = musMod
 
 	musMod.RawSetString("getSender", L.NewFunction(func(L *lua.LState) int {
-		L.Push(lua.LString(msg.SenderID))
+		L.Push(lua.LString(vm.call.msg.SenderID))
 		return 1
 	}))
 
 	musMod.RawSetString("getContent", L.NewFunction(func(L *lua.LState) int {
-		L.Push(lingo.LValueToLua(L, msg.Content))
+		L.Push(lingo.LValueToLua(L, vm.call.msg.Content))
 		return 1
 	}))
 
 	// The full original subject — prefix-routed scripts (SavePainting<L>-<id>
 	// style) read their dash-suffix payload from here.
 	musMod.RawSetString("getSubject", L.NewFunction(func(L *lua.LState) int {
-		L.Push(lua.LString(msg.Subject))
+		L.Push(lua.LString(vm.call.msg.Subject))
 		return 1
 	}))
 
+	// mus.response(value [, errCode [, subject]]) sets the main reply: its
+	// content, MUS error code, and a subject other than the script name.
 	musMod.RawSetString("response", L.NewFunction(func(L *lua.LState) int {
 		arg := L.Get(1)
 		content := lingo.LuaToLValue(arg)
-		result = &ports.ScriptResult{Content: content}
+		vm.call.meter.checkMessage(L, "mus.response", content)
+		result := vm.call.ensureResult()
+		result.Content = content
+		result.ErrCode = int32(L.OptInt(2, 0))
+		result.Subject = L.OptString(3, "")
+		result.NoReply = false
 		L.Push(arg)
 		return 1
 	}))
 
+	// mus.noResponse() sends no main reply (replies queued with mus.reply
+	// are still delivered).
+	musMod.RawSetString("noResponse", L.NewFunction(func(L *lua.LState) int {
+		vm.call.ensureResult().NoReply = true
+		return 0
+	}))
+
+	// mus.reply(subject, content [, errCode [, recipient]]) queues one more
+	// message, delivered by the Dispatcher once the script ends. The
+	// recipient defaults to the sender.
+	musMod.RawSetString("reply", L.NewFunction(func(L *lua.LState) int {
+		subject := L.CheckString(1)
+		if subject == "" {
+			L.ArgError(1, "subject must not be empty")
+			return 0
+		}
+		content := lingo.LuaToLValue(L.Get(2))
+		vm.call.meter.checkMessage(L, "mus.reply", content)
+		result := vm.call.ensureResult()
+		result.Replies = append(result.Replies, ports.ScriptReply{
+			Subject:   subject,
+			Content:   content,
+			ErrCode:   int32(L.OptInt(3, 0)),
+			Recipient: L.OptString(4, ""),
+		})
+		return 0
+	}))
+
+	musMod.RawSetString("errors", scriptErrorCodes(L))
+
 	musMod.RawSetString("publish", L.NewFunction(func(L *lua.LState) int {
 		topic := L.CheckString(1)
 		content := L.Get(2)
@@ -180,11 +398,13 @@ func (e *LuaScriptEngine) Execute(msg *ports.ScriptMessage) (*ports.ScriptResult
 		subject := L.CheckString(2)
 		content := L.Get(3)
 		lingoContent := lingo.LuaToLValue(content)
+		vm.call.meter.countSend(L, "mus.sendMessage")
+		vm.call.meter.checkMessage(L, "mus.sendMessage", lingoContent)
 		if e.sender != nil {
 			// Script-authored messages go on the wire from system.script (the
 			// legacy client only renders several subjects when they come from
 			// the server); the invoking player still anchors group routing.
-			if err := e.sender.SendMessageFrom(systemScriptSender, msg.SenderID, recipientID, subject, lingoContent); err != nil {
+			if err := e.sender.SendMessageFrom(systemScriptSender, vm.call.msg.SenderID, recipientID, subject, lingoContent); err != nil {
 				e.logger.Error("mus.sendMessage failed", map[string]interface{}{
 					"recipientID": recipientID,
 					"subject":     subject,
@@ -202,7 +422,51 @@ func (e *LuaScriptEngine) Execute(msg *ports.ScriptMessage) (*ports.ScriptResult
 
 	// Register mus.server module
 	if e.sessionStore != nil {
-		registerServerModule(L, musMod, e.sessionStore, e.sender, e.logger)
+		registerServerModule(L, musMod, e.sessionStore, e.sender, e.logger, func() *scriptMeter { return vm.call.meter })
+	}
+
+	// mus.getContext() describes the sender: level, movie, IP, groups...
+	musMod.RawSetString("getContext", L.NewFunction(func(L *lua.LState) int {
+		L.Push(e.callerContext(L, vm.call.msg, bound.movies))
+		return 1
+	}))
+
+	// mus.call, and mus.system once System commands are bound
+	registerCallFunctions(L, musMod, e, bound, func() *luaCall { return vm.call })
+
+	// Register mus.session, and mus.movie/mus.group once movies are bound.
+	// Without a directory a script may only touch its own sender's session.
+	sender := func() string { return vm.call.msg.SenderID }
+	if e.sessionStore != nil {
+		canActOn := func(actorID, targetUserID string) bool { return actorID == targetUserID }
+		if bound.movies != nil {
+			canActOn = bound.movies.CanActOn
+		}
+		registerSessionModule(L, musMod, e.sessionStore, sender, canActOn)
+	}
+	if bound.movies != nil {
+		registerMovieModules(L, musMod, bound.movies, sender, bound.inline)
+	}
+
+	// Register mus.admin once moderation is bound
+	if bound.conns != nil && e.sessionStore != nil {
+		registerAdminModule(L, musMod, &adminDeps{
+			sessions: e.sessionStore,
+			conns:    bound.conns,
+			timers:   bound.timers,
+			db:       e.db,
+			logger:   e.logger,
+		}, func() *ports.ScriptMessage { return vm.call.msg })
+	}
+
+	// Register mus.timer once script timers are bound
+	if bound.scriptTimers != nil {
+		registerTimerModule(L, musMod, bound.scriptTimers, func() *ports.ScriptMessage { return vm.call.msg })
+	}
+
+	// Register mus.http once hosts are allowed
+	if bound.http != nil {
+		registerHTTPModule(L, musMod, bound.http, func() *luaCall { return vm.call })
 	}
 
 	// Register mus.email — only when SMTP is configured, so scripts can gate
@@ -225,6 +489,12 @@ func (e *LuaScriptEngine) Execute(msg *ports.ScriptMessage) (*ports.ScriptResult
 	// Register mus.json module
 	registerJsonModule(L, musMod)
 
+	// Register mus.lingo module
+	registerLingoModule(L, musMod)
+
+	// Register mus.crypto module
+	registerCryptoModule(L, musMod)
+
 	musMod.RawSetString("uuid", L.NewFunction(func(L *lua.LState) int {
 		L.Push(lua.LString(uuid.New().String()))
 		return 1
@@ -232,12 +502,13 @@ func (e *LuaScriptEngine) Execute(msg *ports.ScriptMessage) (*ports.ScriptResult
 
 	L.SetGlobal("mus", musMod)
 
-	// Sandboxed require: only loads .lua files from scriptsDir/lib/
-	loaded := L.NewTable()
+	// Sandboxed require: only loads .lua files from scriptsDir/lib/. Modules
+	// are cached per execution, so each run starts from fresh module state.
 	L.SetGlobal("require", L.NewFunction(func(L *lua.LState) int {
 		modName := L.CheckString(1)
 
 		// Return cached module if already loaded
+		loaded := vm.call.loaded
 		if cached := loaded.RawGetString(modName); cached != lua.LNil {
 			L.Push(cached)
 			return 1
@@ -269,7 +540,7 @@ func (e *LuaScriptEngine) Execute(msg *ports.ScriptMessage) (*ports.ScriptResult
 		}
 
 		// Load and execute the module file
-		fn, err := L.LoadFile(modPath)
+		fn, err := e.loadFile(L, modPath)
 		if err != nil {
 			L.RaiseError("failed to load module %q: %s", modName, err.Error())
 			return 0
@@ -315,24 +586,44 @@ func (e *LuaScriptEngine) Execute(msg *ports.ScriptMessage) (*ports.ScriptResult
 			return 0
 		}
 
-		if err := L.DoFile(filePath); err != nil {
+		fn, err := e.loadFile(L, filePath)
+		if err == nil {
+			L.Push(fn)
+			err = L.PCall(0, 0, nil)
+		}
+		if err != nil {
 			L.RaiseError("dofile %q failed: %s", name, err.Error())
 			return 0
 		}
 		return 0
 	}))
 
-	if err := L.DoFile(path); err != nil {
-		return nil, fmt.Errorf("script %q execution failed: %w", msg.Subject, err)
-	}
+	vm.snapshot()
+	return vm, nil
+}
 
-	// Scripts must use mus.response() to produce a result.
-	// If they don't, the result is LVoid (no response).
-	if result == nil {
-		result = &ports.ScriptResult{Content: lingo.NewLVoid()}
+// scriptErrorCodes builds mus.errors: the MUS error codes a script may answer
+// with, by name (mus.response(nil, mus.errors.operationNotAllowed)).
+func scriptErrorCodes(L *lua.LState) *lua.LTable {
+	t := L.NewTable()
+	for name, code := range map[string]int32{
+		"unknown":                   smus.ErrUnknown,
+		"badParameter":              smus.ErrBadParameter,
+		"invalidMessageFormat":      smus.ErrInvalidMessageFormat,
+		"invalidServerCommand":      smus.ErrInvalidServerCommand,
+		"notPermittedWithUserLevel": smus.ErrNotPermittedWithUserLevel,
+		"serverInternalError":       smus.ErrServerInternalError,
+		"databaseError":             smus.ErrDatabaseError,
+		"databaseUserIDNotFound":    smus.ErrDatabaseUserIDNotFound,
+		"databaseDataNotFound":      smus.ErrDatabaseDataNotFound,
+		"operationNotAllowed":       smus.ErrOperationNotAllowed,
+		"requestedDataNotFound":     smus.ErrRequestedDataNotFound,
+		"messageContainsErrorInfo":  smus.ErrMessageContainsErrorInfo,
+		"dataConcurrencyError":      smus.ErrDataConcurrencyError,
+	} {
+		t.RawSetString(name, lua.LNumber(code))
 	}
-
-	return result, nil
+	return t
 }
 
 func containsDotDot(path string) bool {
@@ -0,0 +1,104 @@
+package outbound
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/scriptheader"
+)
+
+// scriptHeaderTag names the optional policy header of a client-invocable
+// script, in the style of the "-- @job" header:
+//
+//	-- @script level=80
+//	-- @script timeout=2 rate=5/10s
+//	-- @script level=20 timeout=500ms rate=1/m
+//
+// level is the minimum session user level, timeout overrides SCRIPT_TIMEOUT
+// (seconds, or a duration like 500ms) and rate caps each user's invocations
+// per window. The Dispatcher enforces level and rate; the engine applies the
+// timeout on every run.
+const scriptHeaderTag = "script"
+
+// headerError is a malformed script header, with the line it is on.
+type headerError struct {
+	line int
+	err  error
+}
+
+func (e *headerError) Error() string { return fmt.Sprintf("line %d: %v", e.line, e.err) }
+func (e *headerError) Unwrap() error { return e.err }
+
+// parseScriptHeader reads the @script header of src. A script without one
+// gets the zero policy.
+func parseScriptHeader(src []byte) (ports.ScriptPolicy, error) {
+	header, ok := scriptheader.Find(bytes.NewReader(src), scriptHeaderTag)
+	if !ok {
+		return ports.ScriptPolicy{}, nil
+	}
+	policy, err := parseScriptOptions(header.Options)
+	if err != nil {
+		return policy, &headerError{line: header.Line, err: err}
+	}
+	return policy, nil
+}
+
+func parseScriptOptions(options []scriptheader.Option) (ports.ScriptPolicy, error) {
+	var policy ports.ScriptPolicy
+	for _, option := range options {
+		key, value := option.Key, option.Value
+		var err error
+		switch key {
+		case "level":
+			policy.Level, err = strconv.Atoi(value)
+			if err == nil && policy.Level < 0 {
+				err = fmt.Errorf("negative")
+			}
+		case "timeout":
+			policy.Timeout, err = headerDuration(value)
+		case "rate":
+			policy.Rate, policy.RateWindow, err = parseRate(value)
+		}
+		if err != nil {
+			return ports.ScriptPolicy{}, fmt.Errorf("@script %s=%s: %v", key, value, err)
+		}
+	}
+	return policy, nil
+}
+
+// headerDuration reads plain seconds ("2") or a Go duration ("500ms").
+func headerDuration(v string) (time.Duration, error) {
+	if n, err := strconv.Atoi(v); err == nil {
+		v = strconv.Itoa(n) + "s"
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("want a positive duration")
+	}
+	return d, nil
+}
+
+// parseRate reads "<count>/<window>"; a bare unit window ("5/s") means one
+// of it.
+func parseRate(v string) (int, time.Duration, error) {
+	count, window, ok := strings.Cut(v, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("want <count>/<window>, e.g. 5/10s")
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 {
+		return 0, 0, fmt.Errorf("want a positive count")
+	}
+	if window != "" && strings.IndexAny(window[:1], "0123456789") < 0 {
+		window = "1" + window
+	}
+	d, err := headerDuration(window)
+	if err != nil {
+		return 0, 0, err
+	}
+	return n, d, nil
+}
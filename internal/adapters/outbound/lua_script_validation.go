@@ -0,0 +1,71 @@
+package outbound
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fsos-server/internal/domain/ports"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// ValidateScripts compiles every .lua file under the scripts directory —
+// client scripts, lib/ modules, hooks, interceptors, jobs and tests — into
+// the compiled-script cache and reports those that fail
+// (ports.ScriptValidator).
+func (e *LuaScriptEngine) ValidateScripts() ([]ports.ScriptProblem, error) {
+	var problems []ports.ScriptProblem
+	err := filepath.WalkDir(e.scriptsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".lua" {
+			return nil
+		}
+		if err := e.Reload(path); err != nil {
+			problems = append(problems, scriptProblem(path, err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Path < problems[j].Path })
+	return problems, nil
+}
+
+// DisableBrokenScripts hides subjects whose script never compiled from
+// HasScript (ports.ScriptValidator).
+func (e *LuaScriptEngine) DisableBrokenScripts() {
+	e.disableBroken.Store(true)
+}
+
+// scriptProblem turns a compile error into a report entry with its line.
+func scriptProblem(path string, err error) ports.ScriptProblem {
+	problem := ports.ScriptProblem{Path: path, Message: err.Error()}
+	var parseErr *parse.Error
+	var compileErr *lua.CompileError
+	var headerErr *headerError
+	switch {
+	case errors.As(err, &parseErr):
+		if parseErr.Pos.Line > 0 {
+			problem.Line = parseErr.Pos.Line
+		}
+		problem.Message = parseErr.Message
+		if parseErr.Token != "" {
+			problem.Message += " near '" + parseErr.Token + "'"
+		}
+	case errors.As(err, &compileErr):
+		problem.Line = compileErr.Line
+		problem.Message = compileErr.Message
+	case errors.As(err, &headerErr):
+		problem.Line = headerErr.line
+		problem.Message = headerErr.err.Error()
+	}
+	problem.Message = strings.TrimSpace(problem.Message)
+	return problem
+}
@@ -14,7 +14,7 @@ const systemScriptSender = "system.script"
 
 // registerServerModule builds mus.server. sender may be nil in contexts without
 // an outbound path (e.g. tests) — broadcast degrades to a no-op.
-func registerServerModule(L *lua.LState, musMod *lua.LTable, sessionStore ports.SessionStore, sender ports.MessageSender, logger ports.Logger) {
+func registerServerModule(L *lua.LState, musMod *lua.LTable, sessionStore ports.SessionStore, sender ports.MessageSender, logger ports.Logger, meter func() *scriptMeter) {
 	serverMod := L.NewTable()
 
 	serverMod.RawSetString("getUserCount", L.NewFunction(func(L *lua.LState) int {
@@ -47,6 +47,8 @@ func registerServerModule(L *lua.LState, musMod *lua.LTable, sessionStore ports.
 	serverMod.RawSetString("broadcast", L.NewFunction(func(L *lua.LState) int {
 		subject := L.CheckString(1)
 		lingoContent := lingo.LuaToLValue(L.Get(2))
+		meter().countSend(L, "mus.server.broadcast")
+		meter().checkMessage(L, "mus.server.broadcast", lingoContent)
 		if sender == nil {
 			return 0
 		}
@@ -0,0 +1,112 @@
+package outbound
+
+import (
+	"fmt"
+	"strings"
+
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// serverAttributePrefix marks session attributes the server owns (#movieID,
+// #userLevel). Scripts may read them but not write them, or a script could
+// hand out admin rights or move a user between movies behind the managers.
+const serverAttributePrefix = "#"
+
+// registerSessionModule builds mus.session over the ephemeral per-user session
+// attributes. sender returns the running script's sender; canActOn gates
+// access to any other user's session.
+func registerSessionModule(L *lua.LState, musMod *lua.LTable, sessionStore ports.SessionStore, sender func() string, canActOn func(actorID, targetUserID string) bool) {
+	sessionMod := L.NewTable()
+
+	checkTarget := func(L *lua.LState, fn string) string {
+		userID := L.CheckString(1)
+		if !canActOn(sender(), userID) {
+			L.RaiseError("mus.session.%s: %s may not access %s's session", fn, sender(), userID)
+		}
+		return userID
+	}
+	checkWritable := func(L *lua.LState, fn string, userID, attr string) error {
+		if strings.HasPrefix(attr, serverAttributePrefix) {
+			L.RaiseError("mus.session.%s: %s is a server attribute", fn, attr)
+		}
+		online, err := sessionStore.IsConnected(userID)
+		if err != nil {
+			return err
+		}
+		if !online {
+			return fmt.Errorf("user %q is not online", userID)
+		}
+		return nil
+	}
+
+	// mus.session.get(userID, attr) -> value|nil
+	sessionMod.RawSetString("get", L.NewFunction(func(L *lua.LState) int {
+		userID := checkTarget(L, "get")
+		attr := L.CheckString(2)
+		val, err := sessionStore.GetUserAttribute(userID, attr)
+		if err != nil || val == nil {
+			L.Push(lua.LNil)
+			return 1
+		}
+		L.Push(lingo.LValueToLua(L, val))
+		return 1
+	}))
+
+	// mus.session.set(userID, attr, value) -> true | false, err
+	sessionMod.RawSetString("set", L.NewFunction(func(L *lua.LState) int {
+		userID := checkTarget(L, "set")
+		attr := L.CheckString(2)
+		value := lingo.LuaToLValue(L.Get(3))
+		err := checkWritable(L, "set", userID, attr)
+		if err == nil {
+			err = sessionStore.SetUserAttribute(userID, attr, value)
+		}
+		return pushResult(L, err)
+	}))
+
+	// mus.session.delete(userID, attr) -> true | false, err
+	sessionMod.RawSetString("delete", L.NewFunction(func(L *lua.LState) int {
+		userID := checkTarget(L, "delete")
+		attr := L.CheckString(2)
+		err := checkWritable(L, "delete", userID, attr)
+		if err == nil {
+			err = sessionStore.DeleteUserAttribute(userID, attr)
+		}
+		return pushResult(L, err)
+	}))
+
+	// mus.session.getNames(userID) -> {attr, ...}
+	sessionMod.RawSetString("getNames", L.NewFunction(func(L *lua.LState) int {
+		userID := checkTarget(L, "getNames")
+		names, err := sessionStore.GetUserAttributeNames(userID)
+		if err != nil {
+			names = nil
+		}
+		L.Push(stringList(L, names))
+		return 1
+	}))
+
+	musMod.RawSetString("session", sessionMod)
+}
+
+// pushResult returns true, or false and the error message, to Lua.
+func pushResult(L *lua.LState, err error) int {
+	if err != nil {
+		L.Push(lua.LFalse)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	L.Push(lua.LTrue)
+	return 1
+}
+
+func stringList(L *lua.LState, values []string) *lua.LTable {
+	tbl := L.NewTable()
+	for _, v := range values {
+		tbl.Append(lua.LString(v))
+	}
+	return tbl
+}
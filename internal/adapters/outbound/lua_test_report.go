@@ -0,0 +1,104 @@
+package outbound
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteScriptTestTAP reports results in TAP version 13, a failure's message
+// in the YAML block under its line.
+func WriteScriptTestTAP(w io.Writer, results []ScriptTestResult) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "TAP version 13\n1..%d\n", len(results))
+	for i, r := range results {
+		status := "ok"
+		if !r.Passed() {
+			status = "not ok"
+		}
+		fmt.Fprintf(&b, "%s %d - %s: %s\n", status, i+1, r.File, r.Name)
+		if r.Passed() {
+			continue
+		}
+		b.WriteString("  ---\n  message: |\n")
+		for _, line := range strings.Split(r.Err.Error(), "\n") {
+			b.WriteString("    " + line + "\n")
+		}
+		fmt.Fprintf(&b, "  duration_ms: %d\n  ...\n", r.Duration.Milliseconds())
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteScriptTestJUnit reports results as JUnit XML, one testsuite per file.
+func WriteScriptTestJUnit(w io.Writer, results []ScriptTestResult) error {
+	doc := junitSuites{Tests: len(results)}
+	index := make(map[string]int)
+	var durations []time.Duration
+	for _, r := range results {
+		i, ok := index[r.File]
+		if !ok {
+			i = len(doc.Suites)
+			index[r.File] = i
+			doc.Suites = append(doc.Suites, junitSuite{Name: r.File})
+			durations = append(durations, 0)
+		}
+		durations[i] += r.Duration
+		suite := &doc.Suites[i]
+		c := junitCase{
+			Name:      r.Name,
+			ClassName: strings.TrimSuffix(r.File, ".lua"),
+			Time:      fmt.Sprintf("%.3f", r.Duration.Seconds()),
+		}
+		if !r.Passed() {
+			c.Failure = &junitFailure{Message: luaErrorMessage(r.Err), Body: r.Err.Error()}
+			suite.Failures++
+			doc.Failures++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, c)
+	}
+	for i, d := range durations {
+		doc.Suites[i].Time = fmt.Sprintf("%.3f", d.Seconds())
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
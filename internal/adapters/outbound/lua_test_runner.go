@@ -0,0 +1,405 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// TestScriptSuffix marks Lua unit-test files (scripts/<name>_test.lua). The
+// engine never runs them as scripts; ScriptTestRunner does.
+const TestScriptSuffix = "_test"
+
+// defaultTestSender is the sender test.run uses when the test names none.
+const defaultTestSender = "tester"
+
+// ScriptTestDeps are the services one test case runs against. Every case gets
+// its own, so no state leaks between cases; Close releases them.
+type ScriptTestDeps struct {
+	DB           ports.DBAdapter
+	QueryBuilder ports.QueryBuilder
+	SessionStore ports.SessionStore
+	Cache        ports.Cache
+	Queue        ports.QueuePublisher
+	Close        func()
+}
+
+// ScriptTestResult is the outcome of one test case. A file that fails to load
+// is reported as a single failed case named after the file.
+type ScriptTestResult struct {
+	File     string // relative to the scripts directory
+	Name     string
+	Err      error // nil when the case passed
+	Duration time.Duration
+}
+
+func (r ScriptTestResult) Passed() bool { return r.Err == nil }
+
+// ScriptTestRunner runs *_test.lua files in the LuaScriptEngine sandbox. A
+// test file declares cases with test.case(name, fn); each case runs on a
+// fresh VM over fresh ScriptTestDeps, re-running the file's top level first.
+// Inside a case, test.run(subject, {sender=, content=}) executes a script the
+// way the Dispatcher would and returns what it answered, test.sent() lists
+// what it sent with mus.sendMessage, and mus.getSender/getContent may be
+// replaced like any other mus function.
+type ScriptTestRunner struct {
+	scriptsDir     string
+	logger         ports.Logger
+	timeoutSeconds int
+	newDeps        func() (*ScriptTestDeps, error)
+}
+
+func NewScriptTestRunner(scriptsDir string, logger ports.Logger, timeoutSeconds int, newDeps func() (*ScriptTestDeps, error)) *ScriptTestRunner {
+	return &ScriptTestRunner{
+		scriptsDir:     scriptsDir,
+		logger:         logger,
+		timeoutSeconds: timeoutSeconds,
+		newDeps:        newDeps,
+	}
+}
+
+// Discover returns the test files under the scripts directory, relative to
+// it and sorted.
+func (r *ScriptTestRunner) Discover() ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(r.scriptsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), TestScriptSuffix+".lua") {
+			return nil
+		}
+		rel, err := filepath.Rel(r.scriptsDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Run runs every case of files, in order.
+func (r *ScriptTestRunner) Run(files []string) []ScriptTestResult {
+	var results []ScriptTestResult
+	for _, file := range files {
+		var names []string
+		start := time.Now()
+		err := r.withFile(file, func(_ *luaVM, cases []luaTestCase) error {
+			for _, c := range cases {
+				names = append(names, c.name)
+			}
+			return nil
+		})
+		if err != nil {
+			results = append(results, ScriptTestResult{File: file, Name: file, Err: err, Duration: time.Since(start)})
+			continue
+		}
+		for i, name := range names {
+			results = append(results, r.runCase(file, i, name))
+		}
+	}
+	return results
+}
+
+func (r *ScriptTestRunner) runCase(file string, idx int, name string) ScriptTestResult {
+	start := time.Now()
+	err := r.withFile(file, func(vm *luaVM, cases []luaTestCase) error {
+		if idx >= len(cases) || cases[idx].name != name {
+			return fmt.Errorf("test cases of %s changed between runs", file)
+		}
+		vm.L.Push(cases[idx].fn)
+		return vm.L.PCall(0, 0, nil)
+	})
+	return ScriptTestResult{File: file, Name: name, Err: err, Duration: time.Since(start)}
+}
+
+// withFile loads file into a fresh VM over fresh deps, then calls run with the
+// cases it declared, all within the script timeout.
+func (r *ScriptTestRunner) withFile(file string, run func(*luaVM, []luaTestCase) error) error {
+	deps, err := r.newDeps()
+	if err != nil {
+		return fmt.Errorf("test dependencies: %w", err)
+	}
+	if deps.Close != nil {
+		defer deps.Close()
+	}
+
+	sent := &scriptTestSender{}
+	engine := NewLuaScriptEngine(r.scriptsDir, r.logger, r.timeoutSeconds, deps.Queue, sent, deps.DB, deps.QueryBuilder, deps.SessionStore, deps.Cache, nil)
+	vm, err := engine.newVM()
+	if err != nil {
+		return err
+	}
+	L := vm.L
+	defer L.Close()
+
+	vm.call = &luaCall{
+		msg: &ports.ScriptMessage{
+			Subject:  strings.TrimSuffix(file, ".lua"),
+			SenderID: defaultTestSender,
+			Content:  lingo.NewLVoid(),
+		},
+		script: filepath.Join(r.scriptsDir, filepath.FromSlash(file)),
+		loaded: L.NewTable(),
+	}
+	var cases []luaTestCase
+	registerTestModule(L, engine, vm, deps.SessionStore, sent, &cases)
+
+	ctx, cancel := context.WithTimeout(context.Background(), engine.scriptTimeout)
+	defer cancel()
+	L.SetContext(ctx)
+
+	fn, err := engine.loadFile(L, filepath.Join(r.scriptsDir, filepath.FromSlash(file)))
+	if err != nil {
+		return err
+	}
+	L.Push(fn)
+	if err := L.PCall(0, 0, nil); err != nil {
+		return err
+	}
+	return run(vm, cases)
+}
+
+type luaTestCase struct {
+	name string
+	fn   *lua.LFunction
+}
+
+// scriptTestSender records the messages scripts send, for test.sent().
+type scriptTestSender struct {
+	mu   sync.Mutex
+	sent []scriptTestMessage
+}
+
+type scriptTestMessage struct {
+	from, routingSender, recipient, subject string
+	content                                 lingo.LValue
+}
+
+func (s *scriptTestSender) SendMessage(senderID, recipientID, subject string, content lingo.LValue) error {
+	return s.SendMessageFrom(senderID, senderID, recipientID, subject, content)
+}
+
+func (s *scriptTestSender) SendMessageFrom(wireFrom, routingSender, recipientID, subject string, content lingo.LValue) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, scriptTestMessage{wireFrom, routingSender, recipientID, subject, content})
+	return nil
+}
+
+func (s *scriptTestSender) messages() []scriptTestMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]scriptTestMessage(nil), s.sent...)
+}
+
+// registerTestModule sets the test global of a test file's VM.
+func registerTestModule(L *lua.LState, e *LuaScriptEngine, vm *luaVM, sessions ports.SessionStore, sent *scriptTestSender, cases *[]luaTestCase) {
+	testMod := L.NewTable()
+
+	// test.case(name, fn) declares a case.
+	testMod.RawSetString("case", L.NewFunction(func(L *lua.LState) int {
+		*cases = append(*cases, luaTestCase{name: L.CheckString(1), fn: L.CheckFunction(2)})
+		return 0
+	}))
+
+	// test.run(subject, [{sender=, content=, system=}]) -> result | nil, err.
+	// result is {content, errCode, subject, noReply, replies}, what
+	// mus.response, mus.noResponse and mus.reply left for the Dispatcher.
+	testMod.RawSetString("run", L.NewFunction(func(L *lua.LState) int {
+		subject := L.CheckString(1)
+		opts := L.OptTable(2, L.NewTable())
+		msg := &ports.ScriptMessage{
+			Subject:  subject,
+			SenderID: defaultTestSender,
+			Content:  lingo.LuaToLValue(opts.RawGetString("content")),
+		}
+		if s, ok := opts.RawGetString("sender").(lua.LString); ok {
+			msg.SenderID = string(s)
+		}
+		msg.System = lua.LVAsBool(opts.RawGetString("system"))
+
+		path, ok := e.resolveScript(subject)
+		if !ok {
+			L.ArgError(1, "no script for subject "+subject)
+			return 0
+		}
+		proto, err := e.compile(path)
+		if err != nil {
+			L.Push(lua.LNil)
+			L.Push(lua.LString(err.Error()))
+			return 2
+		}
+
+		prev := vm.call
+		call := &luaCall{msg: msg, script: path, loaded: L.NewTable()}
+		vm.call = call
+		L.Push(L.NewFunctionFromProto(proto))
+		err = L.PCall(0, 0, nil)
+		vm.call = prev
+		if err != nil {
+			L.Push(lua.LNil)
+			L.Push(lua.LString(luaErrorMessage(err)))
+			return 2
+		}
+		L.Push(scriptResultTable(L, call.ensureResult()))
+		return 1
+	}))
+
+	// test.sent() -> {{from, sender, to, subject, content}, ...}, every
+	// mus.sendMessage so far.
+	testMod.RawSetString("sent", L.NewFunction(func(L *lua.LState) int {
+		list := L.NewTable()
+		for _, m := range sent.messages() {
+			t := L.NewTable()
+			t.RawSetString("from", lua.LString(m.from))
+			t.RawSetString("sender", lua.LString(m.routingSender))
+			t.RawSetString("to", lua.LString(m.recipient))
+			t.RawSetString("subject", lua.LString(m.subject))
+			t.RawSetString("content", lingo.LValueToLua(L, m.content))
+			list.Append(t)
+		}
+		L.Push(list)
+		return 1
+	}))
+
+	// test.connect(userID, [ip]) registers a session, so mus.session and
+	// mus.server see userID online.
+	testMod.RawSetString("connect", L.NewFunction(func(L *lua.LState) int {
+		userID := L.CheckString(1)
+		ip := L.OptString(2, "127.0.0.1")
+		if sessions == nil {
+			L.RaiseError("test.connect: no session store")
+			return 0
+		}
+		if err := sessions.RegisterConnection(userID, ip); err != nil {
+			L.RaiseError("test.connect: %s", err.Error())
+		}
+		return 0
+	}))
+
+	// test.equal(actual, expected, [msg]) compares tables by content.
+	testMod.RawSetString("equal", L.NewFunction(func(L *lua.LState) int {
+		actual, expected := L.CheckAny(1), L.CheckAny(2)
+		if !luaDeepEqual(actual, expected) {
+			L.RaiseError("%sexpected %s, got %s", assertPrefix(L, 3), luaRepr(expected), luaRepr(actual))
+		}
+		return 0
+	}))
+
+	// test.ok(value, [msg]) fails on nil and false.
+	testMod.RawSetString("ok", L.NewFunction(func(L *lua.LState) int {
+		if !lua.LVAsBool(L.Get(1)) {
+			L.RaiseError("%sexpected a true value, got %s", assertPrefix(L, 2), luaRepr(L.Get(1)))
+		}
+		return 0
+	}))
+
+	// test.fail([msg])
+	testMod.RawSetString("fail", L.NewFunction(func(L *lua.LState) int {
+		L.RaiseError("%s", L.OptString(1, "failed"))
+		return 0
+	}))
+
+	L.SetGlobal("test", testMod)
+}
+
+func scriptResultTable(L *lua.LState, result *ports.ScriptResult) *lua.LTable {
+	t := L.NewTable()
+	t.RawSetString("content", lingo.LValueToLua(L, result.Content))
+	t.RawSetString("errCode", lua.LNumber(result.ErrCode))
+	t.RawSetString("subject", lua.LString(result.Subject))
+	t.RawSetString("noReply", lua.LBool(result.NoReply))
+	replies := L.NewTable()
+	for _, r := range result.Replies {
+		reply := L.NewTable()
+		reply.RawSetString("subject", lua.LString(r.Subject))
+		reply.RawSetString("content", lingo.LValueToLua(L, r.Content))
+		reply.RawSetString("errCode", lua.LNumber(r.ErrCode))
+		reply.RawSetString("recipient", lua.LString(r.Recipient))
+		replies.Append(reply)
+	}
+	t.RawSetString("replies", replies)
+	return t
+}
+
+func assertPrefix(L *lua.LState, idx int) string {
+	if msg := L.OptString(idx, ""); msg != "" {
+		return msg + ": "
+	}
+	return ""
+}
+
+// luaErrorMessage is a Lua error without its stack trace.
+func luaErrorMessage(err error) string {
+	var apiErr *lua.ApiError
+	if errors.As(err, &apiErr) {
+		return apiErr.Object.String()
+	}
+	return err.Error()
+}
+
+func luaDeepEqual(a, b lua.LValue) bool {
+	ta, ok := a.(*lua.LTable)
+	tb, okB := b.(*lua.LTable)
+	if !ok || !okB {
+		return a.Type() == b.Type() && a.String() == b.String()
+	}
+	if ta == tb {
+		return true
+	}
+	equal := true
+	count := 0
+	ta.ForEach(func(k, v lua.LValue) {
+		count++
+		if equal && !luaDeepEqual(v, tb.RawGet(k)) {
+			equal = false
+		}
+	})
+	if !equal {
+		return false
+	}
+	tb.ForEach(func(lua.LValue, lua.LValue) { count-- })
+	return count == 0
+}
+
+// luaRepr renders v for assertion messages, tables by content.
+func luaRepr(v lua.LValue) string {
+	switch v := v.(type) {
+	case lua.LString:
+		return fmt.Sprintf("%q", string(v))
+	case *lua.LTable:
+		var parts []string
+		n := v.Len()
+		for i := 1; i <= n; i++ {
+			parts = append(parts, luaRepr(v.RawGetInt(i)))
+		}
+		var keyed []string
+		v.ForEach(func(k, val lua.LValue) {
+			if num, ok := k.(lua.LNumber); ok && float64(num) == float64(int(num)) && int(num) >= 1 && int(num) <= n {
+				return
+			}
+			keyed = append(keyed, fmt.Sprintf("%s=%s", k.String(), luaRepr(val)))
+		})
+		sort.Strings(keyed)
+		return "{" + strings.Join(append(parts, keyed...), ", ") + "}"
+	default:
+		return v.String()
+	}
+}
@@ -1,11 +1,30 @@
 package outbound
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	lua "github.com/yuin/gopher-lua"
 )
 
+// monotonicStart anchors mus.time.monotonic(); time.Since on it reads the
+// monotonic clock, which wall-clock changes do not move.
+var monotonicStart = time.Now()
+
+// strftimeDirectives maps the layout directives mus.time.format and parse
+// take to Go layout elements.
+var strftimeDirectives = map[byte]string{
+	'Y': "2006", 'y': "06", 'm': "01", 'd': "02", 'e': "_2", 'j': "002",
+	'H': "15", 'I': "03", 'M': "04", 'S': "05", 'p': "PM",
+	'b': "Jan", 'B': "January", 'a': "Mon", 'A': "Monday",
+	'Z': "MST", 'z': "-0700",
+}
+
+// registerTimeModule builds mus.time. Timestamps are Unix seconds, fractional
+// where the precision matters; tz arguments are IANA zone names and default to
+// the server's local zone, as job schedules do. Layouts use strftime
+// directives (%Y-%m-%d %H:%M:%S), RFC 3339 when omitted.
 func registerTimeModule(L *lua.LState, musMod *lua.LTable) {
 	timeMod := L.NewTable()
 
@@ -15,5 +34,210 @@ func registerTimeModule(L *lua.LState, musMod *lua.LTable) {
 		return 1
 	}))
 
+	// mus.time.nowMs() -> Unix timestamp in milliseconds
+	timeMod.RawSetString("nowMs", L.NewFunction(func(L *lua.LState) int {
+		L.Push(lua.LNumber(time.Now().UnixMilli()))
+		return 1
+	}))
+
+	// mus.time.monotonic() -> milliseconds from an arbitrary start; only
+	// differences mean anything, and they are immune to clock changes.
+	timeMod.RawSetString("monotonic", L.NewFunction(func(L *lua.LState) int {
+		L.Push(lua.LNumber(float64(time.Since(monotonicStart)) / float64(time.Millisecond)))
+		return 1
+	}))
+
+	// mus.time.format(ts, [layout], [tz]) -> string
+	timeMod.RawSetString("format", L.NewFunction(func(L *lua.LState) int {
+		t := luaTime(L, 1).In(luaLocation(L, 3))
+		layout := L.OptString(2, "")
+		if layout == "" {
+			L.Push(lua.LString(t.Format(time.RFC3339)))
+			return 1
+		}
+		out, err := strftime(t, layout)
+		if err != nil {
+			L.ArgError(2, err.Error())
+			return 0
+		}
+		L.Push(lua.LString(out))
+		return 1
+	}))
+
+	// mus.time.parse(s, [layout], [tz]) -> ts | nil, err. A layout without a
+	// zone reads the time in tz.
+	timeMod.RawSetString("parse", L.NewFunction(func(L *lua.LState) int {
+		value := L.CheckString(1)
+		layout := time.RFC3339
+		if s := L.OptString(2, ""); s != "" {
+			var err error
+			if layout, err = strftimeLayout(s); err != nil {
+				L.ArgError(2, err.Error())
+				return 0
+			}
+		}
+		t, err := time.ParseInLocation(layout, value, luaLocation(L, 3))
+		if err != nil {
+			L.Push(lua.LNil)
+			L.Push(lua.LString(err.Error()))
+			return 2
+		}
+		L.Push(unixSeconds(t))
+		return 1
+	}))
+
+	// mus.time.startOfDay(ts, [tz]) -> ts of that day's midnight in tz
+	timeMod.RawSetString("startOfDay", L.NewFunction(func(L *lua.LState) int {
+		L.Push(unixSeconds(startOfDay(luaTime(L, 1).In(luaLocation(L, 2)))))
+		return 1
+	}))
+
+	// mus.time.startOfWeek(ts, [tz], [firstDay]) -> ts of the week's first
+	// midnight; weeks start on firstDay ("monday" unless given).
+	timeMod.RawSetString("startOfWeek", L.NewFunction(func(L *lua.LState) int {
+		day := startOfDay(luaTime(L, 1).In(luaLocation(L, 2)))
+		first, ok := weekdays[strings.ToLower(L.OptString(3, "monday"))]
+		if !ok {
+			L.ArgError(3, "want a weekday name")
+			return 0
+		}
+		back := (int(day.Weekday()) - int(first) + 7) % 7
+		L.Push(unixSeconds(day.AddDate(0, 0, -back)))
+		return 1
+	}))
+
+	// mus.time.addDays(ts, n, [tz]) -> ts n calendar days later, at the same
+	// wall-clock time in tz even across a DST change
+	timeMod.RawSetString("addDays", L.NewFunction(func(L *lua.LState) int {
+		t := luaTime(L, 1).In(luaLocation(L, 3))
+		L.Push(unixSeconds(t.AddDate(0, 0, L.CheckInt(2))))
+		return 1
+	}))
+
+	// mus.time.daysBetween(a, b, [tz]) -> calendar days from a's date to b's
+	// in tz: 1 for yesterday and today, however few hours apart.
+	timeMod.RawSetString("daysBetween", L.NewFunction(func(L *lua.LState) int {
+		loc := luaLocation(L, 3)
+		a := startOfDay(luaTime(L, 1).In(loc))
+		b := startOfDay(luaTime(L, 2).In(loc))
+		ay, am, ad := a.Date()
+		by, bm, bd := b.Date()
+		// Whole days between the dates as UTC midnights, which DST can't skew.
+		days := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC).Sub(time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)) / (24 * time.Hour)
+		L.Push(lua.LNumber(days))
+		return 1
+	}))
+
+	// mus.time.parseDuration("1h30m") -> seconds | nil, err
+	timeMod.RawSetString("parseDuration", L.NewFunction(func(L *lua.LState) int {
+		d, err := time.ParseDuration(L.CheckString(1))
+		if err != nil {
+			L.Push(lua.LNil)
+			L.Push(lua.LString(err.Error()))
+			return 2
+		}
+		L.Push(lua.LNumber(d.Seconds()))
+		return 1
+	}))
+
+	// mus.time.formatDuration(seconds) -> "1h30m0s"
+	timeMod.RawSetString("formatDuration", L.NewFunction(func(L *lua.LState) int {
+		d := time.Duration(float64(L.CheckNumber(1)) * float64(time.Second))
+		L.Push(lua.LString(d.String()))
+		return 1
+	}))
+
 	musMod.RawSetString("time", timeMod)
 }
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday,
+	"wednesday": time.Wednesday, "thursday": time.Thursday, "friday": time.Friday,
+	"saturday": time.Saturday,
+}
+
+// luaTime reads Unix seconds, fractional or not, at argument n.
+func luaTime(L *lua.LState, n int) time.Time {
+	secs := float64(L.CheckNumber(n))
+	return time.Unix(0, int64(secs*float64(time.Second)))
+}
+
+// luaLocation reads an optional IANA zone name at argument n.
+func luaLocation(L *lua.LState, n int) *time.Location {
+	name := L.OptString(n, "")
+	if name == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		L.ArgError(n, err.Error())
+	}
+	return loc
+}
+
+// unixSeconds is t as Unix seconds, with a fraction only when t has one.
+func unixSeconds(t time.Time) lua.LNumber {
+	if t.Nanosecond() == 0 {
+		return lua.LNumber(t.Unix())
+	}
+	return lua.LNumber(float64(t.UnixNano()) / float64(time.Second))
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// strftime formats t by layout, directive by directive, so literal text is
+// copied as is.
+func strftime(t time.Time, layout string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(layout); i++ {
+		if layout[i] != '%' {
+			b.WriteByte(layout[i])
+			continue
+		}
+		if i++; i == len(layout) {
+			return "", fmt.Errorf("layout ends in %%")
+		}
+		if layout[i] == '%' {
+			b.WriteByte('%')
+			continue
+		}
+		elem, ok := strftimeDirectives[layout[i]]
+		if !ok {
+			return "", fmt.Errorf("unknown directive %%%c", layout[i])
+		}
+		b.WriteString(t.Format(elem))
+	}
+	return b.String(), nil
+}
+
+// strftimeLayout turns layout into a Go layout for parsing. Go reads digits
+// in a layout as elements, so literal text may not contain any.
+func strftimeLayout(layout string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(layout); i++ {
+		c := layout[i]
+		if c != '%' {
+			if c >= '0' && c <= '9' {
+				return "", fmt.Errorf("digit %q outside a directive", c)
+			}
+			b.WriteByte(c)
+			continue
+		}
+		if i++; i == len(layout) {
+			return "", fmt.Errorf("layout ends in %%")
+		}
+		if layout[i] == '%' {
+			b.WriteByte('%')
+			continue
+		}
+		elem, ok := strftimeDirectives[layout[i]]
+		if !ok {
+			return "", fmt.Errorf("unknown directive %%%c", layout[i])
+		}
+		b.WriteString(elem)
+	}
+	return b.String(), nil
+}
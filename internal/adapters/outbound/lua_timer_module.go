@@ -0,0 +1,46 @@
+package outbound
+
+import (
+	"time"
+
+	"fsos-server/internal/domain/ports"
+	"fsos-server/internal/domain/types/lingo"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// registerTimerModule builds mus.timer over timers:
+//
+//	mus.timer.after(seconds, subject, [content]) -> id | nil, err
+//	mus.timer.cancel(id)                         -> true | false, err
+//
+// The timed script runs once, as a server run for the setter, and gets
+// content from mus.getContent(). A script may cancel only the timers set for
+// its own sender, unless the server started its run.
+func registerTimerModule(L *lua.LState, musMod *lua.LTable, timers ports.ScriptTimers, msg func() *ports.ScriptMessage) {
+	timerMod := L.NewTable()
+
+	timerMod.RawSetString("after", L.NewFunction(func(L *lua.LState) int {
+		seconds := float64(L.CheckNumber(1))
+		subject := L.CheckString(2)
+		content := lingo.LuaToLValue(L.Get(3))
+		id, err := timers.After(time.Duration(seconds*float64(time.Second)), subject, msg().SenderID, content)
+		if err != nil {
+			L.Push(lua.LNil)
+			L.Push(lua.LString(err.Error()))
+			return 2
+		}
+		L.Push(lua.LString(id))
+		return 1
+	}))
+
+	timerMod.RawSetString("cancel", L.NewFunction(func(L *lua.LState) int {
+		senderID := msg().SenderID
+		if msg().System {
+			senderID = ""
+		}
+		return pushResult(L, timers.Cancel(L.CheckString(1), senderID))
+	}))
+
+	musMod.RawSetString("timer", timerMod)
+}
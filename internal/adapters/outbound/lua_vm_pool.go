@@ -0,0 +1,235 @@
+package outbound
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"fsos-server/internal/domain/ports"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// compiledScript is a parsed and compiled script file, stamped with the mtime
+// and size it was read at, with the policy of its @script header. Protos are
+// immutable and shared by every VM. When a new version of a file fails to
+// compile, err records why and proto and policy stay the last good version
+// (proto is nil when there never was one).
+type compiledScript struct {
+	modTime time.Time
+	size    int64
+	proto   *lua.FunctionProto
+	policy  ports.ScriptPolicy
+	err     error
+}
+
+func (c *compiledScript) current(info os.FileInfo) bool {
+	return c.modTime.Equal(info.ModTime()) && c.size == info.Size()
+}
+
+// compile returns the function proto of the .lua file at path, compiling it
+// only when the file changed since it was last read.
+func (e *LuaScriptEngine) compile(path string) (*lua.FunctionProto, error) {
+	cached, err := e.compiled(path)
+	if err != nil {
+		return nil, err
+	}
+	return cached.proto, nil
+}
+
+// compiled is compile returning the whole cache entry, which always has a
+// proto when err is nil.
+func (e *LuaScriptEngine) compiled(path string) (*compiledScript, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	e.protoMu.RLock()
+	cached := e.protos[path]
+	e.protoMu.RUnlock()
+	if cached == nil || !cached.current(info) {
+		cached, _ = e.recompile(path, info, cached)
+	}
+	if cached.proto == nil {
+		return nil, cached.err
+	}
+	return cached, nil
+}
+
+// recompile reads path into the cache. A version that does not compile keeps
+// serving the last good proto, and is remembered so it is not re-parsed on
+// every call; the returned error is the compile error either way.
+func (e *LuaScriptEngine) recompile(path string, info os.FileInfo, prev *compiledScript) (*compiledScript, error) {
+	entry := &compiledScript{modTime: info.ModTime(), size: info.Size()}
+	entry.proto, entry.policy, entry.err = compileFile(path)
+	if entry.err != nil && prev != nil && prev.proto != nil {
+		entry.proto, entry.policy = prev.proto, prev.policy
+		e.logger.Warn("Script does not compile; keeping last good version", map[string]interface{}{
+			"path":  path,
+			"error": entry.err.Error(),
+		})
+	}
+
+	e.protoMu.Lock()
+	e.protos[path] = entry
+	e.protoMu.Unlock()
+	return entry, entry.err
+}
+
+// compileFile compiles the script at path and reads its @script header; a bad
+// header fails the compile like a syntax error.
+func compileFile(path string) (*lua.FunctionProto, ports.ScriptPolicy, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, ports.ScriptPolicy{}, err
+	}
+	policy, err := parseScriptHeader(src)
+	if err != nil {
+		return nil, ports.ScriptPolicy{}, fmt.Errorf("%s: %w", path, err)
+	}
+	chunk, err := parse.Parse(bytes.NewReader(src), path)
+	if err != nil {
+		return nil, ports.ScriptPolicy{}, err
+	}
+	proto, err := lua.Compile(chunk, path)
+	return proto, policy, err
+}
+
+// Reload recompiles the file at path now (ports.ScriptReloader). When it does
+// not compile the error is returned and executions keep using the last good
+// version.
+func (e *LuaScriptEngine) Reload(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	e.protoMu.RLock()
+	prev := e.protos[path]
+	e.protoMu.RUnlock()
+	_, err = e.recompile(path, info, prev)
+	return err
+}
+
+// Forget drops the cached version of a deleted file (ports.ScriptReloader).
+func (e *LuaScriptEngine) Forget(path string) {
+	e.protoMu.Lock()
+	delete(e.protos, path)
+	e.protoMu.Unlock()
+}
+
+// loadFile is L.LoadFile backed by the proto cache.
+func (e *LuaScriptEngine) loadFile(L *lua.LState, path string) (*lua.LFunction, error) {
+	proto, err := e.compile(path)
+	if err != nil {
+		return nil, err
+	}
+	return L.NewFunctionFromProto(proto), nil
+}
+
+// luaCall is the state of one execution, read by the mus closures of the VM
+// running it.
+type luaCall struct {
+	msg     *ports.ScriptMessage
+	script  string // path of the running script
+	result  *ports.ScriptResult
+	verdict *ports.InterceptVerdict
+	loaded  *lua.LTable  // require cache
+	depth   int          // mus.call frames above this execution
+	meter   *scriptMeter // nil when nothing is limited, as in script tests
+}
+
+func (c *luaCall) ensureResult() *ports.ScriptResult {
+	if c.result == nil {
+		c.result = &ports.ScriptResult{}
+	}
+	return c.result
+}
+
+// luaVM is a pooled, fully set-up LState. pristine records every table
+// reachable from the globals (and the string metatable) right after setup;
+// reset restores them, so globals a script defines, library functions it
+// replaces and fields it adds to mus are gone before the next execution.
+type luaVM struct {
+	L        *lua.LState
+	mus      *lua.LTable
+	call     *luaCall
+	pristine map[*lua.LTable]tableSnapshot
+	bindGen  int // engine bindings the mus module was built with
+}
+
+type tableSnapshot struct {
+	fields    map[lua.LValue]lua.LValue
+	metatable lua.LValue
+}
+
+func (e *LuaScriptEngine) acquireVM() (*luaVM, error) {
+	if vm, ok := e.vms.Get().(*luaVM); ok {
+		if vm.bindGen == e.bindings().gen {
+			return vm, nil
+		}
+		vm.L.Close() // built before the latest Use* binding
+	}
+	vm, err := e.newVM()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Lua state: %w", err)
+	}
+	return vm, nil
+}
+
+func (e *LuaScriptEngine) releaseVM(vm *luaVM) {
+	vm.reset()
+	e.vms.Put(vm)
+}
+
+func (vm *luaVM) snapshot() {
+	vm.pristine = make(map[*lua.LTable]tableSnapshot)
+	vm.record(vm.L.G.Global)
+	if mt, ok := vm.L.GetMetatable(lua.LString("")).(*lua.LTable); ok {
+		vm.record(mt)
+	}
+}
+
+func (vm *luaVM) record(t *lua.LTable) {
+	if _, seen := vm.pristine[t]; seen {
+		return
+	}
+	snap := tableSnapshot{fields: make(map[lua.LValue]lua.LValue), metatable: vm.L.GetMetatable(t)}
+	vm.pristine[t] = snap
+	t.ForEach(func(k, v lua.LValue) {
+		snap.fields[k] = v
+	})
+	for _, v := range snap.fields {
+		if sub, ok := v.(*lua.LTable); ok {
+			vm.record(sub)
+		}
+	}
+	if mt, ok := snap.metatable.(*lua.LTable); ok {
+		vm.record(mt)
+	}
+}
+
+func (vm *luaVM) reset() {
+	vm.L.SetTop(0)
+	for t, snap := range vm.pristine {
+		var extra []lua.LValue
+		t.ForEach(func(k, v lua.LValue) {
+			if _, ok := snap.fields[k]; !ok {
+				extra = append(extra, k)
+			}
+		})
+		for _, k := range extra {
+			t.RawSet(k, lua.LNil)
+		}
+		for k, v := range snap.fields {
+			if t.RawGet(k) != v {
+				t.RawSet(k, v)
+			}
+		}
+		if vm.L.GetMetatable(t) != snap.metatable {
+			vm.L.SetMetatable(t, snap.metatable)
+		}
+	}
+}
@@ -70,6 +70,25 @@ func (c *MemoryCache) Set(key string, value []byte, ttl time.Duration) error {
 	return nil
 }
 
+func (c *MemoryCache) SetNX(key string, value []byte, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[key]; ok && !entry.expired() {
+		return false, nil
+	}
+
+	copied := make([]byte, len(value))
+	copy(copied, value)
+
+	entry := &cacheEntry{value: copied}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	c.entries[key] = entry
+	return true, nil
+}
+
 func (c *MemoryCache) sweep() {
 	for k, entry := range c.entries {
 		if entry.expired() {
@@ -65,6 +65,11 @@ func (c *RedisCache) Set(key string, value []byte, ttl time.Duration) error {
 	return c.client.Set(ctx, c.key(key), value, ttl).Err()
 }
 
+func (c *RedisCache) SetNX(key string, value []byte, ttl time.Duration) (bool, error) {
+	ctx := context.Background()
+	return c.client.SetNX(ctx, c.key(key), value, ttl).Result()
+}
+
 func (c *RedisCache) Delete(key string) error {
 	ctx := context.Background()
 	return c.client.Del(ctx, c.key(key)).Err()
@@ -3,6 +3,7 @@ package outbound
 import (
 	"database/sql"
 	"fmt"
+	"math"
 	"regexp"
 	"strings"
 	"time"
@@ -118,6 +119,69 @@ func (d *sqlDB) DeleteApplication(appName string) error {
 	return nil
 }
 
+func (d *sqlDB) ListApplications(offset, limit int) ([]string, error) {
+	offset, limit = pageBounds(offset, limit)
+	return d.queryNames(d.dialect.Rebind("SELECT name FROM applications ORDER BY name LIMIT ? OFFSET ?"), limit, offset)
+}
+
+func (d *sqlDB) ListUsers(offset, limit int) ([]ports.User, error) {
+	offset, limit = pageBounds(offset, limit)
+	rows, err := d.db.Query(
+		d.dialect.Rebind("SELECT id, uuid, username, password_hash, user_level, created_at FROM users ORDER BY username LIMIT ? OFFSET ?"),
+		limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []ports.User
+	for rows.Next() {
+		var u ports.User
+		if err := rows.Scan(&u.ID, &u.UUID, &u.Username, &u.PasswordHash, &u.UserLevel, &u.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (d *sqlDB) CountUsers() (int, error) {
+	var n int
+	err := d.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&n)
+	return n, err
+}
+
+// ListBans returns bans newest first, with the banned account's username
+// joined in. activeOnly applies the same unrevoked/unexpired filter as
+// getActiveBan.
+func (d *sqlDB) ListBans(offset, limit int, activeOnly bool) ([]ports.Ban, error) {
+	offset, limit = pageBounds(offset, limit)
+	where := ""
+	if activeOnly {
+		where = fmt.Sprintf("WHERE b.revoked_at IS NULL AND (b.expires_at IS NULL OR b.expires_at > %s)", d.dialect.NowExpr())
+	}
+	query := fmt.Sprintf(`
+		SELECT b.id, b.uuid, b.user_id, b.ip_address, b.reason, b.expires_at, b.revoked_at, b.created_at, u.username
+		FROM bans b LEFT JOIN users u ON u.id = b.user_id
+		%s
+		ORDER BY b.created_at DESC, b.id DESC LIMIT ? OFFSET ?`, where)
+	rows, err := d.db.Query(d.dialect.Rebind(query), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bans []ports.Ban
+	for rows.Next() {
+		var b ports.Ban
+		if err := rows.Scan(&b.ID, &b.UUID, &b.UserID, &b.IPAddress, &b.Reason, &b.ExpiresAt, &b.RevokedAt, &b.CreatedAt, &b.Username); err != nil {
+			return nil, err
+		}
+		bans = append(bans, b)
+	}
+	return bans, rows.Err()
+}
+
 func (d *sqlDB) getAppID(appName string) (int64, error) {
 	var id int64
 	err := d.db.QueryRow(d.dialect.Rebind("SELECT id FROM applications WHERE name = ?"), appName).Scan(&id)
@@ -225,6 +289,37 @@ func (d *sqlDB) DeletePlayerAttribute(appName, userID, attrName string) error {
 	return err
 }
 
+// --- DBUser attributes ---
+
+func (d *sqlDB) SetUserAttribute(userID, attrName string, value lingo.LValue) error {
+	jsonBytes, err := lingo.MarshalLValue(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.db.Exec(d.dialect.Rebind(`
+		INSERT INTO user_attributes (user_id, attr_name, value_json)
+		VALUES (?, ?, ?)
+		ON CONFLICT(user_id, attr_name) DO UPDATE SET value_json=excluded.value_json`),
+		userID, attrName, string(jsonBytes))
+	return err
+}
+
+func (d *sqlDB) GetUserAttribute(userID, attrName string) (lingo.LValue, error) {
+	return d.scanAttribute(
+		d.dialect.Rebind("SELECT value_json FROM user_attributes WHERE user_id = ? AND attr_name = ?"),
+		userID, attrName)
+}
+
+func (d *sqlDB) GetUserAttributeNames(userID string) ([]string, error) {
+	return d.queryNames(d.dialect.Rebind("SELECT attr_name FROM user_attributes WHERE user_id = ?"), userID)
+}
+
+func (d *sqlDB) DeleteUserAttribute(userID, attrName string) error {
+	_, err := d.db.Exec(d.dialect.Rebind("DELETE FROM user_attributes WHERE user_id = ? AND attr_name = ?"), userID, attrName)
+	return err
+}
+
 // --- DBUser ---
 
 func (d *sqlDB) CreateUser(username, passwordHash string, userLevel int) error {
@@ -349,6 +444,60 @@ func (d *sqlDB) RevokeBan(banID int64) error {
 	return nil
 }
 
+// --- Script timers ---
+
+func (d *sqlDB) CreateScriptTimer(t ports.ScriptTimer) error {
+	contentJSON, err := lingo.MarshalLValue(t.Content)
+	if err != nil {
+		return err
+	}
+	_, err = d.db.Exec(
+		d.dialect.Rebind("INSERT INTO script_timers (uuid, subject, sender_id, content_json, fire_at) VALUES (?, ?, ?, ?, ?)"),
+		t.ID, t.Subject, t.SenderID, string(contentJSON), t.FireAt.UTC())
+	return err
+}
+
+func (d *sqlDB) DeleteScriptTimer(id, senderID string) error {
+	query, args := "DELETE FROM script_timers WHERE uuid = ?", []interface{}{id}
+	if senderID != "" {
+		query, args = query+" AND sender_id = ?", append(args, senderID)
+	}
+	result, err := d.db.Exec(d.dialect.Rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ports.ErrTimerNotFound
+	}
+	return nil
+}
+
+func (d *sqlDB) ListScriptTimers() ([]ports.ScriptTimer, error) {
+	rows, err := d.db.Query("SELECT uuid, subject, sender_id, content_json, fire_at FROM script_timers ORDER BY fire_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var timers []ports.ScriptTimer
+	for rows.Next() {
+		var t ports.ScriptTimer
+		var contentJSON string
+		if err := rows.Scan(&t.ID, &t.Subject, &t.SenderID, &contentJSON, &t.FireAt); err != nil {
+			return nil, err
+		}
+		if t.Content, err = lingo.UnmarshalLValue([]byte(contentJSON)); err != nil {
+			return nil, err
+		}
+		timers = append(timers, t)
+	}
+	return timers, rows.Err()
+}
+
 // --- Schema operations ---
 
 func (d *sqlDB) CreateTable(def ports.Table) error {
@@ -536,6 +685,19 @@ func (d *sqlDB) defaultSQL(col ports.Column) string {
 
 // --- helpers ---
 
+// pageBounds normalizes listing arguments for a "LIMIT ? OFFSET ?" clause. A
+// non-positive limit means no upper bound; both backends accept a bound this
+// large, where only SQLite accepts LIMIT -1.
+func pageBounds(offset, limit int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = math.MaxInt32
+	}
+	return offset, limit
+}
+
 func (d *sqlDB) queryNames(query string, args ...interface{}) ([]string, error) {
 	rows, err := d.db.Query(query, args...)
 	if err != nil {
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"net"
 	"net/url"
@@ -20,6 +21,25 @@ type RedisConfig struct {
 	ConnTTL   string
 }
 
+// ScriptHTTPConfig governs mus.http, the scripts' outbound HTTP client.
+// Without allowed hosts scripts get no network access.
+type ScriptHTTPConfig struct {
+	AllowedHosts     []string // host names; ".example.com" also allows subdomains
+	MaxResponseBytes int
+	MaxConcurrent    int // requests in flight per script
+}
+
+// ScriptLimitsConfig caps each script execution beyond SCRIPT_TIMEOUT. 0
+// leaves a cap off.
+type ScriptLimitsConfig struct {
+	CallStackSize   int // nested Lua calls
+	RegistrySize    int // Lua value stack slots
+	MaxMemory       int // approximate bytes a script's values may hold
+	MaxInstructions int
+	MaxMessageBytes int // encoded content of a response or sent message
+	MaxSends        int // mus.sendMessage/broadcast calls per execution
+}
+
 type RabbitMQConfig struct {
 	Host     string
 	Port     string
@@ -82,6 +102,12 @@ type ServerConfig struct {
 	SessionStoreType  string
 	ScriptsPath       string
 	ScriptTimeout     int
+	ScriptWorkers     int
+	ScriptQueueSize   int
+	ScriptReload      int
+	ScriptCheck       string
+	ScriptHTTP        ScriptHTTPConfig
+	ScriptLimits      ScriptLimitsConfig
 	DisconnectHook    string
 	AuthMode          string
 	Redis             RedisConfig
@@ -99,7 +125,10 @@ type ServerConfig struct {
 	MetricsPort       string
 	MetricsBindAddr   string
 	JobsEnabled       bool
+	JobsCluster       bool
+	NodeID            string
 	DefaultMovieID    string
+	GroupAttrNotify   []string
 	SMTPHost          string
 	SMTPPort          string
 	SMTPUser          string
@@ -119,14 +148,16 @@ var defaultCommandLevels = map[string]int{
 	"system.movie.getGroups":     20,
 	"system.movie.getGroupCount": 20,
 	// Group
-	"system.group.join":              20,
-	"system.group.leave":             20,
-	"system.group.getUsers":          20,
-	"system.group.getUserCount":      20,
-	"system.group.setAttribute":      20,
-	"system.group.getAttribute":      20,
-	"system.group.deleteAttribute":   20,
-	"system.group.getAttributeNames": 20,
+	"system.group.join":                  20,
+	"system.group.leave":                 20,
+	"system.group.getUsers":              20,
+	"system.group.getUserCount":          20,
+	"system.group.setAttribute":          20,
+	"system.group.getAttribute":          20,
+	"system.group.deleteAttribute":       20,
+	"system.group.getAttributeNames":     20,
+	"system.group.subscribeAttributes":   20,
+	"system.group.unsubscribeAttributes": 20,
 	// User
 	"system.user.getAddress": 20,
 	"system.user.getGroups":  20,
@@ -136,6 +167,12 @@ var defaultCommandLevels = map[string]int{
 	"DBPlayer.setAttribute":      20,
 	"DBPlayer.deleteAttribute":   20,
 	"DBPlayer.getAttributeNames": 20,
+	// DBUser — per-account data across applications; cross-user access is
+	// additionally gated by Authorizer.OwnerOrAdmin, as for DBPlayer.
+	"DBUser.getAttribute":      20,
+	"DBUser.setAttribute":      20,
+	"DBUser.deleteAttribute":   20,
+	"DBUser.getAttributeNames": 20,
 	// DBApplication — app-level config, admin-only (was level 20, any client could
 	// read/modify application attributes over the wire; backlog L25).
 	"DBApplication.getAttribute":      80,
@@ -148,6 +185,11 @@ var defaultCommandLevels = map[string]int{
 	"DBAdmin.createUser":        80,
 	"DBAdmin.deleteUser":        80,
 	"DBAdmin.getUserCount":      80,
+	"DBAdmin.getUsers":          80,
+	"DBAdmin.getApplications":   80,
+	"DBAdmin.getBans":           80,
+	"DBAdmin.setUserLevel":      80,
+	"DBAdmin.setPassword":       80,
 	"DBAdmin.ban":               80,
 	"DBAdmin.revokeBan":         80,
 	// Email
@@ -193,6 +235,10 @@ func LoadServerConfig() ServerConfig {
 		SessionStoreType: getEnv("SESSION_STORE_TYPE", "memory"),
 		ScriptsPath:      getEnv("SCRIPTS_PATH", "external/scripts"),
 		ScriptTimeout:    getEnvInt("SCRIPT_TIMEOUT", 5),
+		ScriptWorkers:    getEnvInt("SCRIPT_WORKERS", 16),
+		ScriptQueueSize:  getEnvInt("SCRIPT_QUEUE_SIZE", 1024),
+		ScriptReload:     getEnvInt("SCRIPT_RELOAD_INTERVAL", 5),
+		ScriptCheck:      getEnv("SCRIPT_CHECK", "warn"),
 		DisconnectHook:   getEnv("DISCONNECT_HOOK", "users/onDisconnect"),
 		AuthMode:         getEnv("AUTH_MODE", "open"),
 		Redis: RedisConfig{
@@ -237,16 +283,43 @@ func LoadServerConfig() ServerConfig {
 	cfg.MetricsPort = getEnv("METRICS_PORT", "")
 	cfg.MetricsBindAddr = getEnv("METRICS_BIND_ADDR", "127.0.0.1")
 	cfg.JobsEnabled = getEnv("JOBS_ENABLED", "1") == "1"
+	// Cluster mode: each scheduled run takes a lease in the shared cache so
+	// only one instance runs it. NODE_ID names this instance in leases.
+	cfg.JobsCluster = getEnv("JOBS_CLUSTER", "0") == "1"
+	cfg.NodeID = getEnv("NODE_ID", "")
+	if cfg.NodeID == "" {
+		cfg.NodeID = defaultNodeID()
+	}
 	// Movie used to resolve @group sends from senders that aren't in any movie
 	// (system.script broadcasts, scheduler jobs). The FSOS client always
 	// connects with movieID "faria".
 	cfg.DefaultMovieID = getEnv("DEFAULT_MOVIE_ID", "faria")
+	// Movies whose groups push attribute changes to every member ("*" = all).
+	// Elsewhere clients opt in per group with system.group.subscribeAttributes.
+	cfg.GroupAttrNotify = getEnvList("GROUP_ATTR_NOTIFY_MOVIES")
 	// SMTP for outbound mail (password recovery). Empty host = email disabled.
 	cfg.SMTPHost = getEnv("SMTP_HOST", "")
 	cfg.SMTPPort = getEnv("SMTP_PORT", "587")
 	cfg.SMTPUser = getEnv("SMTP_USER", "")
 	cfg.SMTPPass = getEnv("SMTP_PASS", "")
 	cfg.SMTPFrom = getEnv("SMTP_FROM", "")
+	// Hosts scripts may call through mus.http. Empty = mus.http disabled.
+	cfg.ScriptHTTP = ScriptHTTPConfig{
+		AllowedHosts:     getEnvList("SCRIPT_HTTP_ALLOWED_HOSTS"),
+		MaxResponseBytes: getEnvInt("SCRIPT_HTTP_MAX_RESPONSE_BYTES", 1<<20),
+		MaxConcurrent:    getEnvInt("SCRIPT_HTTP_MAX_CONCURRENT", 4),
+	}
+	// Per-execution script caps. Content bigger than MAX_MESSAGE_SIZE would
+	// be refused by clients, so that is the default message cap. The memory
+	// cap meters every instruction, so it is opt-in.
+	cfg.ScriptLimits = ScriptLimitsConfig{
+		CallStackSize:   getEnvInt("SCRIPT_MAX_CALL_STACK", 256),
+		RegistrySize:    getEnvInt("SCRIPT_MAX_REGISTRY", 5120),
+		MaxMemory:       getEnvInt("SCRIPT_MAX_MEMORY", 0),
+		MaxInstructions: getEnvInt("SCRIPT_MAX_INSTRUCTIONS", 0),
+		MaxMessageBytes: getEnvInt("SCRIPT_MAX_MESSAGE_BYTES", cfg.MaxMessageSize),
+		MaxSends:        getEnvInt("SCRIPT_MAX_SENDS", 1000),
+	}
 	cfg.CommandLevels = loadCommandLevels()
 
 	return cfg
@@ -263,8 +336,7 @@ func loadCommandLevels() map[string]int {
 	// Build reverse lookup: normalized subject → original subject
 	lookup := make(map[string]string, len(defaultCommandLevels))
 	for subject := range defaultCommandLevels {
-		normalized := strings.ToUpper(strings.ReplaceAll(subject, ".", "_"))
-		lookup[normalized] = subject
+		lookup[commandLevelEnvSuffix(subject)] = subject
 	}
 
 	for _, env := range os.Environ() {
@@ -289,6 +361,39 @@ func loadCommandLevels() map[string]int {
 	return levels
 }
 
+// CommandLevelFromEnv reads the USERLEVEL_ override for a command the
+// defaults do not list, such as an extension subject. A trailing "*" is
+// spelled ALL: game.shop.* is read from USERLEVEL_GAME_SHOP_ALL.
+func CommandLevelFromEnv(subject string) (int, bool) {
+	val, ok := os.LookupEnv("USERLEVEL_" + commandLevelEnvSuffix(subject))
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// commandLevelEnvSuffix maps a subject to its USERLEVEL_ variable suffix.
+func commandLevelEnvSuffix(subject string) string {
+	if strings.HasSuffix(subject, "*") {
+		subject = strings.TrimSuffix(subject, "*") + "ALL"
+	}
+	return strings.ToUpper(strings.ReplaceAll(subject, ".", "_"))
+}
+
+// defaultNodeID is the hostname plus the process ID, unique per instance even
+// when several share a host.
+func defaultNodeID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "node"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
 func getEnv(key, fallback string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -296,6 +401,17 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// getEnvList splits a comma-separated env var, dropping empty entries.
+func getEnvList(key string) []string {
+	var out []string
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
 func getEnvInt(key string, fallback int) int {
 	if value, exists := os.LookupEnv(key); exists {
 		if n, err := strconv.Atoi(value); err == nil {
@@ -5,6 +5,9 @@ import "time"
 type Cache interface {
 	Get(key string) ([]byte, error)
 	Set(key string, value []byte, ttl time.Duration) error
+	// SetNX stores value only when key is absent or expired, atomically, and
+	// reports whether it did.
+	SetNX(key string, value []byte, ttl time.Duration) (bool, error)
 	Delete(key string) error
 	Exists(key string) (bool, error)
 	SetAdd(key, member string) error
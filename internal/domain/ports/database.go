@@ -25,6 +25,19 @@ type Ban struct {
 	ExpiresAt *time.Time
 	RevokedAt *time.Time
 	CreatedAt time.Time
+	// Username is the banned account's username, resolved by ListBans; nil
+	// for IP-only bans and on the single-ban lookups.
+	Username *string
+}
+
+// ScriptTimer is a script run set for later with mus.timer.after. Content is
+// the payload the script gets from mus.getContent().
+type ScriptTimer struct {
+	ID       string
+	Subject  string
+	SenderID string
+	Content  lingo.LValue
+	FireAt   time.Time
 }
 
 const DefaultUserLevel = 20
@@ -33,12 +46,19 @@ var (
 	ErrUserNotFound       = errors.New("user not found")
 	ErrBanNotFound        = errors.New("ban not found")
 	ErrInvalidCredentials = errors.New("invalid credentials format")
+	ErrTimerNotFound      = errors.New("timer not found")
 )
 
 type DBAdapter interface {
 	// DBAdmin
 	CreateApplication(appName string) error
 	DeleteApplication(appName string) error
+	// Listings are paginated with offset/limit; a non-positive limit returns
+	// every row from offset on.
+	ListApplications(offset, limit int) ([]string, error)
+	ListUsers(offset, limit int) ([]User, error)
+	CountUsers() (int, error)
+	ListBans(offset, limit int, activeOnly bool) ([]Ban, error)
 
 	// DBApplication (global app data)
 	SetApplicationAttribute(appName, attrName string, value lingo.LValue) error
@@ -52,6 +72,12 @@ type DBAdapter interface {
 	GetPlayerAttributeNames(appName, userID string) ([]string, error)
 	DeletePlayerAttribute(appName, userID, attrName string) error
 
+	// DBUser attributes (persistent per userID, shared across applications)
+	SetUserAttribute(userID, attrName string, value lingo.LValue) error
+	GetUserAttribute(userID, attrName string) (lingo.LValue, error)
+	GetUserAttributeNames(userID string) ([]string, error)
+	DeleteUserAttribute(userID, attrName string) error
+
 	// DBUser (authentication)
 	CreateUser(username, passwordHash string, userLevel int) error
 	GetUser(username string) (*User, error)
@@ -65,6 +91,13 @@ type DBAdapter interface {
 	GetActiveBanByIP(ipAddress string) (*Ban, error)
 	RevokeBan(banID int64) error
 
+	// Script timers (mus.timer). DeleteScriptTimer returns ErrTimerNotFound
+	// when the timer is gone, so deleting claims a timer exactly once; a
+	// non-empty senderID deletes it only when that sender set it.
+	CreateScriptTimer(t ScriptTimer) error
+	DeleteScriptTimer(id, senderID string) error
+	ListScriptTimers() ([]ScriptTimer, error)
+
 	// Schema operations — used by migrations for schema changes.
 	CreateTable(def Table) error
 	DropTable(name string) error
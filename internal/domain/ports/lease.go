@@ -0,0 +1,14 @@
+package ports
+
+import "time"
+
+// LeaseStore grants exclusive, time-limited leases shared by every server
+// instance, so work that must happen once per cluster happens once. A lease
+// expires after its TTL whether or not its holder is still alive.
+type LeaseStore interface {
+	// Acquire takes key for owner and reports whether it got it; false means
+	// another owner holds an unexpired lease.
+	Acquire(key, owner string, ttl time.Duration) (bool, error)
+	// Holder returns the owner of key, or "" when it is free.
+	Holder(key string) (string, error)
+}
@@ -1,8 +1,16 @@
 package ports
 
+import "time"
+
 type Metrics interface {
 	IncrementMessages()
 	IncrementErrors()
 	IncrementRateLimited()
 	IncrementBannedConns()
+
+	// Script execution (services.ScriptExecutor)
+	SetScriptQueueDepth(depth int)
+	ObserveScriptWait(d time.Duration)
+	ObserveScriptExecution(d time.Duration)
+	IncrementScriptsRejected()
 }
@@ -0,0 +1,44 @@
+package ports
+
+import "fsos-server/internal/domain/types/lingo"
+
+// MovieDirectory exposes the live movies and their groups to scripts, with
+// the semantics of the system.movie.* and system.group.* commands: a user's
+// movie is the one they logged on to, joining a group creates it, and group
+// attribute writes notify members like a client's setAttribute would.
+type MovieDirectory interface {
+	// UserMovie returns the movie userID is logged on to.
+	UserMovie(userID string) (string, error)
+	// UserGroups lists the groups userID is in within its movie.
+	UserGroups(userID string) ([]string, error)
+	// UserLevel is userID's session user level, as commands are checked
+	// against it.
+	UserLevel(userID string) int
+	MovieUsers(movieID string) ([]string, error)
+	MovieGroups(movieID string) ([]string, error)
+
+	// JoinGroup fails when userID is not in the movie or a beforeJoinGroup
+	// hook vetoes the join.
+	JoinGroup(movieID, groupName, userID string) error
+	LeaveGroup(movieID, groupName, userID string) error
+	GroupMembers(movieID, groupName string) ([]string, error)
+
+	// Group attributes; the group must exist. author is reported to members
+	// in the groupAttributeChanged notification.
+	GroupAttribute(movieID, groupName, attrName string) (lingo.LValue, error)
+	SetGroupAttribute(movieID, groupName, attrName string, value lingo.LValue, author string) error
+	DeleteGroupAttribute(movieID, groupName, attrName, author string) error
+	GroupAttributeNames(movieID, groupName string) ([]string, error)
+
+	// CanActOn reports whether a script running for actorID may touch
+	// targetUserID's session or group membership. An empty targetUserID asks
+	// about data no single user owns, such as another movie's groups.
+	CanActOn(actorID, targetUserID string) bool
+}
+
+// MovieDirectoryUser is implemented by script engines that expose a
+// MovieDirectory to scripts. The handler factory binds one once the movie and
+// group managers exist.
+type MovieDirectoryUser interface {
+	UseMovieDirectory(movies MovieDirectory)
+}
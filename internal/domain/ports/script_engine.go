@@ -1,6 +1,16 @@
 package ports
 
-import "fsos-server/internal/domain/types/lingo"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"fsos-server/internal/domain/types/lingo"
+)
+
+// ErrScriptQueueFull is returned by a bounded ScriptEngine when it cannot take
+// more work; callers should answer "server busy" rather than wait.
+var ErrScriptQueueFull = errors.New("script queue full")
 
 // ScriptMessage holds the fields a script needs from a parsed message.
 // Protocol-agnostic — the handler extracts these from whatever protocol it handles.
@@ -8,13 +18,177 @@ type ScriptMessage struct {
 	Subject  string
 	SenderID string
 	Content  lingo.LValue
+	// Interception is set when the script runs as a message interceptor
+	// (interceptors/*.lua); Content is then the intercepted content.
+	Interception *ScriptInterception
+	// System marks a run the server starts on its own (a scheduled job, a
+	// queue consumer, the serverStart hook) rather than for a client.
+	System bool
+	// Nested marks a run its caller waits on, such as a before-hook. Inside
+	// ScriptInliner.Inline for the same sender it runs at once instead of
+	// queueing behind the script that is waiting for it.
+	Nested bool
 }
 
+// ScriptInterception describes the message an interceptor script inspects.
+type ScriptInterception struct {
+	Phase     string // "before" or "after" routing
+	Subject   string
+	Recipient string
+}
+
+// ScriptResult is what a script produced. The main reply goes to the sender
+// on Subject (the script name when empty) with ErrCode; NoReply suppresses it.
+// Replies are further messages the script queued with mus.reply.
 type ScriptResult struct {
 	Content lingo.LValue
+	ErrCode int32
+	Subject string
+	NoReply bool
+	Replies []ScriptReply
+	// Verdict is an interceptor script's decision; nil allows the message
+	// unchanged.
+	Verdict *InterceptVerdict
+}
+
+// ScriptReply is one extra message from a script, delivered after it ends.
+// An empty Recipient means the sender.
+type ScriptReply struct {
+	Recipient string
+	Subject   string
+	ErrCode   int32
+	Content   lingo.LValue
+}
+
+// InterceptVerdict is what an interceptor script decided through mus.intercept.
+type InterceptVerdict struct {
+	Drop    bool
+	ErrCode int32        // non-zero rejects the message with this error code
+	Content lingo.LValue // non-nil replaces the intercepted content
 }
 
 type ScriptEngine interface {
 	HasScript(subject string) bool
 	Execute(msg *ScriptMessage) (*ScriptResult, error)
 }
+
+// ScriptPolicy is what a script's "-- @script" header asks of the clients
+// invoking it. The zero value lets everyone run it as often as they like.
+type ScriptPolicy struct {
+	Level      int           // minimum session user level
+	Timeout    time.Duration // overrides the engine's default timeout when non-zero
+	Rate       int           // invocations per RateWindow and user; 0 is unlimited
+	RateWindow time.Duration
+	// Script identifies the script the subject resolved to, so subjects
+	// routed to the same script share its rate. Empty when the engine does
+	// not route subjects.
+	Script string
+}
+
+// ScriptPolicySource is implemented by engines that read script headers. An
+// error means the script cannot run (e.g. it does not compile).
+type ScriptPolicySource interface {
+	ScriptPolicy(subject string) (ScriptPolicy, error)
+}
+
+// ScriptReloader is implemented by engines that cache compiled scripts, so a
+// file watcher can drive reloads. Paths are script file paths on disk.
+type ScriptReloader interface {
+	// Reload syntax-checks and recompiles path. On error the engine keeps
+	// running the last version that compiled.
+	Reload(path string) error
+	// Forget drops whatever the engine cached for a deleted file.
+	Forget(path string)
+}
+
+// ScriptProblem is a script file that cannot run as written: it does not
+// compile or one of its headers is malformed. Line is 0 when unknown.
+type ScriptProblem struct {
+	Path    string
+	Line    int
+	Message string
+}
+
+func (p ScriptProblem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", p.Path, p.Line, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", p.Path, p.Message)
+}
+
+// ScriptValidator is implemented by engines that can check every script up
+// front, so a broken file shows at startup rather than when a player first
+// runs it.
+type ScriptValidator interface {
+	// ValidateScripts compiles every .lua file under the scripts directory
+	// and reports the ones that fail. The error is for an unreadable
+	// directory.
+	ValidateScripts() ([]ScriptProblem, error)
+	// DisableBrokenScripts makes HasScript report subjects whose script has
+	// never compiled as missing, until a version that compiles is loaded.
+	DisableBrokenScripts()
+}
+
+// ScriptModerationUser is implemented by engines that let scripts kick, time
+// out and ban users. The connection pool and timer manager are built after
+// the engine, so startup binds them once they exist.
+type ScriptModerationUser interface {
+	UseModeration(conns ConnectionWriter, timers TimerManager)
+}
+
+// ScriptTimerUser is implemented by engines that let scripts set timers
+// (mus.timer). The timers run scripts through the executor, which wraps the
+// engine, so startup binds them afterwards.
+type ScriptTimerUser interface {
+	UseScriptTimers(timers ScriptTimers)
+}
+
+// SystemCommandRunner runs a built-in System command for senderID as if they
+// had sent it, and returns the error code and content of its answer.
+type SystemCommandRunner interface {
+	RunSystemCommand(senderID, subject string, content lingo.LValue) (int32, lingo.LValue, error)
+}
+
+// SystemCommandUser is implemented by engines that let scripts run System
+// commands. The handler factory binds the runner once the dispatcher exists.
+type SystemCommandUser interface {
+	UseSystemCommands(runner SystemCommandRunner)
+}
+
+// ScriptSubmitter is implemented by engines that queue scripts. Submit queues
+// msg without waiting for it to run and reports the outcome to done; scripts
+// submitted one after another run in that order. It fails at once with
+// ErrScriptQueueFull when the queue has no room.
+type ScriptSubmitter interface {
+	Submit(msg *ScriptMessage, done func(*ScriptResult, error)) error
+}
+
+// ScriptCallGuard decides whether a script running for senderID may run
+// subject with mus.call, as senderID could by sending it to system.script:
+// internal scripts (hooks, interceptors) are refused and the callee's
+// @script level and rate apply. The error says why not.
+type ScriptCallGuard interface {
+	CheckScriptCall(senderID, subject string) error
+}
+
+// ScriptCallGuardUser is implemented by engines that let scripts call each
+// other. The handler factory binds the guard once the dispatcher exists.
+type ScriptCallGuardUser interface {
+	UseScriptCallGuard(guard ScriptCallGuard)
+}
+
+// ScriptInliner is implemented by engines that queue a sender's scripts
+// behind each other. A running script that calls into code which runs another
+// script for senderID and waits for it (a before-hook fired by mus.system, or
+// by mus.group.join for the user joining) does so inside Inline, where that
+// script, marked Nested, runs at once instead of waiting behind senderID's
+// queued work.
+type ScriptInliner interface {
+	Inline(senderID string, fn func())
+}
+
+// ScriptInlinerUser is implemented by engines that make such calls. Startup
+// binds the queueing engine wrapping them.
+type ScriptInlinerUser interface {
+	UseInliner(inliner ScriptInliner)
+}
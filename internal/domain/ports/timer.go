@@ -1,5 +1,11 @@
 package ports
 
+import (
+	"time"
+
+	"fsos-server/internal/domain/types/lingo"
+)
+
 type TimerManager interface {
 	SetServerKillTimer(minutes int)
 	CancelServerKillTimer()
@@ -7,3 +13,16 @@ type TimerManager interface {
 	CancelUserKillTimer(clientID string)
 	Stop()
 }
+
+// ScriptTimers runs a script subject once, later, with a given payload. Timers
+// are stored, so one pending across a restart fires once after it.
+type ScriptTimers interface {
+	// After sets a timer running subject for senderID after delay and
+	// returns its ID.
+	After(delay time.Duration, subject, senderID string, content lingo.LValue) (string, error)
+	// Cancel stops a pending timer; ErrTimerNotFound when it already fired
+	// or never existed. A non-empty senderID may only cancel the timers it
+	// set: anyone else's is ErrTimerNotFound too. The server, passing "",
+	// may cancel any.
+	Cancel(id, senderID string) error
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"maps"
+
 	"fsos-server/internal/domain/ports"
 )
 
@@ -15,6 +17,7 @@ const defaultAdminLevel = 80
 type Authorizer struct {
 	sessions      ports.SessionStore
 	commandLevels map[string]int
+	ownsLevels    bool // commandLevels is a private copy, not the caller's map
 }
 
 func NewAuthorizer(sessions ports.SessionStore, commandLevels map[string]int) *Authorizer {
@@ -40,6 +43,24 @@ func (a *Authorizer) CanRun(senderID, command string) bool {
 	return a.UserLevel(senderID) >= requiredLevel
 }
 
+// DeclareCommandLevel sets the level of a command the configuration does not
+// already list, so an explicit configuration always wins. The first
+// declaration copies the level table, leaving the configured map untouched.
+// Call it during startup only; the level table is not synchronized.
+func (a *Authorizer) DeclareCommandLevel(command string, level int) {
+	if _, ok := a.commandLevels[command]; ok {
+		return
+	}
+	if !a.ownsLevels {
+		a.commandLevels = maps.Clone(a.commandLevels)
+		if a.commandLevels == nil {
+			a.commandLevels = make(map[string]int)
+		}
+		a.ownsLevels = true
+	}
+	a.commandLevels[command] = level
+}
+
 // AdminLevel is the level required to act on data the caller does not own. It
 // tracks the configured DBAdmin level (default 80) so cross-user player-data
 // access needs the same privilege as user administration.
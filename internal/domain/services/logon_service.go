@@ -30,6 +30,9 @@ const (
 	LogonRefused
 	// LogonInternalError: an infrastructure failure interrupted the attempt.
 	LogonInternalError
+	// LogonVetoed: the credentials were fine but the LogonGuard refused;
+	// LogonResult.Err carries the guard's reason.
+	LogonVetoed
 )
 
 // LogonCredentials are the parsed logon credentials. How they were encoded on
@@ -60,8 +63,22 @@ type LogonResult struct {
 	UserID    string
 	UserLevel int
 	MovieID   string
+	Err       error // set with LogonVetoed
 }
 
+// LogonAttempt describes an authenticated logon about to take over a session.
+type LogonAttempt struct {
+	ConnectionID string
+	IP           string
+	UserID       string
+	MovieID      string
+	UserLevel    int
+}
+
+// LogonGuard gets the last word on an authenticated logon; a non-nil error
+// vetoes it (game rules such as closed rooms or maintenance windows).
+type LogonGuard func(LogonAttempt) error
+
 // LogonService owns the logon use case: auth-mode policy, credential
 // validation, ban rejection, the session-takeover guard, connection
 // remapping, and session (re-)registration under the authenticated identity.
@@ -72,6 +89,7 @@ type LogonService struct {
 	logger       ports.Logger
 	mode         string // "none", "open" (default), or "strict"
 	defaultLevel int
+	guard        LogonGuard
 }
 
 func NewLogonService(
@@ -92,6 +110,12 @@ func NewLogonService(
 	}
 }
 
+// SetGuard installs the guard consulted after authentication and before any
+// session state is taken over. Call it before serving.
+func (s *LogonService) SetGuard(guard LogonGuard) {
+	s.guard = guard
+}
+
 // Logon runs the full logon use case and reports the outcome. On LogonOK the
 // session is registered under the effective userID with the user level
 // stamped; on any other code no session state has been taken over.
@@ -194,6 +218,11 @@ func (s *LogonService) validateCredentials(user *ports.User, password, connectio
 // authentication: takeover guard, connection remap, re-registration under
 // userID (preserving the client's real IP), and user-level stamping.
 func (s *LogonService) establishSession(connectionID, userID, movieID string, userLevel int) LogonResult {
+	ip := connectionID
+	if existing, _ := s.sessions.GetConnection(connectionID); existing != nil && existing.IP != "" {
+		ip = existing.IP
+	}
+
 	// Reject a Logon for a userID that already has a live session: otherwise a
 	// second client could remap the connection and hijack/evict the first. (H3)
 	if userID != connectionID {
@@ -206,6 +235,18 @@ func (s *LogonService) establishSession(connectionID, userID, movieID string, us
 		}
 	}
 
+	if s.guard != nil {
+		attempt := LogonAttempt{ConnectionID: connectionID, IP: ip, UserID: userID, MovieID: movieID, UserLevel: userLevel}
+		if err := s.guard(attempt); err != nil {
+			s.logger.Info("Logon vetoed", map[string]interface{}{
+				"client": connectionID,
+				"userID": userID,
+				"reason": err.Error(),
+			})
+			return LogonResult{Code: LogonVetoed, UserID: userID, MovieID: movieID, Err: err}
+		}
+	}
+
 	// Remap the connection so future messages use userID. Do this before touching
 	// the session store: if the slot is already bound to another connection (race
 	// with a concurrent logon) RemapClientID returns false and we refuse rather
@@ -222,10 +263,6 @@ func (s *LogonService) establishSession(connectionID, userID, movieID string, us
 
 	// Re-register the session under userID, preserving the client's real IP from the
 	// initial registration instead of storing the connection id in the IP field (L5).
-	ip := connectionID
-	if existing, _ := s.sessions.GetConnection(connectionID); existing != nil && existing.IP != "" {
-		ip = existing.IP
-	}
 	s.sessions.UnregisterConnection(connectionID)
 	s.sessions.RegisterConnection(userID, ip)
 
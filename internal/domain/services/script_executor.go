@@ -0,0 +1,247 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"fsos-server/internal/domain/ports"
+)
+
+// ScriptExecutor bounds script execution: a fixed number of workers run
+// scripts from a queue of limited size, and scripts of the same sender run one
+// at a time in submission order while different senders proceed in parallel.
+// When the queue is full Execute fails fast with ports.ErrScriptQueueFull
+// instead of piling up VMs. It decorates a ports.ScriptEngine, so the
+// Dispatcher, queue consumers, scheduler and hooks all share one budget.
+type ScriptExecutor struct {
+	engine    ports.ScriptEngine
+	metrics   ports.Metrics
+	queueSize int
+
+	mu      sync.Mutex
+	pending map[string][]*scriptJob // per-sender FIFO; present while the sender has queued or running work
+	inline  map[string]int          // Inline calls in progress by sender; their nested scripts skip the queue
+	queued  int
+	seq     uint64
+	closed  bool
+
+	// ready holds senders with a runnable head job. A sender is in ready at
+	// most once and only while it has queued work, so len(ready) <= queueSize.
+	ready chan string
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+type scriptJob struct {
+	msg      *ports.ScriptMessage
+	queuedAt time.Time
+	result   *ports.ScriptResult
+	err      error
+	finished chan struct{}
+	done     func(*ports.ScriptResult, error) // Submit's callback
+}
+
+// NewScriptExecutor starts workers goroutines over engine. metrics may be nil.
+func NewScriptExecutor(engine ports.ScriptEngine, workers, queueSize int, metrics ports.Metrics) *ScriptExecutor {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	x := &ScriptExecutor{
+		engine:    engine,
+		metrics:   metrics,
+		queueSize: queueSize,
+		pending:   make(map[string][]*scriptJob),
+		inline:    make(map[string]int),
+		ready:     make(chan string, queueSize),
+		done:      make(chan struct{}),
+	}
+	x.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go x.work()
+	}
+	return x
+}
+
+func (x *ScriptExecutor) HasScript(subject string) bool {
+	return x.engine.HasScript(subject)
+}
+
+// ScriptPolicy forwards to the engine when it reads script headers; other
+// engines' scripts get the zero policy.
+func (x *ScriptExecutor) ScriptPolicy(subject string) (ports.ScriptPolicy, error) {
+	if src, ok := x.engine.(ports.ScriptPolicySource); ok {
+		return src.ScriptPolicy(subject)
+	}
+	return ports.ScriptPolicy{}, nil
+}
+
+// Execute queues msg behind the sender's earlier scripts and waits for it to
+// run. Messages without a sender are not ordered against anything.
+func (x *ScriptExecutor) Execute(msg *ports.ScriptMessage) (*ports.ScriptResult, error) {
+	job := &scriptJob{msg: msg, queuedAt: time.Now(), finished: make(chan struct{})}
+
+	x.mu.Lock()
+	if x.closed {
+		x.mu.Unlock()
+		return nil, fmt.Errorf("script executor stopped")
+	}
+	if msg.Nested && msg.SenderID != "" && x.inline[msg.SenderID] > 0 {
+		x.mu.Unlock()
+		return x.execute(msg)
+	}
+	x.mu.Unlock()
+	if err := x.enqueue(job); err != nil {
+		return nil, err
+	}
+	<-job.finished
+	return job.result, job.err
+}
+
+// Submit queues msg like Execute but returns without waiting for it
+// (ports.ScriptSubmitter). done, when non-nil, gets the outcome on the worker
+// that ran it. Scripts submitted one after another run in that order, behind
+// the sender's earlier work, even when submitted from inside a script.
+func (x *ScriptExecutor) Submit(msg *ports.ScriptMessage, done func(*ports.ScriptResult, error)) error {
+	return x.enqueue(&scriptJob{msg: msg, queuedAt: time.Now(), finished: make(chan struct{}), done: done})
+}
+
+// enqueue puts job behind its sender's queued work, or fails when the queue
+// is full or the executor stopped.
+func (x *ScriptExecutor) enqueue(job *scriptJob) error {
+	x.mu.Lock()
+	if x.closed {
+		x.mu.Unlock()
+		return fmt.Errorf("script executor stopped")
+	}
+	if x.queued >= x.queueSize {
+		x.mu.Unlock()
+		if x.metrics != nil {
+			x.metrics.IncrementScriptsRejected()
+		}
+		return ports.ErrScriptQueueFull
+	}
+	key := job.msg.SenderID
+	if key == "" {
+		x.seq++
+		key = fmt.Sprintf("\x00%d", x.seq)
+	}
+	queue, active := x.pending[key]
+	x.pending[key] = append(queue, job)
+	x.queued++
+	depth := x.queued
+	if !active {
+		x.ready <- key
+	}
+	x.mu.Unlock()
+
+	if x.metrics != nil {
+		x.metrics.SetScriptQueueDepth(depth)
+	}
+	return nil
+}
+
+// Inline runs fn with senderID's nested scripts skipping the queue
+// (ports.ScriptInliner): a script calling code that runs another script for
+// senderID and waits for it would otherwise wait behind itself, or behind
+// senderID's queued work. Only messages marked Nested skip it, the runs a
+// caller waits on; work for senderID arriving meanwhile, such as new
+// messages, timers and after-hooks, keeps its order.
+func (x *ScriptExecutor) Inline(senderID string, fn func()) {
+	x.mu.Lock()
+	x.inline[senderID]++
+	x.mu.Unlock()
+	defer func() {
+		x.mu.Lock()
+		if x.inline[senderID]--; x.inline[senderID] == 0 {
+			delete(x.inline, senderID)
+		}
+		x.mu.Unlock()
+	}()
+	fn()
+}
+
+// Stop lets running scripts finish and stops the workers. Scripts still queued
+// fail; Execute calls after Stop fail immediately.
+func (x *ScriptExecutor) Stop() {
+	x.mu.Lock()
+	if x.closed {
+		x.mu.Unlock()
+		return
+	}
+	x.closed = true
+	close(x.done)
+	x.mu.Unlock()
+	x.wg.Wait()
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for key, queue := range x.pending {
+		for _, job := range queue {
+			job.err = fmt.Errorf("script executor stopped")
+			close(job.finished)
+			if job.done != nil {
+				job.done(nil, job.err)
+			}
+		}
+		delete(x.pending, key)
+	}
+}
+
+func (x *ScriptExecutor) work() {
+	defer x.wg.Done()
+	for {
+		select {
+		case <-x.done:
+			return
+		case key := <-x.ready:
+			x.runNext(key)
+		}
+	}
+}
+
+// runNext runs the head job of key and, when more of its jobs are queued,
+// hands the sender back to the ready queue so another worker may continue it.
+func (x *ScriptExecutor) runNext(key string) {
+	x.mu.Lock()
+	job := x.pending[key][0]
+	x.queued--
+	depth := x.queued
+	x.mu.Unlock()
+
+	started := time.Now()
+	if x.metrics != nil {
+		x.metrics.SetScriptQueueDepth(depth)
+		x.metrics.ObserveScriptWait(started.Sub(job.queuedAt))
+	}
+	job.result, job.err = x.execute(job.msg)
+	if x.metrics != nil {
+		x.metrics.ObserveScriptExecution(time.Since(started))
+	}
+	close(job.finished)
+	if job.done != nil {
+		job.done(job.result, job.err)
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if rest := x.pending[key][1:]; len(rest) > 0 {
+		x.pending[key] = rest
+		x.ready <- key
+	} else {
+		delete(x.pending, key)
+	}
+}
+
+// execute shields the worker from a panicking engine.
+func (x *ScriptExecutor) execute(msg *ports.ScriptMessage) (result *ports.ScriptResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("script %q panicked: %v", msg.Subject, r)
+		}
+	}()
+	return x.engine.Execute(msg)
+}
@@ -6,7 +6,9 @@ import (
 )
 
 // LDate represents a Lingo date value. The 8-byte data format is
-// Shockwave/Director-specific and not publicly documented.
+// Shockwave/Director-specific and not publicly documented, so Data is only
+// carried, never read: converting it to a time needs a layout confirmed
+// against dates captured from a client.
 type LDate struct {
 	BaseLValue
 	Data [8]byte
@@ -36,35 +36,35 @@ func LValueToLua(L *lua.LState, val LValue) lua.LValue {
 		}
 		return tbl
 	case *LPoint:
-		tbl := L.NewTable()
+		tbl := taggedTable(L, lingoTagPoint)
 		tbl.RawSetString("locH", LValueToLua(L, v.LocH))
 		tbl.RawSetString("locV", LValueToLua(L, v.LocV))
 		return tbl
 	case *LRect:
-		tbl := L.NewTable()
+		tbl := taggedTable(L, lingoTagRect)
 		tbl.RawSetString("left", LValueToLua(L, v.Left))
 		tbl.RawSetString("top", LValueToLua(L, v.Top))
 		tbl.RawSetString("right", LValueToLua(L, v.Right))
 		tbl.RawSetString("bottom", LValueToLua(L, v.Bottom))
 		return tbl
 	case *LColor:
-		tbl := L.NewTable()
+		tbl := taggedTable(L, lingoTagColor)
 		tbl.RawSetString("red", lua.LNumber(v.Red))
 		tbl.RawSetString("green", lua.LNumber(v.Green))
 		tbl.RawSetString("blue", lua.LNumber(v.Blue))
 		return tbl
 	case *LDate:
-		return lua.LString(string(v.Data[:]))
+		return binaryToLua(L, lingoTagDate, v.Data[:])
 	case *L3dVector:
-		tbl := L.NewTable()
+		tbl := taggedTable(L, lingoTagVector)
 		tbl.RawSetString("x", lua.LNumber(v.X))
 		tbl.RawSetString("y", lua.LNumber(v.Y))
 		tbl.RawSetString("z", lua.LNumber(v.Z))
 		return tbl
 	case *L3dTransform:
-		tbl := L.NewTable()
+		tbl := taggedTable(L, lingoTagTransform)
 		for i := 0; i < 16; i++ {
-			tbl.Append(lua.LNumber(v.Matrix[i]))
+			tbl.RawSetInt(i+1, lua.LNumber(v.Matrix[i]))
 		}
 		return tbl
 	case *LPicture:
@@ -78,33 +78,132 @@ func LValueToLua(L *lua.LState, val LValue) lua.LValue {
 	}
 }
 
-// Binary lingo values (media/picture) cross into Lua as a tagged table so a
-// script can pass them through (store, echo, re-send) without losing the wire
-// type — a plain Lua string would come back as LString and break the client's
-// readvalue. The bytes live in `data` as a Lua string (Lua strings are 8-bit
-// clean).
+// Lingo types with no Lua counterpart cross into Lua as a table tagged with
+// their type, so a script can pass them through (store, echo, re-send) without
+// losing the wire type: a point would otherwise come back as a prop list and a
+// picture as a string, breaking the client's readvalue. Binary values keep
+// their bytes in `data` as a Lua string (Lua strings are 8-bit clean).
+// Symbols arriving from Lingo stay plain strings, which scripts compare
+// against, index with and pass to string.*; the tagged symbol form is for
+// sending one (mus.lingo.symbol). Its metatable makes tostring and .. give
+// the name and == hold between two symbols of the same name.
 const (
-	lingoTagKey     = "__lingo"
-	lingoTagMedia   = "media"
-	lingoTagPicture = "picture"
+	lingoTagKey       = "__lingo"
+	lingoTagSymbol    = "symbol"
+	lingoTagPoint     = "point"
+	lingoTagRect      = "rect"
+	lingoTagColor     = "color"
+	lingoTagDate      = "date"
+	lingoTagVector    = "vector"
+	lingoTagTransform = "transform"
+	lingoTagMedia     = "media"
+	lingoTagPicture   = "picture"
 )
 
-func binaryToLua(L *lua.LState, tag string, data []byte) lua.LValue {
+func taggedTable(L *lua.LState, tag string) *lua.LTable {
 	tbl := L.NewTable()
 	tbl.RawSetString(lingoTagKey, lua.LString(tag))
+	return tbl
+}
+
+func binaryToLua(L *lua.LState, tag string, data []byte) lua.LValue {
+	tbl := taggedTable(L, tag)
 	tbl.RawSetString("data", lua.LString(string(data)))
 	return tbl
 }
 
-// binaryFromLuaTable recognizes the tagged-table form and rebuilds the binary
+// SymbolToLua returns the tagged form of symbol name, which converts back to
+// an LSymbol.
+func SymbolToLua(L *lua.LState, name string) lua.LValue {
+	tbl := taggedTable(L, lingoTagSymbol)
+	tbl.RawSetString("name", lua.LString(name))
+	L.SetMetatable(tbl, symbolMetatable(L))
+	return tbl
+}
+
+// LuaSymbolName returns the name of a tagged symbol.
+func LuaSymbolName(lv lua.LValue) (string, bool) {
+	tbl, ok := lv.(*lua.LTable)
+	if !ok || tbl.RawGetString(lingoTagKey) != lua.LString(lingoTagSymbol) {
+		return "", false
+	}
+	name, ok := tbl.RawGetString("name").(lua.LString)
+	return string(name), ok
+}
+
+// symbolMetatableName is the registry key of the metatable symbols share.
+const symbolMetatableName = "lingo.symbol"
+
+func symbolMetatable(L *lua.LState) *lua.LTable {
+	if mt, ok := L.GetTypeMetatable(symbolMetatableName).(*lua.LTable); ok {
+		return mt
+	}
+	// text is what a symbol, or the other operand of .., reads as.
+	text := func(L *lua.LState, lv lua.LValue) string {
+		if name, ok := LuaSymbolName(lv); ok {
+			return name
+		}
+		if lua.LVCanConvToString(lv) {
+			return lua.LVAsString(lv)
+		}
+		L.RaiseError("cannot perform concat operation between symbol and %s", lv.Type().String())
+		return ""
+	}
+	mt := L.NewTypeMetatable(symbolMetatableName)
+	mt.RawSetString("__tostring", L.NewFunction(func(L *lua.LState) int {
+		name, _ := LuaSymbolName(L.Get(1))
+		L.Push(lua.LString(name))
+		return 1
+	}))
+	mt.RawSetString("__eq", L.NewFunction(func(L *lua.LState) int {
+		a, okA := LuaSymbolName(L.Get(1))
+		b, okB := LuaSymbolName(L.Get(2))
+		L.Push(lua.LBool(okA && okB && a == b))
+		return 1
+	}))
+	mt.RawSetString("__concat", L.NewFunction(func(L *lua.LState) int {
+		L.Push(lua.LString(text(L, L.Get(1)) + text(L, L.Get(2))))
+		return 1
+	}))
+	return mt
+}
+
+// taggedFromLuaTable recognizes the tagged-table form and rebuilds the
 // LValue; returns nil when the table isn't tagged.
-func binaryFromLuaTable(tbl *lua.LTable) LValue {
+func taggedFromLuaTable(tbl *lua.LTable) LValue {
 	tag, ok := tbl.RawGetString(lingoTagKey).(lua.LString)
 	if !ok {
 		return nil
 	}
+	field := func(name string) LValue { return LuaToLValue(tbl.RawGetString(name)) }
+	number := func(name string) float64 {
+		n, _ := tbl.RawGetString(name).(lua.LNumber)
+		return float64(n)
+	}
 	data, _ := tbl.RawGetString("data").(lua.LString)
 	switch string(tag) {
+	case lingoTagSymbol:
+		name, _ := tbl.RawGetString("name").(lua.LString)
+		return NewLSymbol(string(name))
+	case lingoTagPoint:
+		return NewLPoint(field("locH"), field("locV"))
+	case lingoTagRect:
+		return NewLRect(field("left"), field("top"), field("right"), field("bottom"))
+	case lingoTagColor:
+		return NewLColor(uint8(number("red")), uint8(number("green")), uint8(number("blue")))
+	case lingoTagDate:
+		var raw [8]byte
+		copy(raw[:], data)
+		return NewLDate(raw)
+	case lingoTagVector:
+		return NewL3dVector(float32(number("x")), float32(number("y")), float32(number("z")))
+	case lingoTagTransform:
+		var matrix [16]float32
+		for i := range matrix {
+			n, _ := tbl.RawGetInt(i + 1).(lua.LNumber)
+			matrix[i] = float32(n)
+		}
+		return NewL3dTransform(matrix)
 	case lingoTagMedia:
 		return NewLMedia([]byte(string(data)))
 	case lingoTagPicture:
@@ -142,9 +241,9 @@ func LuaToLValue(lv lua.LValue) LValue {
 // If the table has sequential integer keys 1..N, it becomes an LList.
 // Otherwise, it becomes an LPropList with LSymbol keys.
 func luaTableToLValue(tbl *lua.LTable) LValue {
-	// Tagged binary tables round-trip back to their lingo type first.
-	if bin := binaryFromLuaTable(tbl); bin != nil {
-		return bin
+	// Tagged tables round-trip back to their lingo type first.
+	if tagged := taggedFromLuaTable(tbl); tagged != nil {
+		return tagged
 	}
 
 	maxN := tbl.MaxN()
@@ -0,0 +1,88 @@
+// Package scriptheader reads the one-line headers Lua scripts declare at
+// their top, such as
+//
+//	-- @job cron="0 3 * * *" tz=Europe/Madrid
+//	-- @script level=80 rate=5/10s
+//	-- @interceptor phase=both
+//
+// A header is a comment whose first word is @<tag>, followed by key=value
+// options; values containing spaces are quoted. Each reader interprets its
+// own options.
+package scriptheader
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// MaxLines is how far from the top of a script its header may be.
+const MaxLines = 20
+
+var optionRe = regexp.MustCompile(`(\w+)\s*=\s*(?:"([^"]*)"|(\S+))`)
+
+// Option is one key=value of a header, its value unquoted.
+type Option struct {
+	Key   string
+	Value string
+}
+
+// Header is a script's @<tag> line.
+type Header struct {
+	Line    int // 1-based
+	Options []Option
+}
+
+// Get returns the value of the last option named key.
+func (h Header) Get(key string) (string, bool) {
+	for i := len(h.Options) - 1; i >= 0; i-- {
+		if h.Options[i].Key == key {
+			return h.Options[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// Find returns the first @tag header in the first MaxLines lines of r.
+func Find(r io.Reader, tag string) (Header, bool) {
+	scanner := bufio.NewScanner(r)
+	for i := 0; i < MaxLines && scanner.Scan(); i++ {
+		if rest, ok := tagged(scanner.Text(), tag); ok {
+			return Header{Line: i + 1, Options: parseOptions(rest)}, true
+		}
+	}
+	return Header{}, false
+}
+
+// FindFile is Find on the file at path. An unreadable file has no header.
+func FindFile(path, tag string) (Header, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Header{}, false
+	}
+	defer f.Close()
+	return Find(f, tag)
+}
+
+// tagged returns what follows "-- @tag" when line is that header.
+func tagged(line, tag string) (string, bool) {
+	comment, ok := strings.CutPrefix(strings.TrimSpace(line), "--")
+	if !ok {
+		return "", false
+	}
+	rest, ok := strings.CutPrefix(strings.TrimLeft(comment, "- \t"), "@"+tag)
+	if !ok || rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return "", false
+	}
+	return rest, true
+}
+
+func parseOptions(s string) []Option {
+	var options []Option
+	for _, m := range optionRe.FindAllStringSubmatch(s, -1) {
+		options = append(options, Option{Key: m[1], Value: m[2] + m[3]})
+	}
+	return options
+}
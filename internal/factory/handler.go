@@ -5,6 +5,7 @@ import (
 
 	"fsos-server/internal/adapters/inbound"
 	"fsos-server/internal/adapters/inbound/mus"
+	"fsos-server/internal/config"
 	"fsos-server/internal/domain/ports"
 	"fsos-server/internal/domain/services"
 )
@@ -25,15 +26,61 @@ func NewHandler(
 	commandLevels map[string]int,
 	emailSender ports.EmailSender,
 	timerManager ports.TimerManager,
+	groupAttrNotify []string,
+	extensions []mus.Extension,
+	interceptors []mus.Interceptor,
+	scriptMovies ports.MovieDirectoryUser,
+	scriptSystem ports.SystemCommandUser,
 ) (ports.MessageHandler, error) {
 	switch protocol {
 	case "smus":
 		movieManager := mus.NewMovieManager(sessionStore, log)
+		if sender != nil {
+			movieManager.EnableGroupAttributeNotifications(sender, groupAttrNotify)
+		}
 		groupManager := mus.NewGroupManager(sessionStore, log)
+		groupManager.UseMovies(movieManager)
 		logonService := services.NewLogonService(db, sessionStore, connWriter, log, authMode, defaultUserLevel)
 		authorizer := services.NewAuthorizer(sessionStore, commandLevels)
 		systemService := mus.NewSystemService(db, sessionStore, log, movieManager, groupManager, connWriter, logonService, authorizer, emailSender, timerManager)
+		if scriptEngine != nil {
+			hooks := mus.NewHooks(scriptEngine, log)
+			movieManager.UseHooks(hooks)
+			groupManager.UseHooks(hooks)
+			logonService.SetGuard(hooks.GuardLogon)
+			systemService.UseHooks(hooks)
+		}
+		if scriptMovies != nil {
+			scriptMovies.UseMovieDirectory(mus.NewScriptDirectory(movieManager, groupManager, sessionStore, authorizer))
+		}
 		dispatcher := mus.NewDispatcher(log, scriptEngine, systemService, sender, queue)
+		if len(extensions) > 0 {
+			registry := mus.NewExtensionRegistry(&mus.ExtensionContext{
+				Sessions: sessionStore,
+				DB:       db,
+				Sender:   sender,
+				Authz:    authorizer,
+				Logger:   log,
+			})
+			for _, ext := range extensions {
+				if level, ok := config.CommandLevelFromEnv(ext.Subject); ok {
+					ext.Level = level
+				}
+				if err := registry.Register(ext, systemService.HasHandler); err != nil {
+					return nil, err
+				}
+			}
+			dispatcher.UseExtensions(registry)
+		}
+		for _, i := range interceptors {
+			dispatcher.Use(i)
+		}
+		if scriptSystem != nil {
+			scriptSystem.UseSystemCommands(dispatcher)
+			if calls, ok := scriptSystem.(ports.ScriptCallGuardUser); ok {
+				calls.UseScriptCallGuard(dispatcher)
+			}
+		}
 		return inbound.NewSMUSHandler(log, cipher, dispatcher, allEncrypted), nil
 	default:
 		return nil, fmt.Errorf("unsupported protocol: %s", protocol)
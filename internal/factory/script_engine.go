@@ -2,6 +2,7 @@ package factory
 
 import (
 	"fsos-server/internal/adapters/outbound"
+	"fsos-server/internal/config"
 	"fsos-server/internal/domain/ports"
 )
 
@@ -16,9 +17,28 @@ func NewScriptEngine(
 	sessionStore ports.SessionStore,
 	cache ports.Cache,
 	emailSender ports.EmailSender,
+	httpCfg config.ScriptHTTPConfig,
+	limits config.ScriptLimitsConfig,
 ) ports.ScriptEngine {
 	if scriptsPath == "" {
 		return nil
 	}
-	return outbound.NewLuaScriptEngine(scriptsPath, logger, scriptTimeoutSeconds, publisher, sender, db, queryBuilder, sessionStore, cache, emailSender)
+	engine := outbound.NewLuaScriptEngine(scriptsPath, logger, scriptTimeoutSeconds, publisher, sender, db, queryBuilder, sessionStore, cache, emailSender)
+	engine.UseLimits(outbound.ScriptLimits{
+		CallStackSize:   limits.CallStackSize,
+		RegistrySize:    limits.RegistrySize,
+		MaxMemory:       int64(limits.MaxMemory),
+		MaxInstructions: int64(limits.MaxInstructions),
+		MaxMessageBytes: limits.MaxMessageBytes,
+		MaxSends:        limits.MaxSends,
+	})
+	// mus.http only exists when some host is allowed
+	if len(httpCfg.AllowedHosts) > 0 {
+		engine.UseHTTP(outbound.ScriptHTTPPolicy{
+			AllowedHosts:     httpCfg.AllowedHosts,
+			MaxResponseBytes: int64(httpCfg.MaxResponseBytes),
+			MaxConcurrent:    httpCfg.MaxConcurrent,
+		})
+	}
+	return engine
 }